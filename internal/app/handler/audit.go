@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type AuditHandler interface {
+	List() http.HandlerFunc
+}
+
+type auditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) AuditHandler {
+	return &auditHandler{auditService}
+}
+
+func (h *auditHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		merchantID, err := web.GetUrlPathInt64(r, "merchant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := web.GetPage(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		filter := model.AuditFilter{
+			MerchantID: merchantID,
+			EntityType: web.GetUrlQueryString(r, "entity_type"),
+		}
+		if web.GetUrlQueryString(r, "entity_id") != "" {
+			filter.EntityID, err = web.GetUrlQueryInt64(r, "entity_id")
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		req := model.AuditListRequest{Page: page, Filter: filter}
+		res, info, err := h.auditService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalList(w, r, req.Page, res, info)
+	}
+}
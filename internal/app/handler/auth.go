@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/service"
@@ -8,11 +9,13 @@ import (
 	"github.com/SemmiDev/go-product/internal/validation"
 	"github.com/SemmiDev/go-product/internal/web"
 	"net/http"
-
 )
 
 type AuthHandler interface {
 	Login() http.HandlerFunc
+	LoginCustomer() http.HandlerFunc
+	Refresh() http.HandlerFunc
+	Logout() http.HandlerFunc
 }
 
 func NewAuthHandler(authService service.AuthService) AuthHandler {
@@ -24,6 +27,14 @@ type authHandler struct {
 }
 
 func (h *authHandler) Login() http.HandlerFunc {
+	return h.login(h.authService.Login)
+}
+
+func (h *authHandler) LoginCustomer() http.HandlerFunc {
+	return h.login(h.authService.LoginCustomer)
+}
+
+func (h *authHandler) login(login func(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req model.AuthRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
@@ -38,7 +49,8 @@ func (h *authHandler) Login() http.HandlerFunc {
 			return
 		}
 
-		res, err := h.authService.Login(r.Context(), req)
+		req.CartToken = r.Header.Get(constant.CART_TOKEN_HEADER)
+		res, err := login(r.Context(), req)
 		if err != nil {
 			switch err {
 			case constant.ErrEmailNotRegistered, constant.ErrWrongPassword:
@@ -53,3 +65,61 @@ func (h *authHandler) Login() http.HandlerFunc {
 		web.MarshalPayload(w, http.StatusOK, res)
 	}
 }
+
+func (h *authHandler) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AuthRefreshRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.authService.Refresh(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidRefreshToken, constant.ErrRefreshTokenReused:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *authHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.AuthLogoutRequest
+		if r.ContentLength != 0 {
+			err := json.NewDecoder(r.Body).Decode(&req)
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+				return
+			}
+		}
+
+		err := h.authService.Logout(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type CartHandler interface {
+	Get() http.HandlerFunc
+	AddItem() http.HandlerFunc
+	UpdateItem() http.HandlerFunc
+	RemoveItem() http.HandlerFunc
+	Clear() http.HandlerFunc
+}
+
+type cartHandler struct {
+	cartService service.CartService
+}
+
+func NewCartHandler(cartService service.CartService) CartHandler {
+	return &cartHandler{cartService}
+}
+
+func (h *cartHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.CartGetRequest{Owner: cartOwner(r)}
+		res, err := h.cartService.Get(r.Context(), req)
+		if err != nil {
+			h.marshalCartError(w, err)
+			return
+		}
+
+		h.marshalCart(w, http.StatusOK, res)
+	}
+}
+
+func (h *cartHandler) AddItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CartItemAddRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req.Owner = cartOwner(r)
+		res, err := h.cartService.AddItem(r.Context(), req)
+		if err != nil {
+			h.marshalCartError(w, err)
+			return
+		}
+
+		h.marshalCart(w, http.StatusOK, res)
+	}
+}
+
+func (h *cartHandler) UpdateItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var req model.CartItemUpdateRequest
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req.Owner = cartOwner(r)
+		req.ProductID = productID
+		res, err := h.cartService.UpdateItem(r.Context(), req)
+		if err != nil {
+			h.marshalCartError(w, err)
+			return
+		}
+
+		h.marshalCart(w, http.StatusOK, res)
+	}
+}
+
+func (h *cartHandler) RemoveItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CartItemRemoveRequest{Owner: cartOwner(r), ProductID: productID}
+		res, err := h.cartService.RemoveItem(r.Context(), req)
+		if err != nil {
+			h.marshalCartError(w, err)
+			return
+		}
+
+		h.marshalCart(w, http.StatusOK, res)
+	}
+}
+
+func (h *cartHandler) Clear() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := model.CartClearRequest{Owner: cartOwner(r)}
+		err := h.cartService.Clear(r.Context(), req)
+		if err != nil {
+			h.marshalCartError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// cartOwner reads the anonymous cart token, the service ignores it for signed-in customers.
+func cartOwner(r *http.Request) model.CartOwner {
+	return model.CartOwner{Token: r.Header.Get(constant.CART_TOKEN_HEADER)}
+}
+
+// marshalCart echoes the anonymous cart token so a client can pick up a newly issued one.
+func (h *cartHandler) marshalCart(w http.ResponseWriter, status int, res *model.CartResponse) {
+	if res.Token != "" {
+		w.Header().Set(constant.CART_TOKEN_HEADER, res.Token)
+	}
+	web.MarshalPayload(w, status, res)
+}
+
+func (h *cartHandler) marshalCartError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrForbiddenRole:
+		web.MarshalError(w, http.StatusForbidden, err)
+	case constant.ErrProductNotFound, constant.ErrCartItemNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrCartFull:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type CategoryHandler interface {
+	Create() http.HandlerFunc
+	List() http.HandlerFunc
+	Get() http.HandlerFunc
+	Update() http.HandlerFunc
+	Delete() http.HandlerFunc
+
+	ListProductCategories() http.HandlerFunc
+	SetProductCategories() http.HandlerFunc
+}
+
+type categoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) CategoryHandler {
+	return &categoryHandler{categoryService}
+}
+
+func (h *categoryHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CategoryCreateRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.categoryService.Create(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *categoryHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := h.categoryService.List(r.Context())
+		if err != nil {
+			web.MarshalError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *categoryHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "category_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CategoryGetRequest{ID: id}
+		res, err := h.categoryService.Get(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *categoryHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "category_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CategoryUpdateRequest{ID: id}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.categoryService.Update(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *categoryHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "category_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CategoryDeleteRequest{ID: id}
+		err = h.categoryService.Delete(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *categoryHandler) ListProductCategories() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductCategoriesGetRequest{ProductID: productID}
+		res, err := h.categoryService.ListProductCategories(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *categoryHandler) SetProductCategories() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductCategoriesUpdateRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.categoryService.SetProductCategories(r.Context(), req)
+		if err != nil {
+			h.marshalCategoryError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *categoryHandler) marshalCategoryError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrCategoryNotFound, constant.ErrProductNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrCategoryInvalidSlug:
+		web.MarshalError(w, http.StatusBadRequest, err)
+	case constant.ErrCategoryParentNotFound:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	case constant.ErrCategorySlugTaken, constant.ErrCategoryCycle, constant.ErrCategoryHasChildren, constant.ErrCategoryInUse:
+		web.MarshalError(w, http.StatusConflict, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type CustomerHandler interface {
+	Create() http.HandlerFunc
+	Get() http.HandlerFunc
+	Update() http.HandlerFunc
+}
+
+func NewCustomerHandler(customerService service.CustomerService) CustomerHandler {
+	return &customerHandler{customerService}
+}
+
+type customerHandler struct {
+	customerService service.CustomerService
+}
+
+func (h *customerHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CustomerCreateRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.customerService.Create(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrEmailRegistered:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *customerHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "customer_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CustomerGetRequest{ID: id}
+		res, err := h.customerService.Get(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrCustomerNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *customerHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "customer_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CustomerUpdateRequest{ID: id}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.customerService.Update(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrEmailRegistered:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			case constant.ErrCustomerNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/security/token"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCustomerRepository struct {
+	repository.CustomerRepository
+	customers []*model.Customer
+}
+
+func (r *fakeCustomerRepository) Create(ctx context.Context, customer *model.Customer) error {
+	customer.ID = int64(len(r.customers) + 1)
+	r.customers = append(r.customers, customer)
+	return nil
+}
+
+func (r *fakeCustomerRepository) Get(ctx context.Context, id int64) (*model.Customer, error) {
+	for _, customer := range r.customers {
+		if customer.ID == id {
+			return customer, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeCustomerRepository) GetByEmail(ctx context.Context, email string) (*model.Customer, error) {
+	for _, customer := range r.customers {
+		if customer.Email == email {
+			return customer, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// fakeTokenRepository stores refresh tokens nowhere and revokes nothing.
+type fakeTokenRepository struct {
+	repository.TokenRepository
+}
+
+func (r *fakeTokenRepository) CreateRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) error {
+	return nil
+}
+
+func (r *fakeTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+// customerRouter mounts the customer routes the way the server does.
+func customerRouter(customers repository.CustomerRepository) http.Handler {
+	tokens := &fakeTokenRepository{}
+	authHandler := NewAuthHandler(service.NewAuthService(nil, customers, tokens, nil))
+	customerHandler := NewCustomerHandler(service.NewCustomerService(customers))
+	jwtVerifier := middleware.NewJWTVerifier(tokens)
+	customerOnly := middleware.RequireRole(constant.ROLE_CUSTOMER)
+
+	r := chi.NewRouter()
+	r.Post("/customers/auth", authHandler.LoginCustomer())
+	r.Post("/customers", customerHandler.Create())
+	r.With(jwtVerifier, customerOnly).Get("/customers/{customer_id}", customerHandler.Get())
+	return r
+}
+
+func TestCustomerRegisterLoginProfile(t *testing.T) {
+	customers := &fakeCustomerRepository{}
+	router := customerRouter(customers)
+
+	res := serve(router, "POST", "/customers", nil, `{"name":"Ani","email":"ani@example.com","password":"secret123"}`)
+	require.Equal(t, http.StatusCreated, res.Code, res.Body.String())
+	var customer model.CustomerResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &customer))
+	assert.Equal(t, int64(1), customer.ID)
+	assert.NotContains(t, res.Body.String(), "secret123")
+
+	res = serve(router, "POST", "/customers", nil, `{"name":"Ani","email":"ani@example.com","password":"secret123"}`)
+	assert.Equal(t, http.StatusConflict, res.Code)
+	res = serve(router, "POST", "/customers", nil, `{"name":"Budi","email":"budi","password":"short"}`)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = serve(router, "POST", "/customers/auth", nil, `{"email":"ani@example.com","password":"wrongpass"}`)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	res = serve(router, "POST", "/customers/auth", nil, `{"email":"ani@example.com","password":"secret123"}`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var login model.AuthResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &login))
+
+	signedIn := http.Header{constant.API_KEY_HEADER: {login.Token}}
+	res = serve(router, "GET", "/customers/1", signedIn, "")
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &customer))
+	assert.Equal(t, "ani@example.com", customer.Email)
+
+	customers.Create(context.Background(), &model.Customer{Name: "Budi", Email: "budi@example.com"})
+	res = serve(router, "GET", "/customers/2", signedIn, "")
+	assert.Equal(t, http.StatusUnauthorized, res.Code, "another customer's profile")
+
+	res = serve(router, "GET", "/customers/1", nil, "")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestCustomerRouteRejectsMerchant(t *testing.T) {
+	customers := &fakeCustomerRepository{}
+	customers.Create(context.Background(), &model.Customer{Name: "Ani", Email: "ani@example.com"})
+	router := customerRouter(customers)
+
+	// a merchant sharing the customer's id
+	merchantToken, _, err := token.GenerateToken(&model.Merchant{ID: 1})
+	require.NoError(t, err)
+	res := serve(router, "GET", "/customers/1", http.Header{constant.API_KEY_HEADER: {merchantToken}}, "")
+	assert.Equal(t, http.StatusForbidden, res.Code)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/token"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type JWKSHandler interface {
+	Get() http.HandlerFunc
+}
+
+func NewJWKSHandler() JWKSHandler {
+	return &jwksHandler{}
+}
+
+type jwksHandler struct{}
+
+func (h *jwksHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := token.Keys()
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to load token keys")
+			web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		web.MarshalPayload(w, http.StatusOK, keys.JWKS())
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/service"
 	"github.com/SemmiDev/go-product/internal/constant"
@@ -15,8 +16,11 @@ type MerchantHandler interface {
 	List() http.HandlerFunc
 	Get() http.HandlerFunc
 	Update() http.HandlerFunc
+	Patch() http.HandlerFunc
 	UpdatePassword() http.HandlerFunc
 	Delete() http.HandlerFunc
+	Restore() http.HandlerFunc
+	Trash() http.HandlerFunc
 }
 
 func NewMerchantHandler(merchantService service.MerchantService) MerchantHandler {
@@ -60,25 +64,30 @@ func (h *merchantHandler) Create() http.HandlerFunc {
 
 func (h *merchantHandler) List() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limit, offset, err := web.GetPagination(r)
+		page, err := web.GetPage(r)
 		if err != nil {
 			web.MarshalError(w, http.StatusBadRequest, err)
 			return
 		}
 
 		req := model.MerchantListRequest{
-			Limit:  limit,
-			Offset: offset,
-			Name:   web.GetUrlQueryString(r, "name"),
+			Page: page,
+			Name: web.GetUrlQueryString(r, "name"),
 		}
 
-		res, err := h.merchantService.List(r.Context(), req)
+		res, info, err := h.merchantService.List(r.Context(), req)
 		if err != nil {
-			web.MarshalError(w, http.StatusInternalServerError, err)
-			return
+			switch err {
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalList(w, r, req.Page, res, info)
 	}
 }
 
@@ -103,7 +112,7 @@ func (h *merchantHandler) Get() http.HandlerFunc {
 			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
 	}
 }
 
@@ -115,7 +124,7 @@ func (h *merchantHandler) Update() http.HandlerFunc {
 			return
 		}
 
-		req := model.MerchantUpdateRequest{ID: id}
+		req := model.MerchantUpdateRequest{ID: id, IfMatch: web.GetPrecondition(r)}
 		err = json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
@@ -140,13 +149,69 @@ func (h *merchantHandler) Update() http.HandlerFunc {
 			case constant.ErrMerchantNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
+	}
+}
+
+func (h *merchantHandler) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "merchant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		patch, err := web.ReadMergePatch(w, r)
+		if err == constant.ErrPatchMediaType {
+			web.MarshalError(w, http.StatusUnsupportedMediaType, err)
+			return
+		} else if err == constant.ErrRequestTooLarge {
+			web.MarshalError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		} else if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.MerchantPatchRequest{ID: id, IfMatch: web.GetPrecondition(r), Patch: patch}
+		res, err := h.merchantService.Patch(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, constant.ErrFieldValidation) {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+			switch err {
+			case constant.ErrRequestBody:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrEmailRegistered:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			case constant.ErrMerchantNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
 	}
 }
 
@@ -180,13 +245,16 @@ func (h *merchantHandler) UpdatePassword() http.HandlerFunc {
 			case constant.ErrMerchantNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
 	}
 }
 
@@ -198,7 +266,7 @@ func (h *merchantHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		req := model.MerchantDeleteRequest{ID: id}
+		req := model.MerchantDeleteRequest{ID: id, IfMatch: web.GetPrecondition(r)}
 		err = h.merchantService.Delete(r.Context(), req)
 		if err != nil {
 			switch err {
@@ -208,6 +276,9 @@ func (h *merchantHandler) Delete() http.HandlerFunc {
 			case constant.ErrMerchantNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
@@ -217,3 +288,65 @@ func (h *merchantHandler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func (h *merchantHandler) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "merchant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.MerchantRestoreRequest{ID: id}
+		res, err := h.merchantService.Restore(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrMerchantNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *merchantHandler) Trash() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "merchant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := web.GetPage(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.MerchantTrashRequest{ID: id, Page: page}
+		res, info, err := h.merchantService.Trash(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalList(w, r, req.Page, res, info)
+	}
+}
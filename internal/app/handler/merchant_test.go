@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMerchantService keeps one merchant and checks If-Match the way the repository does.
+type fakeMerchantService struct {
+	service.MerchantService
+	merchant *model.Merchant
+}
+
+func (s *fakeMerchantService) Get(ctx context.Context, req model.MerchantGetRequest) (*model.MerchantResponse, error) {
+	if req.ID != s.merchant.ID {
+		return nil, constant.ErrMerchantNotFound
+	}
+	return model.NewMerchantResponse(s.merchant), nil
+}
+
+func (s *fakeMerchantService) Update(ctx context.Context, req model.MerchantUpdateRequest) (*model.MerchantResponse, error) {
+	if !req.IfMatch.Holds(s.merchant.Version) {
+		return nil, constant.ErrPreconditionFailed
+	}
+	s.merchant.Name = req.Name
+	s.merchant.Email = req.Email
+	s.merchant.Version++
+	return model.NewMerchantResponse(s.merchant), nil
+}
+
+func (s *fakeMerchantService) Delete(ctx context.Context, req model.MerchantDeleteRequest) error {
+	if !req.IfMatch.Holds(s.merchant.Version) {
+		return constant.ErrPreconditionFailed
+	}
+	return nil
+}
+
+func merchantRouter(merchant *model.Merchant) http.Handler {
+	h := NewMerchantHandler(&fakeMerchantService{merchant: merchant})
+	r := chi.NewRouter()
+	r.Get("/merchants/{merchant_id}", h.Get())
+	r.Put("/merchants/{merchant_id}", h.Update())
+	r.Delete("/merchants/{merchant_id}", h.Delete())
+	return r
+}
+
+func serve(handler http.Handler, method, target string, header http.Header, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMerchantNotModified(t *testing.T) {
+	merchant := &model.Merchant{ID: 7, Name: "Shop", Email: "shop@example.com", CreatedAt: time.Now(), Version: 3}
+	router := merchantRouter(merchant)
+
+	res := serve(router, "GET", "/merchants/7", nil, "")
+	require.Equal(t, http.StatusOK, res.Code)
+	etag := res.Header().Get(constant.ETAG_HEADER)
+	assert.True(t, strings.HasPrefix(etag, `"3-`), etag)
+
+	res = serve(router, "GET", "/merchants/7", http.Header{constant.IF_NONE_MATCH_HEADER: {etag}}, "")
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Equal(t, etag, res.Header().Get(constant.ETAG_HEADER))
+	assert.Empty(t, res.Body.String())
+
+	res = serve(router, "GET", "/merchants/7", http.Header{constant.IF_NONE_MATCH_HEADER: {"W/" + etag}}, "")
+	assert.Equal(t, http.StatusNotModified, res.Code, "If-None-Match compares weakly")
+
+	merchant.Name = "Corner Shop"
+	res = serve(router, "GET", "/merchants/7", http.Header{constant.IF_NONE_MATCH_HEADER: {etag}}, "")
+	assert.Equal(t, http.StatusOK, res.Code, "the representation changed")
+	assert.NotEqual(t, etag, res.Header().Get(constant.ETAG_HEADER))
+}
+
+func TestMerchantPreconditionFailed(t *testing.T) {
+	merchant := &model.Merchant{ID: 7, Name: "Shop", Email: "shop@example.com", CreatedAt: time.Now(), Version: 3}
+	router := merchantRouter(merchant)
+	body := `{"name":"Corner Shop","email":"shop@example.com"}`
+
+	res := serve(router, "PUT", "/merchants/7", http.Header{constant.IF_MATCH_HEADER: {`"2-abc"`}}, body)
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code)
+	assert.Equal(t, "Shop", merchant.Name)
+
+	res = serve(router, "PUT", "/merchants/7", http.Header{constant.IF_MATCH_HEADER: {`W/"3-abc"`}}, body)
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code, "a weak tag never matches")
+
+	res = serve(router, "PUT", "/merchants/7", http.Header{constant.IF_MATCH_HEADER: {`"2-abc", "3-abc"`}}, body)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.True(t, strings.HasPrefix(res.Header().Get(constant.ETAG_HEADER), `"4-`))
+
+	res = serve(router, "DELETE", "/merchants/7", http.Header{constant.IF_MATCH_HEADER: {`"3-abc"`}}, "")
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code)
+
+	res = serve(router, "DELETE", "/merchants/7", http.Header{constant.IF_MATCH_HEADER: {"*"}}, "")
+	assert.NotEqual(t, http.StatusPreconditionFailed, res.Code)
+}
+
+type fakeMerchantRepository struct {
+	repository.MerchantRepository
+	merchant *model.Merchant
+	patched  [][]string
+}
+
+func (r *fakeMerchantRepository) GetLatest(ctx context.Context, id int64) (*model.Merchant, error) {
+	merchant := *r.merchant
+	return &merchant, nil
+}
+
+func (r *fakeMerchantRepository) EmailTaken(ctx context.Context, email string, exceptID int64) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeMerchantRepository) Patch(ctx context.Context, merchant *model.Merchant, ifMatch model.Precondition, columns ...string) error {
+	if !ifMatch.Holds(r.merchant.Version) {
+		return constant.ErrPreconditionFailed
+	}
+	r.patched = append(r.patched, columns)
+
+	merchant.Version = r.merchant.Version + 1
+	*r.merchant = *merchant
+	return nil
+}
+
+type fakeAuditService struct {
+	service.AuditService
+	entries []*model.AuditEntry
+}
+
+func (s *fakeAuditService) Record(ctx context.Context, entry *model.AuditEntry) {
+	s.entries = append(s.entries, entry)
+}
+
+// merchantPatchRouter serves PATCH through the merchant service to merchants, signed in
+// as the merchant 7.
+func merchantPatchRouter(merchants repository.MerchantRepository) http.Handler {
+	h := NewMerchantHandler(service.NewMerchantService(merchants, nil, nil, &fakeAuditService{}))
+	r := chi.NewRouter()
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.WithClaims(r.Context(), 7, constant.ROLE_MERCHANT)))
+		})
+	}).Patch("/merchants/{merchant_id}", h.Patch())
+	return r
+}
+
+func TestMerchantPatch(t *testing.T) {
+	merchants := &fakeMerchantRepository{
+		merchant: &model.Merchant{ID: 7, Name: "Shop", Email: "shop@example.com", CreatedAt: time.Now(), Version: 3},
+	}
+	router := merchantPatchRouter(merchants)
+	header := http.Header{"Content-Type": {constant.MERGE_PATCH_MEDIA_TYPE}}
+
+	res := serve(router, "PATCH", "/merchants/7", header, `{"name":"Corner Shop"}`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, [][]string{{"name"}}, merchants.patched, "only the changed column is written")
+	assert.True(t, strings.HasPrefix(res.Header().Get(constant.ETAG_HEADER), `"4-`))
+
+	var merchant model.MerchantResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &merchant))
+	assert.Equal(t, "Corner Shop", merchant.Name)
+	assert.Equal(t, "shop@example.com", merchant.Email)
+
+	res = serve(router, "PATCH", "/merchants/7", header, `{"name":"Corner Shop","email":"shop@example.com"}`)
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Len(t, merchants.patched, 1, "a patch changing nothing writes nothing")
+	assert.True(t, strings.HasPrefix(res.Header().Get(constant.ETAG_HEADER), `"4-`), "and keeps the version")
+
+	stale := http.Header{"Content-Type": {"application/json"}, constant.IF_MATCH_HEADER: {`"3-abc"`}}
+	res = serve(router, "PATCH", "/merchants/7", stale, `{}`)
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code, "even when it changes nothing")
+}
+
+func TestMerchantPatchRejects(t *testing.T) {
+	merchants := &fakeMerchantRepository{
+		merchant: &model.Merchant{ID: 7, Name: "Shop", Email: "shop@example.com", CreatedAt: time.Now(), Version: 3},
+	}
+	router := merchantPatchRouter(merchants)
+	header := http.Header{"Content-Type": {constant.MERGE_PATCH_MEDIA_TYPE}}
+
+	res := serve(router, "PATCH", "/merchants/7", http.Header{"Content-Type": {"application/json-patch+json"}}, `[]`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	assert.Equal(t, constant.MERGE_PATCH_MEDIA_TYPE, res.Header().Get(constant.ACCEPT_PATCH_HEADER))
+
+	res = serve(router, "PATCH", "/merchants/7", header, `{"email":null}`)
+	assert.Equal(t, http.StatusBadRequest, res.Code, "a required field cannot be removed")
+	res = serve(router, "PATCH", "/merchants/7", header, `{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, res.Code, "nor cleared")
+	res = serve(router, "PATCH", "/merchants/7", header, `{"name":`)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = serve(router, "PATCH", "/merchants/7", header, `{"name":"`+strings.Repeat("a", 64<<10)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+
+	assert.Empty(t, merchants.patched)
+	assert.Equal(t, int64(3), merchants.merchant.Version)
+}
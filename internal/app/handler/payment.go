@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/web"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxWebhookBytes bounds the webhook payloads read, provider events are a few kilobytes.
+const maxWebhookBytes = 1 << 16
+
+type PaymentHandler interface {
+	Checkout() http.HandlerFunc
+	Webhook() http.HandlerFunc
+}
+
+type paymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) PaymentHandler {
+	return &paymentHandler{paymentService}
+}
+
+func (h *paymentHandler) Checkout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		orderID, err := web.GetUrlPathInt64(r, "order_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.CheckoutRequest{OrderID: orderID}
+		res, err := h.paymentService.Checkout(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrOrderNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrOrderNotPayable:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			case constant.ErrPaymentProvider:
+				web.MarshalError(w, http.StatusBadGateway, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *paymentHandler) Webhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		req := model.PaymentWebhookRequest{Payload: payload, Signature: r.Header.Get(constant.PAYMENT_SIGNATURE_HEADER)}
+		err = h.paymentService.HandleWebhook(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrPaymentSignature, constant.ErrRequestBody:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type PriceRuleHandler interface {
+	List() http.HandlerFunc
+	Create() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
+
+type priceRuleHandler struct {
+	priceRuleService service.PriceRuleService
+}
+
+func NewPriceRuleHandler(priceRuleService service.PriceRuleService) PriceRuleHandler {
+	return &priceRuleHandler{priceRuleService}
+}
+
+func (h *priceRuleHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PriceRuleListRequest{ProductID: productID}
+		res, err := h.priceRuleService.List(r.Context(), req)
+		if err != nil {
+			h.marshalPriceRuleError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *priceRuleHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PriceRuleCreateRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.priceRuleService.Create(r.Context(), req)
+		if err != nil {
+			h.marshalPriceRuleError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *priceRuleHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ruleID, err := web.GetUrlPathInt64(r, "rule_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PriceRuleDeleteRequest{ProductID: productID, RuleID: ruleID}
+		err = h.priceRuleService.Delete(r.Context(), req)
+		if err != nil {
+			h.marshalPriceRuleError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *priceRuleHandler) marshalPriceRuleError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrProductNotFound, constant.ErrPriceRuleNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrPriceRuleOverlap:
+		web.MarshalError(w, http.StatusConflict, err)
+	case constant.ErrPriceRuleInvalid, constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
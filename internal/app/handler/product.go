@@ -2,20 +2,27 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/service"
 	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/validation"
 	"github.com/SemmiDev/go-product/internal/web"
 	"net/http"
+	"strings"
 )
 
 type ProductHandler interface {
 	Create() http.HandlerFunc
 	List() http.HandlerFunc
+	Search() http.HandlerFunc
 	Get() http.HandlerFunc
 	Update() http.HandlerFunc
+	Patch() http.HandlerFunc
 	Delete() http.HandlerFunc
+	Restore() http.HandlerFunc
+	PriceHistory() http.HandlerFunc
+	PriceAt() http.HandlerFunc
 }
 
 type productHandler struct {
@@ -47,6 +54,9 @@ func (h *productHandler) Create() http.HandlerFunc {
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision, constant.ErrCurrencyUnsupported:
+				web.MarshalError(w, http.StatusUnprocessableEntity, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
@@ -59,19 +69,73 @@ func (h *productHandler) Create() http.HandlerFunc {
 
 func (h *productHandler) List() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		limit, offset, err := web.GetPagination(r)
+		page, err := web.GetPage(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		filter, err := model.ParseProductFilter(r.URL.Query())
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		currency, err := web.GetUrlQueryCurrency(r, "currency")
 		if err != nil {
 			web.MarshalError(w, http.StatusBadRequest, err)
 			return
 		}
 
 		req := model.ProductListRequest{
+			Page:     page,
+			Filter:   filter,
+			Currency: currency,
+		}
+
+		res, info, err := h.productService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrExchangeRateCurrency:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrExchangeRateUnavailable:
+				web.MarshalError(w, http.StatusServiceUnavailable, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalList(w, r, req.Page, res, info)
+	}
+}
+
+func (h *productHandler) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := web.GetPagination(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		query := strings.TrimSpace(web.GetUrlQueryString(r, "q"))
+		if query == "" {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrUrlQueryParameter)
+			return
+		}
+
+		req := model.ProductSearchRequest{
+			Query:  query,
 			Limit:  limit,
 			Offset: offset,
-			Title:  web.GetUrlQueryString(r, "name"),
 		}
 
-		res, err := h.productService.List(r.Context(), req)
+		res, err := h.productService.Search(r.Context(), req)
 		if err != nil {
 			web.MarshalError(w, http.StatusInternalServerError, err)
 			return
@@ -89,20 +153,32 @@ func (h *productHandler) Get() http.HandlerFunc {
 			return
 		}
 
-		req := model.ProductGetRequest{ID: id}
+		currency, err := web.GetUrlQueryCurrency(r, "currency")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductGetRequest{ID: id, Currency: currency}
 		res, err := h.productService.Get(r.Context(), req)
 		if err != nil {
 			switch err {
 			case constant.ErrProductNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrExchangeRateCurrency:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrExchangeRateUnavailable:
+				web.MarshalError(w, http.StatusServiceUnavailable, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
 	}
 }
 
@@ -114,7 +190,7 @@ func (h *productHandler) Update() http.HandlerFunc {
 			return
 		}
 
-		req := model.ProductUpdateRequest{ID: id}
+		req := model.ProductUpdateRequest{ID: id, IfMatch: web.GetPrecondition(r)}
 		err = json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
@@ -133,16 +209,77 @@ func (h *productHandler) Update() http.HandlerFunc {
 			case constant.ErrUnauthorized:
 				web.MarshalError(w, http.StatusUnauthorized, err)
 				return
+			case constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision, constant.ErrCurrencyUnsupported,
+				constant.ErrVariantPriceCurrency, constant.ErrPriceRuleCurrency:
+				web.MarshalError(w, http.StatusUnprocessableEntity, err)
+				return
 			case constant.ErrProductNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
 			}
 		}
 
-		web.MarshalPayload(w, http.StatusOK, res)
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
+	}
+}
+
+func (h *productHandler) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		patch, err := web.ReadMergePatch(w, r)
+		if err == constant.ErrPatchMediaType {
+			web.MarshalError(w, http.StatusUnsupportedMediaType, err)
+			return
+		} else if err == constant.ErrRequestTooLarge {
+			web.MarshalError(w, http.StatusRequestEntityTooLarge, err)
+			return
+		} else if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductPatchRequest{ID: id, IfMatch: web.GetPrecondition(r), Patch: patch}
+		res, err := h.productService.Patch(r.Context(), req)
+		if err != nil {
+			if errors.Is(err, constant.ErrFieldValidation) {
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			}
+			switch err {
+			case constant.ErrRequestBody:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision, constant.ErrCurrencyUnsupported,
+				constant.ErrVariantPriceCurrency, constant.ErrPriceRuleCurrency:
+				web.MarshalError(w, http.StatusUnprocessableEntity, err)
+				return
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalVersioned(w, r, http.StatusOK, res.Version, res)
 	}
 }
 
@@ -154,7 +291,7 @@ func (h *productHandler) Delete() http.HandlerFunc {
 			return
 		}
 
-		req := model.ProductDeleteRequest{ID: id}
+		req := model.ProductDeleteRequest{ID: id, IfMatch: web.GetPrecondition(r)}
 		err = h.productService.Delete(r.Context(), req)
 		if err != nil {
 			switch err {
@@ -164,6 +301,9 @@ func (h *productHandler) Delete() http.HandlerFunc {
 			case constant.ErrProductNotFound:
 				web.MarshalError(w, http.StatusNotFound, err)
 				return
+			case constant.ErrPreconditionFailed:
+				web.MarshalError(w, http.StatusPreconditionFailed, err)
+				return
 			default:
 				web.MarshalError(w, http.StatusInternalServerError, err)
 				return
@@ -173,3 +313,98 @@ func (h *productHandler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func (h *productHandler) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductRestoreRequest{ID: id}
+		res, err := h.productService.Restore(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrProductMerchantDeleted:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productHandler) PriceHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := web.GetPage(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductPriceHistoryRequest{ProductID: id, Page: page}
+		res, info, err := h.productService.PriceHistory(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalList(w, r, req.Page, res, info)
+	}
+}
+
+func (h *productHandler) PriceAt() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req, err := model.ParseProductPriceAtRequest(id, r.URL.Query())
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.productService.PriceAt(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrProductNotFound, constant.ErrPriceNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
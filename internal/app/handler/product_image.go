@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type ProductImageHandler interface {
+	Upload() http.HandlerFunc
+	Reorder() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
+
+type productImageHandler struct {
+	productImageService service.ProductImageService
+}
+
+func NewProductImageHandler(productImageService service.ProductImageService) ProductImageHandler {
+	return &productImageHandler{productImageService}
+}
+
+func (h *productImageHandler) Upload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		data, err := web.ReadUploadedFile(w, r, "image", config.Cfg().ImageMaxBytes)
+		if err != nil {
+			h.marshalImageError(w, err)
+			return
+		}
+
+		req := model.ProductImageUploadRequest{ProductID: productID, Data: data}
+		res, err := h.productImageService.Upload(r.Context(), req)
+		if err != nil {
+			h.marshalImageError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *productImageHandler) Reorder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductImageReorderRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.productImageService.Reorder(r.Context(), req)
+		if err != nil {
+			h.marshalImageError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productImageHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		imageID, err := web.GetUrlPathInt64(r, "image_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductImageDeleteRequest{ProductID: productID, ImageID: imageID}
+		err = h.productImageService.Delete(r.Context(), req)
+		if err != nil {
+			h.marshalImageError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *productImageHandler) marshalImageError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrRequestBody:
+		web.MarshalError(w, http.StatusBadRequest, err)
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrProductNotFound, constant.ErrImageNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrImageTooLarge:
+		web.MarshalError(w, http.StatusRequestEntityTooLarge, err)
+	case constant.ErrImageUnsupported:
+		web.MarshalError(w, http.StatusUnsupportedMediaType, err)
+	case constant.ErrImageDimensions, constant.ErrImageOrderInvalid:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProductRepository struct {
+	repository.ProductRepository
+	product *model.Product
+	patched [][]string
+}
+
+func (r *fakeProductRepository) GetLatest(ctx context.Context, id int64) (*model.Product, error) {
+	product := *r.product
+	return &product, nil
+}
+
+func (r *fakeProductRepository) ListPriceRules(ctx context.Context, productID int64) ([]*model.PriceRule, error) {
+	return nil, nil
+}
+
+func (r *fakeProductRepository) Patch(ctx context.Context, product *model.Product, ifMatch model.Precondition, columns ...string) error {
+	if !ifMatch.Holds(r.product.Version) {
+		return constant.ErrPreconditionFailed
+	}
+	r.patched = append(r.patched, columns)
+
+	product.Version = r.product.Version + 1
+	*r.product = *product
+	return nil
+}
+
+type fakeProductSearcher struct {
+	repository.ProductSearcher
+}
+
+func (s *fakeProductSearcher) Index(ctx context.Context, product *model.Product) error {
+	return nil
+}
+
+// productPatchRouter serves PATCH through the product service to products, signed in as
+// the merchant 7.
+func productPatchRouter(products repository.ProductRepository, audits service.AuditService) http.Handler {
+	h := NewProductHandler(service.NewProductService(products, &fakeProductSearcher{}, nil, audits))
+	r := chi.NewRouter()
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.WithClaims(r.Context(), 7, constant.ROLE_MERCHANT)))
+		})
+	}).Patch("/products/{product_id}", h.Patch())
+	return r
+}
+
+func newPatchProduct() *model.Product {
+	return &model.Product{
+		ID: 3, Name: "Kopi", Price: model.Money{Amount: 1500000, Currency: "IDR"},
+		CreatedAt: time.Now(), Version: 2, MerchantID: 7, Merchant: model.Merchant{ID: 7},
+	}
+}
+
+func TestProductPatch(t *testing.T) {
+	products := &fakeProductRepository{product: newPatchProduct()}
+	audits := &fakeAuditService{}
+	router := productPatchRouter(products, audits)
+	header := http.Header{"Content-Type": {constant.MERGE_PATCH_MEDIA_TYPE}}
+
+	res := serve(router, "PATCH", "/products/3", header, `{"price":"12500"}`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, [][]string{{"price"}}, products.patched, "the currency is kept and not written")
+	assert.Equal(t, model.Money{Amount: 1250000, Currency: "IDR"}, products.product.Price)
+	assert.True(t, strings.HasPrefix(res.Header().Get(constant.ETAG_HEADER), `"3-`))
+
+	var product struct {
+		Name  string `json:"name"`
+		Price struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"price"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &product))
+	assert.Equal(t, "Kopi", product.Name)
+	assert.Equal(t, "12500.00", product.Price.Amount)
+	assert.Equal(t, "IDR", product.Price.Currency)
+
+	require.Len(t, audits.entries, 1)
+	assert.Equal(t, model.AuditActionUpdate, audits.entries[0].Action)
+	assert.Equal(t, []string{"price"}, model.ChangedFields(audits.entries[0].Changes))
+	assert.Equal(t, model.AuditChange{Before: "15000.00", After: "12500.00"}, audits.entries[0].Changes["price"])
+
+	// the amount reads the same in USD, only the currency changes
+	res = serve(router, "PATCH", "/products/3", header, `{"currency":"USD"}`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, []string{"currency"}, products.patched[1])
+	assert.Equal(t, model.Money{Amount: 1250000, Currency: "USD"}, products.product.Price)
+	assert.Equal(t, []string{"currency"}, model.ChangedFields(audits.entries[1].Changes))
+
+	res = serve(router, "PATCH", "/products/3", header, `{"name":"Kopi Susu","price":"10"}`)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	assert.Equal(t, []string{"name", "price"}, products.patched[2])
+	assert.Equal(t, "Kopi Susu", products.product.Name)
+
+	res = serve(router, "PATCH", "/products/3", header, `{"name":"Kopi Susu"}`)
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Len(t, products.patched, 3, "a patch changing nothing writes nothing")
+	assert.Len(t, audits.entries, 3, "nor audits")
+}
+
+func TestProductPatchRejects(t *testing.T) {
+	products := &fakeProductRepository{product: newPatchProduct()}
+	audits := &fakeAuditService{}
+	router := productPatchRouter(products, audits)
+	header := http.Header{"Content-Type": {constant.MERGE_PATCH_MEDIA_TYPE}}
+
+	res := serve(router, "PATCH", "/products/3", http.Header{"Content-Type": {"text/plain"}}, `{}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	assert.Equal(t, constant.MERGE_PATCH_MEDIA_TYPE, res.Header().Get(constant.ACCEPT_PATCH_HEADER))
+
+	res = serve(router, "PATCH", "/products/3", header, `{"name":"`+strings.Repeat("a", 64<<10)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+
+	stale := http.Header{"Content-Type": {constant.MERGE_PATCH_MEDIA_TYPE}, constant.IF_MATCH_HEADER: {`"1-abc"`}}
+	res = serve(router, "PATCH", "/products/3", stale, `{"price":"10"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code)
+	res = serve(router, "PATCH", "/products/3", stale, `{}`)
+	assert.Equal(t, http.StatusPreconditionFailed, res.Code, "even when it changes nothing")
+
+	res = serve(router, "PATCH", "/products/3", header, `{"price":null}`)
+	assert.Equal(t, http.StatusBadRequest, res.Code, "a required field cannot be removed")
+	res = serve(router, "PATCH", "/products/3", header, `{"price":"1.234"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	res = serve(router, "PATCH", "/products/3", header, `{"currency":"XXX"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+
+	assert.Empty(t, products.patched)
+	assert.Empty(t, audits.entries)
+	assert.Equal(t, int64(2), products.product.Version)
+}
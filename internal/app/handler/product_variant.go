@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type ProductVariantHandler interface {
+	SetOptions() http.HandlerFunc
+	List() http.HandlerFunc
+	Get() http.HandlerFunc
+	Create() http.HandlerFunc
+	Update() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
+
+type productVariantHandler struct {
+	productVariantService service.ProductVariantService
+}
+
+func NewProductVariantHandler(productVariantService service.ProductVariantService) ProductVariantHandler {
+	return &productVariantHandler{productVariantService}
+}
+
+func (h *productVariantHandler) SetOptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductOptionsSetRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.productVariantService.SetOptions(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productVariantHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductVariantListRequest{ProductID: productID}
+		res, err := h.productVariantService.List(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productVariantHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		variantID, err := web.GetUrlPathInt64(r, "variant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductVariantGetRequest{ProductID: productID, VariantID: variantID}
+		res, err := h.productVariantService.Get(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productVariantHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductVariantCreateRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.productVariantService.Create(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *productVariantHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		variantID, err := web.GetUrlPathInt64(r, "variant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductVariantUpdateRequest{ProductID: productID, VariantID: variantID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.productVariantService.Update(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *productVariantHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		variantID, err := web.GetUrlPathInt64(r, "variant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.ProductVariantDeleteRequest{ProductID: productID, VariantID: variantID}
+		err = h.productVariantService.Delete(r.Context(), req)
+		if err != nil {
+			h.marshalVariantError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *productVariantHandler) marshalVariantError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrProductNotFound, constant.ErrVariantNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrVariantExists, constant.ErrVariantSKUTaken:
+		web.MarshalError(w, http.StatusConflict, err)
+	case constant.ErrProductOptionsInvalid, constant.ErrTooManyVariants, constant.ErrProductOptionsTooLong,
+		constant.ErrVariantOptionsInvalid,
+		constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type PromotionHandler interface {
+	Create() http.HandlerFunc
+	List() http.HandlerFunc
+	Get() http.HandlerFunc
+	Delete() http.HandlerFunc
+	Evaluate() http.HandlerFunc
+}
+
+type promotionHandler struct {
+	promotionService service.PromotionService
+}
+
+func NewPromotionHandler(promotionService service.PromotionService) PromotionHandler {
+	return &promotionHandler{promotionService}
+}
+
+func (h *promotionHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.PromotionCreateRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.promotionService.Create(r.Context(), req)
+		if err != nil {
+			h.marshalPromotionError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *promotionHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		merchantID, err := web.GetUrlPathInt64(r, "merchant_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		page, err := web.GetPage(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PromotionListRequest{MerchantID: merchantID, Page: page}
+		res, info, err := h.promotionService.List(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrInvalidCursor:
+				web.MarshalError(w, http.StatusBadRequest, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalList(w, r, req.Page, res, info)
+	}
+}
+
+func (h *promotionHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "promotion_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PromotionGetRequest{ID: id}
+		res, err := h.promotionService.Get(r.Context(), req)
+		if err != nil {
+			h.marshalPromotionError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *promotionHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := web.GetUrlPathInt64(r, "promotion_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.PromotionDeleteRequest{ID: id}
+		err = h.promotionService.Delete(r.Context(), req)
+		if err != nil {
+			h.marshalPromotionError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *promotionHandler) Evaluate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.PromotionEvaluateRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.promotionService.Evaluate(r.Context(), req)
+		if err != nil {
+			h.marshalPromotionError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *promotionHandler) marshalPromotionError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrPromotionNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrPromotionCodeTaken:
+		web.MarshalError(w, http.StatusConflict, err)
+	case constant.ErrPromotionCodeInvalid, constant.ErrPromotionInvalid, constant.ErrPromotionProducts,
+		constant.ErrOrderProductNotFound, constant.ErrOrderCurrencyMismatch,
+		constant.ErrPriceInvalid, constant.ErrPriceNegative, constant.ErrPricePrecision, constant.ErrCurrencyUnsupported:
+		web.MarshalError(w, http.StatusUnprocessableEntity, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
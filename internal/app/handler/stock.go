@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/validation"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+)
+
+type StockHandler interface {
+	Get() http.HandlerFunc
+	Adjust() http.HandlerFunc
+	Reserve() http.HandlerFunc
+	Confirm() http.HandlerFunc
+	Release() http.HandlerFunc
+}
+
+type stockHandler struct {
+	stockService service.StockService
+}
+
+func NewStockHandler(stockService service.StockService) StockHandler {
+	return &stockHandler{stockService}
+}
+
+func (h *stockHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.StockGetRequest{ProductID: productID}
+		res, err := h.stockService.Get(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *stockHandler) Adjust() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.StockAdjustRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.stockService.Adjust(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrInsufficientStock:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *stockHandler) Reserve() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := web.GetUrlPathInt64(r, "product_id")
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.StockReservationCreateRequest{ProductID: productID}
+		err = json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+			return
+		}
+
+		err = validation.Struct(req)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := h.stockService.Reserve(r.Context(), req)
+		if err != nil {
+			switch err {
+			case constant.ErrUnauthorized:
+				web.MarshalError(w, http.StatusUnauthorized, err)
+				return
+			case constant.ErrProductNotFound:
+				web.MarshalError(w, http.StatusNotFound, err)
+				return
+			case constant.ErrInsufficientStock:
+				web.MarshalError(w, http.StatusConflict, err)
+				return
+			default:
+				web.MarshalError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		web.MarshalPayload(w, http.StatusCreated, res)
+	}
+}
+
+func (h *stockHandler) Confirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, reservationID, err := h.getReservationPath(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.StockReservationConfirmRequest{ProductID: productID, ReservationID: reservationID}
+		res, err := h.stockService.Confirm(r.Context(), req)
+		if err != nil {
+			h.marshalReservationError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *stockHandler) Release() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, reservationID, err := h.getReservationPath(r)
+		if err != nil {
+			web.MarshalError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		req := model.StockReservationReleaseRequest{ProductID: productID, ReservationID: reservationID}
+		res, err := h.stockService.Release(r.Context(), req)
+		if err != nil {
+			h.marshalReservationError(w, err)
+			return
+		}
+
+		web.MarshalPayload(w, http.StatusOK, res)
+	}
+}
+
+func (h *stockHandler) getReservationPath(r *http.Request) (productID, reservationID int64, err error) {
+	productID, err = web.GetUrlPathInt64(r, "product_id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reservationID, err = web.GetUrlPathInt64(r, "reservation_id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return productID, reservationID, nil
+}
+
+func (h *stockHandler) marshalReservationError(w http.ResponseWriter, err error) {
+	switch err {
+	case constant.ErrUnauthorized:
+		web.MarshalError(w, http.StatusUnauthorized, err)
+	case constant.ErrProductNotFound, constant.ErrReservationNotFound:
+		web.MarshalError(w, http.StatusNotFound, err)
+	case constant.ErrReservationNotPending, constant.ErrReservationExpired:
+		web.MarshalError(w, http.StatusConflict, err)
+	default:
+		web.MarshalError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+
+	AuditEntityMerchant = "merchant"
+	AuditEntityProduct  = "product"
+)
+
+// auditRedacted fields are recorded as changed without their values.
+var auditRedacted = map[string]bool{"password": true}
+
+const auditRedactedValue = "[redacted]"
+
+// AuditChange is the value of one field before and after an operation, nil when the
+// entity did not exist on that side.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry records one operation on an entity. MerchantID is the merchant whose history
+// it belongs to: the merchant itself or the owner of the product. ActorID is not valid for
+// unauthenticated operations such as signing up.
+type AuditEntry struct {
+	ID         int64
+	ActorID    sql.NullInt64
+	ActorRole  string
+	Action     string
+	EntityType string
+	EntityID   int64
+	MerchantID int64
+	Changes    map[string]AuditChange
+	RequestID  string
+	IP         string
+	CreatedAt  time.Time
+}
+
+// AuditDiff compares two field snapshots, nil for a missing side, and keeps the fields
+// whose value changed. Values must be comparable, snapshots hold strings and nils.
+func AuditDiff(before, after map[string]interface{}) map[string]AuditChange {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	changes := make(map[string]AuditChange)
+	for key := range keys {
+		change := AuditChange{Before: before[key], After: after[key]}
+		if change.Before == change.After {
+			continue
+		}
+		if auditRedacted[key] {
+			change = AuditChange{Before: redactAudit(change.Before), After: redactAudit(change.After)}
+		}
+		changes[key] = change
+	}
+	return changes
+}
+
+// ChangedFields lists the fields of changes in order.
+func ChangedFields(changes map[string]AuditChange) []string {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func redactAudit(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return auditRedactedValue
+}
+
+// auditTime formats an optional time for a snapshot.
+func auditTime(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// AuditDeletedAt is the change of soft-deleting or restoring an entity without loading it.
+func AuditDeletedAt(before, after sql.NullTime) map[string]AuditChange {
+	return AuditDiff(
+		map[string]interface{}{"deleted_at": auditTime(before)},
+		map[string]interface{}{"deleted_at": auditTime(after)},
+	)
+}
+
+func (a *Merchant) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":       a.Name,
+		"email":      a.Email,
+		"password":   a.Password,
+		"deleted_at": auditTime(a.DeletedAt),
+	}
+}
+
+func (p *Product) AuditFields() map[string]interface{} {
+	return map[string]interface{}{
+		"name":       p.Name,
+		"price":      p.Price.String(),
+		"currency":   p.Price.Currency,
+		"deleted_at": auditTime(p.DeletedAt),
+	}
+}
+
+type AuditFilter struct {
+	MerchantID int64
+	EntityType string
+	EntityID   int64
+}
+
+type AuditListRequest struct {
+	Page   Page
+	Filter AuditFilter
+}
+
+type AuditActorResponse struct {
+	ID   int64  `json:"id"`
+	Role string `json:"role"`
+}
+
+type AuditEntryResponse struct {
+	ID         int64                  `json:"id"`
+	Actor      *AuditActorResponse    `json:"actor"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int64                  `json:"entity_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	RequestID  string                 `json:"request_id"`
+	IP         string                 `json:"ip"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+func NewAuditEntryResponse(payload *AuditEntry) *AuditEntryResponse {
+	res := &AuditEntryResponse{
+		ID:         payload.ID,
+		Action:     payload.Action,
+		EntityType: payload.EntityType,
+		EntityID:   payload.EntityID,
+		Changes:    payload.Changes,
+		RequestID:  payload.RequestID,
+		IP:         payload.IP,
+		CreatedAt:  payload.CreatedAt,
+	}
+	if payload.ActorID.Valid {
+		res.Actor = &AuditActorResponse{ID: payload.ActorID.Int64, Role: payload.ActorRole}
+	}
+	return res
+}
+
+func NewAuditEntryListResponse(payloads []*AuditEntry) []*AuditEntryResponse {
+	res := make([]*AuditEntryResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewAuditEntryResponse(payload)
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDiff(t *testing.T) {
+	before := (&Product{Name: "Ayam", Price: Money{1999, "USD"}}).AuditFields()
+	after := (&Product{Name: "Ayam goreng", Price: Money{1999, "USD"}}).AuditFields()
+	assert.Equal(t, map[string]AuditChange{"name": {"Ayam", "Ayam goreng"}}, AuditDiff(before, after))
+
+	created := AuditDiff(nil, after)
+	assert.Equal(t, AuditChange{nil, "19.99"}, created["price"])
+	assert.Equal(t, AuditChange{nil, "USD"}, created["currency"])
+	assert.NotContains(t, created, "deleted_at", "nil on both sides is no change")
+
+	deletedAt := sql.NullTime{Time: time.Date(2021, 6, 1, 7, 0, 0, 0, time.UTC), Valid: true}
+	deleted := (&Product{Name: "Ayam goreng", Price: Money{1999, "USD"}, DeletedAt: deletedAt}).AuditFields()
+	assert.Equal(t, map[string]AuditChange{"deleted_at": {nil, "2021-06-01T07:00:00Z"}}, AuditDiff(after, deleted))
+	assert.Equal(t, []string{"currency", "name", "price"}, ChangedFields(created))
+}
+
+func TestAuditDiffRedacts(t *testing.T) {
+	before := (&Merchant{Name: "Sam", Email: "sam@example.com", Password: "hash1"}).AuditFields()
+	after := (&Merchant{Name: "Sam", Email: "sam@example.com", Password: "hash2"}).AuditFields()
+	assert.Equal(t, map[string]AuditChange{"password": {"[redacted]", "[redacted]"}}, AuditDiff(before, after))
+
+	assert.Equal(t, AuditChange{nil, "[redacted]"}, AuditDiff(nil, after)["password"])
+	assert.Empty(t, AuditDiff(after, after))
+}
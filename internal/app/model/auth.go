@@ -1,10 +1,35 @@
 package model
 
+import "time"
+
 type AuthRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,gte=8"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,gte=8"`
+	CartToken string `json:"-"`
+}
+
+type AuthRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type AuthLogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
+// UnmergedCartItems lists the products of the anonymous cart that did not fit into the
+// account cart on login, they stay in the anonymous cart.
 type AuthResponse struct {
-	Token string `json:"token"`
-}
\ No newline at end of file
+	Token             string    `json:"token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	RefreshToken      string    `json:"refresh_token"`
+	UnmergedCartItems []int64   `json:"unmerged_cart_items,omitempty"`
+}
+
+type RefreshToken struct {
+	Hash      string    `json:"hash"`
+	Family    string    `json:"family"`
+	SubjectID int64     `json:"subject_id"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxCartItems bounds the distinct products of a cart.
+const MaxCartItems = 100
+
+// CartOwner identifies a cart: the cart of a signed-in customer or an anonymous cart
+// keyed by the token handed out when it was created.
+type CartOwner struct {
+	CustomerID int64
+	Token      string
+}
+
+func (o CartOwner) Key() string {
+	if o.CustomerID != 0 {
+		return fmt.Sprintf("cart_customer_%d", o.CustomerID)
+	}
+	return fmt.Sprintf("cart_token_%s", o.Token)
+}
+
+// CartItem is a product in a cart with its effective price when it was last added or
+// updated, which reads compare the current price against.
+type CartItem struct {
+	ProductID int64     `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Price     Money     `json:"price"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// SortCartItems orders items by when they were first added.
+func SortCartItems(items []*CartItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].AddedAt.Equal(items[j].AddedAt) {
+			return items[i].AddedAt.Before(items[j].AddedAt)
+		}
+		return items[i].ProductID < items[j].ProductID
+	})
+}
+
+// MergeCartItems adds the items of an anonymous cart to an account cart. Products in both
+// keep the account cart's entry with the quantities summed. New products that would take
+// the account cart past MaxCartItems are left out of merged and returned in left.
+func MergeCartItems(account, anonymous []*CartItem) (merged, left []*CartItem) {
+	byID := make(map[int64]*CartItem, len(account))
+	for _, item := range account {
+		byID[item.ProductID] = item
+	}
+
+	merged = []*CartItem{}
+	for _, item := range anonymous {
+		if existing, found := byID[item.ProductID]; found {
+			existing.Quantity += item.Quantity
+			merged = append(merged, existing)
+		} else if len(byID) < MaxCartItems {
+			byID[item.ProductID] = item
+			merged = append(merged, item)
+		} else {
+			left = append(left, item)
+		}
+	}
+	return merged, left
+}
+
+type CartGetRequest struct {
+	Owner CartOwner
+}
+
+type CartItemAddRequest struct {
+	Owner     CartOwner `json:"-"`
+	ProductID int64     `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+}
+
+type CartItemUpdateRequest struct {
+	Owner     CartOwner `json:"-"`
+	ProductID int64     `json:"-"`
+	Quantity  int       `json:"quantity" validate:"required,gt=0"`
+}
+
+type CartItemRemoveRequest struct {
+	Owner     CartOwner
+	ProductID int64
+}
+
+type CartClearRequest struct {
+	Owner CartOwner
+}
+
+// UnitPrice is the current effective price, PreviousUnitPrice the one the item was added
+// at when it has changed since. Unavailable items are no longer sold and count for nothing.
+type CartItemResponse struct {
+	ProductID         int64  `json:"product_id"`
+	Name              string `json:"name"`
+	Quantity          int    `json:"quantity"`
+	UnitPrice         Money  `json:"unit_price"`
+	PreviousUnitPrice *Money `json:"previous_unit_price"`
+	Subtotal          Money  `json:"subtotal"`
+	Unavailable       bool   `json:"unavailable"`
+	PriceChanged      bool   `json:"price_changed"`
+}
+
+// Total is left out when the available items are priced in more than one currency.
+type CartResponse struct {
+	Token string              `json:"token,omitempty"`
+	Items []*CartItemResponse `json:"items"`
+	Total *Money              `json:"total"`
+}
+
+// NewCartResponse reprices items against products, the current state of those still
+// sold, keyed by id.
+func NewCartResponse(token string, items []*CartItem, products map[int64]*Product) *CartResponse {
+	res := &CartResponse{
+		Token: token,
+		Items: make([]*CartItemResponse, len(items)),
+	}
+
+	currencies := make(map[string]bool)
+	var total Money
+	for i, item := range items {
+		itemRes := &CartItemResponse{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Price,
+			Subtotal:  Money{Currency: item.Price.Currency},
+		}
+		res.Items[i] = itemRes
+
+		product, found := products[item.ProductID]
+		if !found {
+			itemRes.Unavailable = true
+			continue
+		}
+
+		itemRes.Name = product.Name
+		itemRes.UnitPrice = product.EffectivePrice()
+		itemRes.Subtotal = itemRes.UnitPrice.Times(item.Quantity)
+		if itemRes.UnitPrice != item.Price {
+			previous := item.Price
+			itemRes.PreviousUnitPrice = &previous
+			itemRes.PriceChanged = true
+		}
+
+		currencies[itemRes.Subtotal.Currency] = true
+		total.Currency = itemRes.Subtotal.Currency
+		total.Amount += itemRes.Subtotal.Amount
+	}
+
+	if len(currencies) <= 1 {
+		if total.Currency == "" {
+			total.Currency = DefaultCurrency
+		}
+		res.Total = &total
+	}
+	return res
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartOwnerKey(t *testing.T) {
+	assert.Equal(t, "cart_customer_7", CartOwner{CustomerID: 7, Token: "abc"}.Key())
+	assert.Equal(t, "cart_token_abc", CartOwner{Token: "abc"}.Key())
+}
+
+func TestMergeCartItems(t *testing.T) {
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	account := []*CartItem{
+		{ProductID: 1, Quantity: 2, Price: Money{1000, "IDR"}, AddedAt: at},
+		{ProductID: 2, Quantity: 1, Price: Money{500, "IDR"}, AddedAt: at},
+	}
+	anonymous := []*CartItem{
+		{ProductID: 2, Quantity: 3, Price: Money{400, "IDR"}, AddedAt: at.Add(time.Hour)},
+		{ProductID: 3, Quantity: 1, Price: Money{700, "IDR"}, AddedAt: at.Add(time.Hour)},
+	}
+
+	merged, left := MergeCartItems(account, anonymous)
+	assert.Len(t, merged, 2, "untouched account items are not rewritten")
+	assert.Equal(t, &CartItem{ProductID: 2, Quantity: 4, Price: Money{500, "IDR"}, AddedAt: at}, merged[0])
+	assert.Equal(t, int64(3), merged[1].ProductID)
+	assert.Empty(t, left)
+}
+
+func TestMergeCartItemsOverflow(t *testing.T) {
+	account := make([]*CartItem, MaxCartItems-1)
+	for i := range account {
+		account[i] = &CartItem{ProductID: int64(i + 1), Quantity: 1}
+	}
+	anonymous := []*CartItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 1001, Quantity: 1},
+		{ProductID: 1002, Quantity: 1},
+		{ProductID: 1003, Quantity: 1},
+	}
+
+	merged, left := MergeCartItems(account, anonymous)
+	assert.Len(t, merged, 2)
+	assert.Equal(t, 3, merged[0].Quantity, "products already in the account cart always merge")
+	assert.Equal(t, int64(1001), merged[1].ProductID, "the last free line")
+	assert.Equal(t, []*CartItem{anonymous[2], anonymous[3]}, left)
+}
+
+func TestNewCartResponse(t *testing.T) {
+	items := []*CartItem{
+		{ProductID: 1, Quantity: 2, Price: Money{1000, "IDR"}},
+		{ProductID: 2, Quantity: 1, Price: Money{500, "IDR"}},
+		{ProductID: 3, Quantity: 4, Price: Money{200, "IDR"}},
+	}
+	products := map[int64]*Product{
+		1: {ID: 1, Name: "Kopi", Price: Money{1000, "IDR"}},
+		2: {ID: 2, Name: "Teh", Price: Money{600, "IDR"}},
+	}
+
+	res := NewCartResponse("abc", items, products)
+	assert.Equal(t, "abc", res.Token)
+	assert.Equal(t, &Money{2600, "IDR"}, res.Total)
+
+	assert.Equal(t, "Kopi", res.Items[0].Name)
+	assert.False(t, res.Items[0].PriceChanged)
+	assert.Nil(t, res.Items[0].PreviousUnitPrice)
+
+	assert.True(t, res.Items[1].PriceChanged)
+	assert.Equal(t, Money{600, "IDR"}, res.Items[1].UnitPrice)
+	assert.Equal(t, &Money{500, "IDR"}, res.Items[1].PreviousUnitPrice)
+
+	assert.True(t, res.Items[2].Unavailable)
+	assert.Equal(t, Money{0, "IDR"}, res.Items[2].Subtotal)
+
+	products[1].Price = Money{10, "USD"}
+	assert.Nil(t, NewCartResponse("", items, products).Total, "mixed currencies")
+	assert.Equal(t, &Money{0, DefaultCurrency}, NewCartResponse("", []*CartItem{}, nil).Total)
+}
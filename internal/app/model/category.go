@@ -0,0 +1,146 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Category is part of the tree every merchant files products under. MerchantID is the
+// merchant who created it and may change it, categories from before owners existed have none.
+type Category struct {
+	ID         int64
+	ParentID   sql.NullInt64
+	MerchantID sql.NullInt64
+	Name       string
+	Slug       string
+	Position   int
+	CreatedAt  time.Time
+	UpdatedAt  sql.NullTime
+}
+
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+func IsSlug(s string) bool {
+	return s != "" && Slugify(s) == s
+}
+
+type CategoryCreateRequest struct {
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gt=0"`
+	Name     string `json:"name" validate:"required"`
+	Slug     string `json:"slug"`
+	Position int    `json:"position" validate:"gte=0"`
+}
+
+type CategoryGetRequest struct {
+	ID int64
+}
+
+type CategoryUpdateRequest struct {
+	ID       int64  `json:"-"`
+	ParentID *int64 `json:"parent_id" validate:"omitempty,gt=0"`
+	Name     string `json:"name" validate:"required"`
+	Slug     string `json:"slug" validate:"required"`
+	Position int    `json:"position" validate:"gte=0"`
+}
+
+type CategoryDeleteRequest struct {
+	ID int64
+}
+
+type ProductCategoriesGetRequest struct {
+	ProductID int64
+}
+
+type ProductCategoriesUpdateRequest struct {
+	ProductID   int64   `json:"-"`
+	CategoryIDs []int64 `json:"category_ids" validate:"dive,gt=0"`
+}
+
+type CategoryResponse struct {
+	ID         int64               `json:"id"`
+	ParentID   *int64              `json:"parent_id"`
+	MerchantID *int64              `json:"merchant_id"`
+	Name       string              `json:"name"`
+	Slug       string              `json:"slug"`
+	Position   int                 `json:"position"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  *time.Time          `json:"updated_at"`
+	Children   []*CategoryResponse `json:"children,omitempty"`
+}
+
+func NewCategoryResponse(payload *Category) *CategoryResponse {
+	res := &CategoryResponse{
+		ID:        payload.ID,
+		Name:      payload.Name,
+		Slug:      payload.Slug,
+		Position:  payload.Position,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.ParentID.Valid {
+		res.ParentID = &payload.ParentID.Int64
+	}
+	if payload.MerchantID.Valid {
+		res.MerchantID = &payload.MerchantID.Int64
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
+
+func NewCategoryListResponse(payloads []*Category) []*CategoryResponse {
+	res := make([]*CategoryResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewCategoryResponse(payload)
+	}
+	return res
+}
+
+// NewCategoryTreeResponse nests categories under their parents, each level ordered by
+// position then name. Categories whose parent is missing from payloads become roots.
+func NewCategoryTreeResponse(payloads []*Category) []*CategoryResponse {
+	nodes := make(map[int64]*CategoryResponse, len(payloads))
+	for _, payload := range payloads {
+		nodes[payload.ID] = NewCategoryResponse(payload)
+	}
+
+	roots := []*CategoryResponse{}
+	for _, payload := range payloads {
+		node := nodes[payload.ID]
+		if parent, ok := nodes[payload.ParentID.Int64]; payload.ParentID.Valid && ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	sortCategoryResponses(roots)
+	return roots
+}
+
+func sortCategoryResponses(nodes []*CategoryResponse) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Position != nodes[j].Position {
+			return nodes[i].Position < nodes[j].Position
+		}
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortCategoryResponses(node.Children)
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "home-kitchen", Slugify("Home & Kitchen"))
+	assert.Equal(t, "t-shirts-2021", Slugify("  T-Shirts (2021) "))
+	assert.Equal(t, "", Slugify("!!!"))
+
+	assert.True(t, IsSlug("home-kitchen"))
+	assert.False(t, IsSlug("Home-Kitchen"))
+	assert.False(t, IsSlug("home--kitchen"))
+	assert.False(t, IsSlug("-home"))
+	assert.False(t, IsSlug(""))
+}
+
+func TestNewCategoryTreeResponse(t *testing.T) {
+	parent := func(id int64) sql.NullInt64 { return sql.NullInt64{Int64: id, Valid: true} }
+	tree := NewCategoryTreeResponse([]*Category{
+		{ID: 1, Name: "Fashion", Position: 1},
+		{ID: 2, Name: "Electronics", Position: 0},
+		{ID: 3, ParentID: parent(1), Name: "Shoes"},
+		{ID: 4, ParentID: parent(1), Name: "Bags"},
+		{ID: 5, ParentID: parent(3), Name: "Sneakers"},
+		{ID: 6, ParentID: parent(99), Name: "Orphan", Position: 2},
+	})
+
+	assert.Len(t, tree, 3)
+	assert.Equal(t, []string{"Electronics", "Fashion", "Orphan"}, []string{tree[0].Name, tree[1].Name, tree[2].Name})
+	assert.Equal(t, "Bags", tree[1].Children[0].Name)
+	assert.Equal(t, "Shoes", tree[1].Children[1].Name)
+	assert.Equal(t, "Sneakers", tree[1].Children[1].Children[0].Name)
+	assert.Empty(t, tree[0].Children)
+}
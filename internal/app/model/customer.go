@@ -0,0 +1,58 @@
+package model
+
+import (
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
+	jwt "github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+type Customer struct {
+	ID        int64
+	Name      string
+	Email     string
+	Password  string
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
+func (c *Customer) GenerateClaims() jwt.MapClaims {
+	return jwt.MapClaims{"id": c.ID, "role": constant.ROLE_CUSTOMER}
+}
+
+type CustomerCreateRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,gte=8"`
+}
+
+type CustomerGetRequest struct {
+	ID int64
+}
+
+type CustomerUpdateRequest struct {
+	ID    int64  `json:"-"`
+	Name  string `json:"name" validate:"required"`
+	Email string `json:"email" validate:"required,email"`
+}
+
+type CustomerResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func NewCustomerResponse(payload *Customer) *CustomerResponse {
+	res := &CustomerResponse{
+		ID:        payload.ID,
+		Name:      payload.Name,
+		Email:     payload.Email,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ETag tags a representation of a versioned resource with its version and a digest of the
+// body. If-None-Match needs the digest, a product reads differently in another currency or
+// once its images change, while If-Match only has to name the version last read.
+func ETag(version int64, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%d-%s"`, version, hex.EncodeToString(sum[:8]))
+}
+
+// Precondition is a parsed If-Match header. The zero value, a request without one, holds
+// for every version.
+type Precondition struct {
+	Set      bool
+	Any      bool
+	Versions []int64
+}
+
+// ParsePrecondition reads an If-Match header. Weak and malformed tags never match, so a
+// header made only of those fails against every version.
+func ParsePrecondition(header string) Precondition {
+	if strings.TrimSpace(header) == "" {
+		return Precondition{}
+	}
+
+	p := Precondition{Set: true, Versions: []int64{}}
+	for _, tag := range splitETags(header) {
+		if tag == "*" {
+			p.Any = true
+			continue
+		}
+		if version, valid := etagVersion(tag); valid {
+			p.Versions = append(p.Versions, version)
+		}
+	}
+	return p
+}
+
+func (p Precondition) Holds(version int64) bool {
+	if !p.Set || p.Any {
+		return true
+	}
+	for _, v := range p.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesETag reports whether an If-None-Match header lists etag, comparing weakly.
+func MatchesETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range splitETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func splitETags(header string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func etagVersion(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	opaque := tag[1 : len(tag)-1]
+	if i := strings.IndexByte(opaque, '-'); i >= 0 {
+		opaque = opaque[:i]
+	}
+
+	version, err := strconv.ParseInt(opaque, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	etag := ETag(3, []byte(`{"id":1}`))
+	assert.Regexp(t, `^"3-[0-9a-f]{16}"$`, etag)
+	assert.Equal(t, etag, ETag(3, []byte(`{"id":1}`)))
+	assert.NotEqual(t, etag, ETag(3, []byte(`{"id":2}`)))
+}
+
+func TestPrecondition(t *testing.T) {
+	etag := ETag(3, []byte(`{"id":1}`))
+
+	assert.True(t, ParsePrecondition("").Holds(3), "no header holds")
+	assert.True(t, ParsePrecondition("*").Holds(3))
+	assert.True(t, ParsePrecondition(etag).Holds(3))
+	assert.True(t, ParsePrecondition(`"2-abc", `+etag).Holds(3))
+	assert.True(t, ParsePrecondition(`"3"`).Holds(3), "the digest is optional")
+	assert.False(t, ParsePrecondition(etag).Holds(4))
+	assert.False(t, ParsePrecondition("W/"+etag).Holds(3), "weak tags never match")
+	assert.False(t, ParsePrecondition(`"abc"`).Holds(3))
+	assert.False(t, ParsePrecondition(`3`).Holds(3))
+}
+
+func TestMatchesETag(t *testing.T) {
+	etag := ETag(3, []byte(`{"id":1}`))
+
+	assert.True(t, MatchesETag(etag, etag))
+	assert.True(t, MatchesETag("W/"+etag, etag))
+	assert.True(t, MatchesETag(`"1-abc", `+etag, etag))
+	assert.True(t, MatchesETag("*", etag))
+	assert.False(t, MatchesETag("", etag))
+	assert.False(t, MatchesETag(ETag(3, []byte(`{"id":2}`)), etag))
+}
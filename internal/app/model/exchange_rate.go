@@ -0,0 +1,20 @@
+package model
+
+import (
+	"github.com/SemmiDev/go-product/internal/exchange"
+	"time"
+)
+
+// ExchangeRates is what gets cached: the provider's rates and when they were fetched,
+// which decides when to refresh them while Rates.Timestamp decides whether they are stale.
+type ExchangeRates struct {
+	Rates     exchange.Rates
+	FetchedAt time.Time
+}
+
+type ExchangeRateResponse struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      float64   `json:"rate"`
+	Timestamp time.Time `json:"timestamp"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "net/http"
+
+// IdempotentResponse is the first response to a request carrying an Idempotency-Key, kept to
+// be replayed to repeats of the request. RequestHash fingerprints the request it answered.
+type IdempotentResponse struct {
+	RequestHash string      `json:"request_hash"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
 	jwt "github.com/dgrijalva/jwt-go"
 	"time"
 )
@@ -13,10 +14,12 @@ type Merchant struct {
 	Password  string
 	CreatedAt time.Time
 	UpdatedAt sql.NullTime
+	DeletedAt sql.NullTime
+	Version   int64
 }
 
 func (a *Merchant) GenerateClaims() jwt.MapClaims {
-	return jwt.MapClaims{"id": a.ID}
+	return jwt.MapClaims{"id": a.ID, "role": constant.ROLE_MERCHANT}
 }
 
 type MerchantCreateRequest struct {
@@ -26,9 +29,8 @@ type MerchantCreateRequest struct {
 }
 
 type MerchantListRequest struct {
-	Limit  int
-	Offset int
-	Name   string
+	Page Page
+	Name string
 }
 
 type MerchantGetRequest struct {
@@ -36,9 +38,21 @@ type MerchantGetRequest struct {
 }
 
 type MerchantUpdateRequest struct {
-	ID    int64  `json:"-"`
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
+	ID      int64        `json:"-"`
+	IfMatch Precondition `json:"-"`
+	Name    string       `json:"name" validate:"required"`
+	Email   string       `json:"email" validate:"required,email"`
+}
+
+// MerchantPatchRequest carries an RFC 7396 merge patch of a MerchantUpdateRequest.
+type MerchantPatchRequest struct {
+	ID      int64
+	IfMatch Precondition
+	Patch   []byte
+}
+
+func NewMerchantUpdateRequest(payload *Merchant) *MerchantUpdateRequest {
+	return &MerchantUpdateRequest{ID: payload.ID, Name: payload.Name, Email: payload.Email}
 }
 
 type MerchantPasswordUpdateRequest struct {
@@ -48,15 +62,28 @@ type MerchantPasswordUpdateRequest struct {
 }
 
 type MerchantDeleteRequest struct {
+	ID      int64
+	IfMatch Precondition
+}
+
+type MerchantRestoreRequest struct {
 	ID int64
 }
 
+// MerchantTrashRequest lists the merchant's deleted products, most recently deleted first.
+type MerchantTrashRequest struct {
+	ID   int64
+	Page Page
+}
+
 type MerchantResponse struct {
 	ID        int64      `json:"id"`
 	Name      string     `json:"name"`
 	Email     string     `json:"email"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Version   int64      `json:"version"`
 }
 
 func NewMerchantResponse(payload *Merchant) *MerchantResponse {
@@ -65,10 +92,14 @@ func NewMerchantResponse(payload *Merchant) *MerchantResponse {
 		Name:      payload.Name,
 		Email:     payload.Email,
 		CreatedAt: payload.CreatedAt,
+		Version:   payload.Version,
 	}
 	if payload.UpdatedAt.Valid {
 		res.UpdatedAt = &payload.UpdatedAt.Time
 	}
+	if payload.DeletedAt.Valid {
+		res.DeletedAt = &payload.DeletedAt.Time
+	}
 	return res
 }
 
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const DefaultCurrency = "IDR"
+
+// currencyExponents holds the ISO 4217 minor unit exponent of every accepted currency,
+// e.g. 2 means an amount of 1999 reads as 19.99.
+var currencyExponents = map[string]int{
+	"AUD": 2,
+	"BHD": 3,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"MYR": 2,
+	"PHP": 2,
+	"SGD": 2,
+	"THB": 2,
+	"USD": 2,
+	"VND": 0,
+}
+
+// MaxCurrencyExponent is the largest exponent in use, amounts scaled to it compare across
+// currencies by face value.
+const MaxCurrencyExponent = 3
+
+func IsCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+func CurrencyExponent(code string) int {
+	return currencyExponents[code]
+}
+
+// Currencies lists the accepted currency codes in alphabetical order.
+func Currencies() []string {
+	codes := make([]string, 0, len(currencyExponents))
+	for code := range currencyExponents {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Money is an amount in the minor unit of an ISO 4217 currency, cents for USD, so sums
+// and products of quantities stay exact.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ParseMoney reads a plain decimal such as "19.99" in currency, rejecting negative amounts
+// and more decimal places than the currency has.
+func ParseMoney(amount, currency string) (Money, error) {
+	if !IsCurrency(currency) {
+		return Money{}, constant.ErrCurrencyUnsupported
+	}
+
+	minor, err := ParseDecimal(amount, CurrencyExponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// ParseDecimal converts a non-negative decimal to an integer count of 10^-exponent units.
+func ParseDecimal(s string, exponent int) (int64, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return 0, constant.ErrPriceNegative
+	}
+
+	whole, fraction := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || (strings.Contains(s, ".") && fraction == "") {
+		return 0, constant.ErrPriceInvalid
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, constant.ErrPricePrecision
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	n, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, constant.ErrPriceInvalid
+	}
+	return n, nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) Times(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Scaled returns the amount in 10^-exponent units, exponent being at least the currency's.
+func (m Money) Scaled(exponent int) int64 {
+	n := m.Amount
+	for i := CurrencyExponent(m.Currency); i < exponent; i++ {
+		n *= 10
+	}
+	return n
+}
+
+// String formats the amount as a decimal with the currency's number of places.
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	digits := strconv.FormatInt(m.Amount, 10)
+
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+// MarshalJSON writes the amount as a decimal string so clients never see a float.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.Currency})
+}
+
+// Convert prices the amount in currency at rate units of currency per unit of m.Currency,
+// rounding half away from zero to the minor unit of currency.
+func (m Money) Convert(currency string, rate float64) Money {
+	amount := float64(m.Amount) * rate * math.Pow10(CurrencyExponent(currency)-CurrencyExponent(m.Currency))
+	return Money{Amount: int64(math.Round(amount)), Currency: currency}
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	for amount, expected := range map[string]Money{
+		"19.99":  {1999, "USD"},
+		"19.9":   {1990, "USD"},
+		"19":     {1900, "USD"},
+		"19.990": {1999, "USD"},
+		"0":      {0, "USD"},
+	} {
+		money, err := ParseMoney(amount, "USD")
+		assert.NoError(t, err, amount)
+		assert.Equal(t, expected, money, amount)
+	}
+
+	money, err := ParseMoney("1500", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, Money{1500, "JPY"}, money)
+
+	for _, c := range []struct {
+		amount, currency string
+		err              error
+	}{
+		{"-1", "USD", constant.ErrPriceNegative},
+		{"19.999", "USD", constant.ErrPricePrecision},
+		{"1500.5", "JPY", constant.ErrPricePrecision},
+		{"1e3", "USD", constant.ErrPriceInvalid},
+		{"19.", "USD", constant.ErrPriceInvalid},
+		{".5", "USD", constant.ErrPriceInvalid},
+		{"99999999999999999999", "USD", constant.ErrPriceInvalid},
+		{"10", "XYZ", constant.ErrCurrencyUnsupported},
+		{"10", "usd", constant.ErrCurrencyUnsupported},
+	} {
+		_, err := ParseMoney(c.amount, c.currency)
+		assert.Equal(t, c.err, err, c.amount+" "+c.currency)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "19.99", Money{1999, "USD"}.String())
+	assert.Equal(t, "0.05", Money{5, "USD"}.String())
+	assert.Equal(t, "-0.05", Money{-5, "USD"}.String())
+	assert.Equal(t, "1500", Money{1500, "JPY"}.String())
+	assert.Equal(t, "1.250", Money{1250, "KWD"}.String())
+
+	b, err := json.Marshal(Money{1999, "USD"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"19.99","currency":"USD"}`, string(b))
+}
+
+func TestMoneyScaled(t *testing.T) {
+	assert.Equal(t, int64(19990), Money{1999, "USD"}.Scaled(MaxCurrencyExponent))
+	assert.Equal(t, int64(1500000), Money{1500, "JPY"}.Scaled(MaxCurrencyExponent))
+	assert.Equal(t, int64(1250), Money{1250, "KWD"}.Scaled(MaxCurrencyExponent))
+}
+
+func TestMoneyConvert(t *testing.T) {
+	assert.Equal(t, Money{2849950, "IDR"}, Money{199, "USD"}.Convert("IDR", 14321.357))
+	assert.Equal(t, Money{218, "JPY"}, Money{199, "USD"}.Convert("JPY", 109.5))
+	assert.Equal(t, Money{183, "USD"}, Money{200, "JPY"}.Convert("USD", 1/109.5))
+	assert.Equal(t, Money{6010, "KWD"}, Money{199, "USD"}.Convert("KWD", 3.02))
+}
@@ -0,0 +1,279 @@
+package model
+
+import (
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending: {OrderPaid, OrderCancelled},
+	OrderPaid:    {OrderShipped, OrderCancelled},
+	OrderShipped: {OrderDelivered},
+}
+
+// orderProgress ranks the statuses an order goes through until it is delivered.
+var orderProgress = []OrderStatus{OrderPending, OrderPaid, OrderShipped, OrderDelivered}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, status := range orderTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s OrderStatus) progress() int {
+	for i, status := range orderProgress {
+		if status == s {
+			return i
+		}
+	}
+	return len(orderProgress)
+}
+
+// Total is what the buyer pays, the sum of the items no merchant cancelled less Discount.
+type Order struct {
+	ID        int64
+	BuyerID   int64
+	Status    OrderStatus
+	Total     Money
+	Discount  Money
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+
+	Items      []*OrderItem
+	Promotions []*AppliedPromotion
+
+	// PaymentPending is set while a payment of the order awaits the provider's outcome,
+	// nothing of the order may be cancelled then.
+	PaymentPending bool
+}
+
+func (o *Order) HasMerchant(merchantID int64) bool {
+	for _, item := range o.Items {
+		if item.MerchantID == merchantID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStatus moves the whole order, the lines of every merchant with it.
+func (o *Order) SetStatus(status OrderStatus, now time.Time) error {
+	if !o.Status.CanTransitionTo(status) {
+		return constant.ErrInvalidOrderTransition
+	} else if status == OrderCancelled && o.PaymentPending {
+		return constant.ErrOrderPaymentPending
+	}
+
+	for _, item := range o.Items {
+		item.Status = status
+	}
+	o.Status = status
+	o.calculateTotal()
+	o.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	return nil
+}
+
+// Advance moves the lines of one merchant to status and the order with the merchant that
+// is furthest behind, it is cancelled once every merchant cancelled. Cancelled lines drop
+// out of Total. Cancelling and shipping exclude each other: no merchant cancels once
+// another shipped, or ships once another cancelled.
+func (o *Order) Advance(merchantID int64, status OrderStatus, now time.Time) error {
+	if status == OrderCancelled && o.PaymentPending {
+		return constant.ErrOrderPaymentPending
+	}
+
+	for _, item := range o.Items {
+		switch {
+		case item.MerchantID == merchantID:
+			if !item.Status.CanTransitionTo(status) {
+				return constant.ErrInvalidOrderTransition
+			}
+		case status == OrderCancelled && (item.Status == OrderShipped || item.Status == OrderDelivered),
+			status != OrderCancelled && item.Status == OrderCancelled:
+			return constant.ErrInvalidOrderTransition
+		}
+	}
+
+	o.Status = OrderCancelled
+	for _, item := range o.Items {
+		if item.MerchantID == merchantID {
+			item.Status = status
+		}
+		if item.Status != OrderCancelled && item.Status.progress() < o.Status.progress() {
+			o.Status = item.Status
+		}
+	}
+	o.calculateTotal()
+	o.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	return nil
+}
+
+// AddItem adds quantity of product to the order, every product of an order must be priced
+// in the same currency.
+func (o *Order) AddItem(product *Product, quantity int) error {
+	price := product.EffectivePrice()
+	if len(o.Items) > 0 && o.Total.Currency != price.Currency {
+		return constant.ErrOrderCurrencyMismatch
+	}
+
+	for _, item := range o.Items {
+		if item.ProductID == product.ID {
+			item.Quantity += quantity
+			item.Subtotal = item.UnitPrice.Times(item.Quantity)
+			o.calculateTotal()
+			return nil
+		}
+	}
+
+	o.Items = append(o.Items, &OrderItem{
+		ProductID:  product.ID,
+		MerchantID: product.MerchantID,
+		Name:       product.Name,
+		Status:     OrderPending,
+		UnitPrice:  price,
+		Quantity:   quantity,
+		Subtotal:   price.Times(quantity),
+	})
+	o.calculateTotal()
+	return nil
+}
+
+// Payable is what the buyer owes for the items no merchant cancelled, less Discount and
+// never below zero. Total keeps it as of the last change of the order.
+func (o *Order) Payable() Money {
+	payable := Money{Currency: o.Total.Currency}
+	if len(o.Items) > 0 {
+		payable.Currency = o.Items[0].UnitPrice.Currency
+	}
+	for _, item := range o.Items {
+		if item.Status != OrderCancelled {
+			payable.Amount += item.Subtotal.Amount
+		}
+	}
+	payable.Amount -= o.Discount.Amount
+	if payable.Amount < 0 {
+		payable.Amount = 0
+	}
+	return payable
+}
+
+func (o *Order) calculateTotal() {
+	o.Total = o.Payable()
+}
+
+// ApplyPromotions discounts the order by an evaluation of its items.
+func (o *Order) ApplyPromotions(evaluation *PromotionEvaluation) {
+	o.Discount = evaluation.Discount
+	o.Promotions = evaluation.Applied
+	o.calculateTotal()
+}
+
+type OrderItem struct {
+	ID         int64
+	OrderID    int64
+	ProductID  int64
+	MerchantID int64
+	Name       string
+	Status     OrderStatus
+	UnitPrice  Money
+	Quantity   int
+	Subtotal   Money
+}
+
+type OrderItemRequest struct {
+	ProductID int64 `json:"product_id" validate:"required"`
+	Quantity  int   `json:"quantity" validate:"required,gt=0"`
+}
+
+type OrderCreateRequest struct {
+	Items []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	Codes []string           `json:"codes" validate:"omitempty,max=10,dive,required"`
+}
+
+type OrderListRequest struct {
+	Limit  int
+	Offset int
+}
+
+type OrderGetRequest struct {
+	ID int64
+}
+
+type OrderStatusUpdateRequest struct {
+	ID     int64       `json:"-"`
+	Status OrderStatus `json:"status" validate:"required,oneof=shipped delivered cancelled"`
+}
+
+type OrderItemResponse struct {
+	ID         int64       `json:"id"`
+	ProductID  int64       `json:"product_id"`
+	MerchantID int64       `json:"merchant_id"`
+	Name       string      `json:"name"`
+	Status     OrderStatus `json:"status"`
+	UnitPrice  Money       `json:"unit_price"`
+	Quantity   int         `json:"quantity"`
+	Subtotal   Money       `json:"subtotal"`
+}
+
+type OrderResponse struct {
+	ID        int64                `json:"id"`
+	BuyerID   int64                `json:"buyer_id"`
+	Status    OrderStatus          `json:"status"`
+	Subtotal  Money                `json:"subtotal"`
+	Discount  Money                `json:"discount"`
+	Total     Money                `json:"total"`
+	Items     []*OrderItemResponse `json:"items"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt *time.Time           `json:"updated_at"`
+}
+
+func NewOrderResponse(payload *Order) *OrderResponse {
+	res := &OrderResponse{
+		ID:        payload.ID,
+		BuyerID:   payload.BuyerID,
+		Status:    payload.Status,
+		Subtotal:  Money{Amount: payload.Total.Amount + payload.Discount.Amount, Currency: payload.Total.Currency},
+		Discount:  Money{Amount: payload.Discount.Amount, Currency: payload.Total.Currency},
+		Total:     payload.Total,
+		Items:     make([]*OrderItemResponse, len(payload.Items)),
+		CreatedAt: payload.CreatedAt,
+	}
+	for i, item := range payload.Items {
+		res.Items[i] = &OrderItemResponse{
+			ID:         item.ID,
+			ProductID:  item.ProductID,
+			MerchantID: item.MerchantID,
+			Name:       item.Name,
+			Status:     item.Status,
+			UnitPrice:  item.UnitPrice,
+			Quantity:   item.Quantity,
+			Subtotal:   item.Subtotal,
+		}
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
+
+func NewOrderListResponse(payloads []*Order) []*OrderResponse {
+	res := make([]*OrderResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewOrderResponse(payload)
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatusTransition(t *testing.T) {
+	allowed := map[OrderStatus][]OrderStatus{
+		OrderPending:   {OrderPaid, OrderCancelled},
+		OrderPaid:      {OrderShipped, OrderCancelled},
+		OrderShipped:   {OrderDelivered},
+		OrderDelivered: {},
+		OrderCancelled: {},
+	}
+	all := []OrderStatus{OrderPending, OrderPaid, OrderShipped, OrderDelivered, OrderCancelled}
+
+	for from, nexts := range allowed {
+		for _, to := range all {
+			expected := false
+			for _, next := range nexts {
+				expected = expected || next == to
+			}
+			assert.Equal(t, expected, from.CanTransitionTo(to), "%s -> %s", from, to)
+		}
+	}
+}
+
+func TestOrderAddItem(t *testing.T) {
+	order := new(Order)
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Name: "a", Price: Money{1500, "IDR"}, MerchantID: 7}, 2))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Name: "b", Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Name: "a", Price: Money{1500, "IDR"}, MerchantID: 7}, 1))
+
+	assert.Len(t, order.Items, 2)
+	assert.Equal(t, 3, order.Items[0].Quantity)
+	assert.Equal(t, Money{4500, "IDR"}, order.Items[0].Subtotal)
+	assert.Equal(t, Money{4750, "IDR"}, order.Total)
+	assert.True(t, order.HasMerchant(8))
+	assert.False(t, order.HasMerchant(9))
+
+	err := order.AddItem(&Product{ID: 3, Name: "c", Price: Money{100, "USD"}, MerchantID: 7}, 1)
+	assert.Equal(t, constant.ErrOrderCurrencyMismatch, err)
+	assert.Len(t, order.Items, 2)
+}
+
+func TestOrderAddItemOnSale(t *testing.T) {
+	order := new(Order)
+	rule := &PriceRule{Percentage: sql.NullInt64{Int64: 20, Valid: true}, Active: true}
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, PriceRule: rule}, 2))
+
+	assert.Equal(t, Money{1200, "IDR"}, order.Items[0].UnitPrice)
+	assert.Equal(t, Money{2400, "IDR"}, order.Total)
+}
+
+func TestOrderSetStatus(t *testing.T) {
+	order := new(Order)
+	order.Status = OrderPending
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, MerchantID: 7}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+
+	assert.NoError(t, order.SetStatus(OrderCancelled, time.Now()))
+	assert.Equal(t, OrderCancelled, order.Status)
+	assert.Equal(t, OrderCancelled, order.Items[0].Status)
+	assert.Equal(t, OrderCancelled, order.Items[1].Status)
+	assert.True(t, order.UpdatedAt.Valid)
+	assert.Equal(t, constant.ErrInvalidOrderTransition, order.SetStatus(OrderPaid, time.Now()))
+}
+
+func TestOrderAdvance(t *testing.T) {
+	now := time.Now()
+	order := new(Order)
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, MerchantID: 7}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+	order.Status = OrderPending
+	assert.NoError(t, order.SetStatus(OrderPaid, now))
+
+	assert.NoError(t, order.Advance(7, OrderShipped, now))
+	assert.Equal(t, OrderShipped, order.Items[0].Status)
+	assert.Equal(t, OrderPaid, order.Items[1].Status)
+	assert.Equal(t, OrderPaid, order.Status, "the order waits for every merchant")
+
+	assert.NoError(t, order.Advance(7, OrderDelivered, now))
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.Equal(t, constant.ErrInvalidOrderTransition, order.Advance(8, OrderCancelled, now), "another merchant shipped")
+	assert.Equal(t, constant.ErrInvalidOrderTransition, order.Advance(7, OrderShipped, now))
+
+	assert.NoError(t, order.Advance(8, OrderShipped, now))
+	assert.Equal(t, OrderShipped, order.Status)
+	assert.NoError(t, order.Advance(8, OrderDelivered, now))
+	assert.Equal(t, OrderDelivered, order.Status)
+}
+
+func TestOrderAdvanceCancel(t *testing.T) {
+	now := time.Now()
+	order := new(Order)
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, MerchantID: 7}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+	order.Status = OrderPending
+	assert.NoError(t, order.SetStatus(OrderPaid, now))
+
+	assert.NoError(t, order.Advance(7, OrderCancelled, now))
+	assert.Equal(t, OrderPaid, order.Status, "one merchant cannot cancel the whole order")
+	assert.Equal(t, constant.ErrInvalidOrderTransition, order.Advance(8, OrderShipped, now), "another merchant cancelled")
+
+	assert.NoError(t, order.Advance(8, OrderCancelled, now))
+	assert.Equal(t, OrderCancelled, order.Status)
+}
+
+func TestOrderAdvanceCancelTotal(t *testing.T) {
+	now := time.Now()
+	order := &Order{Status: OrderPending}
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, MerchantID: 7}, 2))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+	order.ApplyPromotions(&PromotionEvaluation{Discount: Money{100, "IDR"}})
+	assert.Equal(t, Money{3150, "IDR"}, order.Total)
+
+	assert.NoError(t, order.Advance(7, OrderCancelled, now))
+	assert.Equal(t, Money{150, "IDR"}, order.Total, "the buyer only pays for what is still coming")
+
+	assert.NoError(t, order.Advance(8, OrderCancelled, now))
+	assert.Equal(t, Money{0, "IDR"}, order.Total, "the discount never turns into a credit")
+}
+
+func TestOrderCancelWhilePaymentPending(t *testing.T) {
+	now := time.Now()
+	order := &Order{Status: OrderPending, PaymentPending: true}
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{1500, "IDR"}, MerchantID: 7}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{250, "IDR"}, MerchantID: 8}, 1))
+
+	assert.Equal(t, constant.ErrOrderPaymentPending, order.SetStatus(OrderCancelled, now))
+	assert.Equal(t, constant.ErrOrderPaymentPending, order.Advance(7, OrderCancelled, now))
+	assert.Equal(t, OrderPending, order.Status)
+	assert.Equal(t, OrderPending, order.Items[0].Status)
+
+	order.PaymentPending = false
+	assert.NoError(t, order.Advance(7, OrderCancelled, now))
+}
@@ -0,0 +1,72 @@
+package model
+
+import "strings"
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+func (f SortField) String() string {
+	if f.Desc {
+		return "-" + f.Field
+	}
+	return f.Field
+}
+
+func SortString(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Cursor points at a row of a keyset paginated list: Keys holds the row's values for the
+// list's sort fields and ID breaks ties. Backward cursors select the rows before it.
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Keys     []string `json:"k"`
+	ID       int64    `json:"i"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Page selects either offset pagination or, with UseCursor, keyset pagination starting
+// at Cursor (the first page when Cursor is nil).
+type Page struct {
+	Limit     int
+	Offset    int
+	UseCursor bool
+	Cursor    *Cursor
+}
+
+type PageInfo struct {
+	Total   int64
+	Next    *Cursor
+	Prev    *Cursor
+	HasMore bool
+}
+
+// SetTotal records the number of rows matching the filter. The count may be cached, so
+// whether more rows follow is left to the extra row every page fetches.
+func (i *PageInfo) SetTotal(total int64) {
+	i.Total = total
+}
+
+type ListLinks struct {
+	Next *string `json:"next"`
+	Prev *string `json:"prev"`
+}
+
+// ListResponse wraps every list endpoint. Offset is only set in offset mode and the
+// cursors only in cursor mode; Total always counts every row matching the filter.
+type ListResponse struct {
+	Data       interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	Offset     *int        `json:"offset,omitempty"`
+	NextCursor *string     `json:"next_cursor,omitempty"`
+	PrevCursor *string     `json:"prev_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+	Links      ListLinks   `json:"links"`
+}
@@ -0,0 +1,14 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageInfoSetTotal(t *testing.T) {
+	info := &PageInfo{HasMore: true}
+	info.SetTotal(10)
+	assert.Equal(t, int64(10), info.Total)
+	assert.True(t, info.HasMore, "pages keep what the extra row told them, the total may be cached")
+}
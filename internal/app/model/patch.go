@@ -0,0 +1,61 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var errTrailingJSON = errors.New("unexpected data after the JSON document")
+
+// MergePatch applies the RFC 7396 merge patch patch to the JSON document target: members of
+// a patch object replace those of the target recursively, null members remove them and any
+// other patch replaces the target whole. Numbers keep their exact text.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	targetValue, err := decodeJSON(target)
+	if err != nil {
+		return nil, err
+	}
+	patchValue, err := decodeJSON(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergePatch(targetValue, patchValue))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, isObject := patch.(map[string]interface{})
+	if !isObject {
+		return patch
+	}
+
+	targetObject, isObject := target.(map[string]interface{})
+	if !isObject {
+		targetObject = map[string]interface{}{}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, errTrailingJSON
+	}
+	return value, nil
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := `{"name":"Kopi","price":19.990,"tags":["a"],"meta":{"a":1,"b":2}}`
+
+	cases := []struct {
+		patch, want string
+	}{
+		{`{}`, `{"meta":{"a":1,"b":2},"name":"Kopi","price":19.990,"tags":["a"]}`},
+		{`{"name":"Teh"}`, `{"meta":{"a":1,"b":2},"name":"Teh","price":19.990,"tags":["a"]}`},
+		{`{"name":null,"tags":["b","c"]}`, `{"meta":{"a":1,"b":2},"price":19.990,"tags":["b","c"]}`},
+		{`{"meta":{"a":null,"c":{"d":3}}}`, `{"meta":{"b":2,"c":{"d":3}},"name":"Kopi","price":19.990,"tags":["a"]}`},
+		{`{"tags":{"x":null}}`, `{"meta":{"a":1,"b":2},"name":"Kopi","price":19.990,"tags":{}}`},
+		{`["whole"]`, `["whole"]`},
+	}
+	for _, c := range cases {
+		merged, err := MergePatch([]byte(target), []byte(c.patch))
+		assert.NoError(t, err, c.patch)
+		assert.JSONEq(t, c.want, string(merged), c.patch)
+	}
+	merged, _ := MergePatch([]byte(target), []byte(`{}`))
+	assert.Contains(t, string(merged), `19.990`, "numbers keep their text")
+
+	for _, patch := range []string{``, `{`, `{"name":"Teh"} {}`, `{"name":"Teh"}}`} {
+		_, err := MergePatch([]byte(target), []byte(patch))
+		assert.Error(t, err, patch)
+	}
+}
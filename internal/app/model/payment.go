@@ -0,0 +1,91 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPending   PaymentStatus = "pending"
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	// PaymentRefundDue marks money the provider collected that cannot pay for the order,
+	// it has to be refunded.
+	PaymentRefundDue PaymentStatus = "refund_due"
+)
+
+// Payment is an attempt to collect an order's total through a payment provider's intent.
+// An order has at most one pending payment, a new one is only made after a failure.
+type Payment struct {
+	ID             int64
+	OrderID        int64
+	Provider       string
+	IntentID       string
+	IdempotencyKey string
+	Amount         Money
+	Status         PaymentStatus
+	ClientSecret   string
+	CreatedAt      time.Time
+	UpdatedAt      sql.NullTime
+}
+
+// Settle records the outcome the provider reported for a pending payment. A succeeded
+// intent only pays the order when it collected exactly the payment's amount while the
+// order was still pending, an order cancelled in the meantime cannot be paid for. The
+// payment is PaymentRefundDue otherwise. It reports whether the order is paid.
+func (p *Payment) Settle(status PaymentStatus, collected Money, order OrderStatus, now time.Time) bool {
+	if status == PaymentSucceeded && (collected != p.Amount || order != OrderPending) {
+		status = PaymentRefundDue
+	}
+
+	p.Status = status
+	p.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	return status == PaymentSucceeded
+}
+
+// PaymentIdempotencyKey names the attempt-th payment of an order. Concurrent or retried
+// checkouts of the same attempt send the provider the same key and get the same intent.
+func PaymentIdempotencyKey(orderID int64, attempt int) string {
+	return fmt.Sprintf("order-%d-payment-%d", orderID, attempt)
+}
+
+type CheckoutRequest struct {
+	OrderID int64
+}
+
+type PaymentWebhookRequest struct {
+	Payload   []byte
+	Signature string
+}
+
+type PaymentResponse struct {
+	ID           int64         `json:"id"`
+	OrderID      int64         `json:"order_id"`
+	Provider     string        `json:"provider"`
+	IntentID     string        `json:"intent_id"`
+	Amount       Money         `json:"amount"`
+	Status       PaymentStatus `json:"status"`
+	ClientSecret string        `json:"client_secret"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    *time.Time    `json:"updated_at"`
+}
+
+func NewPaymentResponse(payload *Payment) *PaymentResponse {
+	res := &PaymentResponse{
+		ID:           payload.ID,
+		OrderID:      payload.OrderID,
+		Provider:     payload.Provider,
+		IntentID:     payload.IntentID,
+		Amount:       payload.Amount,
+		Status:       payload.Status,
+		ClientSecret: payload.ClientSecret,
+		CreatedAt:    payload.CreatedAt,
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentSettle(t *testing.T) {
+	now := time.Now()
+	amount := Money{1999, "USD"}
+	pending := func() *Payment { return &Payment{Amount: amount, Status: PaymentPending} }
+
+	payment := pending()
+	assert.True(t, payment.Settle(PaymentSucceeded, amount, OrderPending, now))
+	assert.Equal(t, PaymentSucceeded, payment.Status)
+	assert.Equal(t, now, payment.UpdatedAt.Time)
+
+	payment = pending()
+	assert.False(t, payment.Settle(PaymentFailed, Money{}, OrderPending, now))
+	assert.Equal(t, PaymentFailed, payment.Status)
+
+	tests := map[string]struct {
+		collected Money
+		order     OrderStatus
+	}{
+		"order cancelled meanwhile":     {amount, OrderCancelled},
+		"order paid by another payment": {amount, OrderPaid},
+		"less collected":                {Money{999, "USD"}, OrderPending},
+		"other currency":                {Money{1999, "EUR"}, OrderPending},
+	}
+	for name, tt := range tests {
+		payment = pending()
+		assert.False(t, payment.Settle(PaymentSucceeded, tt.collected, tt.order, now), name)
+		assert.Equal(t, PaymentRefundDue, payment.Status, name)
+	}
+
+	payment = pending()
+	assert.False(t, payment.Settle(PaymentFailed, Money{}, OrderCancelled, now))
+	assert.Equal(t, PaymentFailed, payment.Status, "nothing was collected")
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// PriceRule puts a product on sale from StartsAt until EndsAt, either at SalePrice, an
+// amount in the product currency, or at Percentage off. Active is switched by the
+// scheduler when the period starts and ends; only an active rule changes the price.
+type PriceRule struct {
+	ID         int64
+	ProductID  int64
+	StartsAt   time.Time
+	EndsAt     time.Time
+	SalePrice  sql.NullInt64
+	Percentage sql.NullInt64
+	Active     bool
+	CreatedAt  time.Time
+}
+
+// Apply discounts price, rounding percentages half up to the minor unit. A sale never
+// raises a price, so a sale price above it leaves it unchanged.
+func (r *PriceRule) Apply(price Money) Money {
+	switch {
+	case r.SalePrice.Valid && r.SalePrice.Int64 < price.Amount:
+		return Money{Amount: r.SalePrice.Int64, Currency: price.Currency}
+	case r.Percentage.Valid:
+		discount := (price.Amount*r.Percentage.Int64 + 50) / 100
+		return Money{Amount: price.Amount - discount, Currency: price.Currency}
+	default:
+		return price
+	}
+}
+
+// Overlaps reports whether both rules are in force at some moment, periods include their
+// start only.
+func (r *PriceRule) Overlaps(other *PriceRule) bool {
+	return r.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(r.EndsAt)
+}
+
+// Ended reports whether the rule can no longer take effect at now.
+func (r *PriceRule) Ended(now time.Time) bool {
+	return !now.Before(r.EndsAt)
+}
+
+// EffectivePrice is the price the product sells at now, its regular price unless a price
+// rule is active.
+func (p *Product) EffectivePrice() Money {
+	if p.PriceRule != nil {
+		return p.PriceRule.Apply(p.Price)
+	}
+	return p.Price
+}
+
+type PriceRuleListRequest struct {
+	ProductID int64
+}
+
+// SalePrice is in the product currency. Exactly one of SalePrice and Percentage is set.
+type PriceRuleCreateRequest struct {
+	ProductID  int64        `json:"-"`
+	StartsAt   time.Time    `json:"starts_at" validate:"required"`
+	EndsAt     time.Time    `json:"ends_at" validate:"required,gtfield=StartsAt"`
+	SalePrice  *json.Number `json:"sale_price" validate:"required_without=Percentage"`
+	Percentage *int64       `json:"percentage" validate:"required_without=SalePrice,omitempty,min=1,max=99"`
+}
+
+type PriceRuleDeleteRequest struct {
+	ProductID int64
+	RuleID    int64
+}
+
+type PriceRuleResponse struct {
+	ID         int64     `json:"id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	SalePrice  *Money    `json:"sale_price"`
+	Percentage *int64    `json:"percentage"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewPriceRuleResponse(payload *PriceRule, currency string) *PriceRuleResponse {
+	res := &PriceRuleResponse{
+		ID:        payload.ID,
+		StartsAt:  payload.StartsAt,
+		EndsAt:    payload.EndsAt,
+		Active:    payload.Active,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.SalePrice.Valid {
+		res.SalePrice = &Money{Amount: payload.SalePrice.Int64, Currency: currency}
+	}
+	if payload.Percentage.Valid {
+		res.Percentage = &payload.Percentage.Int64
+	}
+	return res
+}
+
+func NewPriceRuleListResponse(payloads []*PriceRule, currency string) []*PriceRuleResponse {
+	res := make([]*PriceRuleResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewPriceRuleResponse(payload, currency)
+	}
+	return res
+}
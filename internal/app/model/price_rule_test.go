@@ -0,0 +1,53 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceRuleApply(t *testing.T) {
+	price := Money{1999, "USD"}
+
+	sale := &PriceRule{SalePrice: sql.NullInt64{Int64: 1500, Valid: true}}
+	assert.Equal(t, Money{1500, "USD"}, sale.Apply(price))
+	assert.Equal(t, Money{1000, "USD"}, sale.Apply(Money{1000, "USD"}), "sale price above the price")
+
+	percentage := &PriceRule{Percentage: sql.NullInt64{Int64: 15, Valid: true}}
+	assert.Equal(t, Money{1699, "USD"}, percentage.Apply(price), "299.85 rounds to 300")
+	assert.Equal(t, Money{0, "USD"}, percentage.Apply(Money{0, "USD"}))
+}
+
+func TestPriceRuleOverlaps(t *testing.T) {
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	rule := &PriceRule{StartsAt: at, EndsAt: at.Add(48 * time.Hour)}
+
+	assert.True(t, rule.Overlaps(&PriceRule{StartsAt: at.Add(24 * time.Hour), EndsAt: at.Add(72 * time.Hour)}))
+	assert.True(t, rule.Overlaps(&PriceRule{StartsAt: at.Add(-time.Hour), EndsAt: at.Add(time.Hour)}))
+	assert.False(t, rule.Overlaps(&PriceRule{StartsAt: at.Add(48 * time.Hour), EndsAt: at.Add(72 * time.Hour)}), "back to back")
+	assert.False(t, rule.Overlaps(&PriceRule{StartsAt: at.Add(-time.Hour), EndsAt: at}))
+
+	assert.False(t, rule.Ended(at.Add(47*time.Hour)))
+	assert.True(t, rule.Ended(at.Add(48*time.Hour)))
+}
+
+func TestProductEffectivePrice(t *testing.T) {
+	product := &Product{Price: Money{1000, "IDR"}}
+	assert.Equal(t, Money{1000, "IDR"}, product.EffectivePrice())
+
+	product.PriceRule = &PriceRule{ID: 3, SalePrice: sql.NullInt64{Int64: 800, Valid: true}, Active: true}
+	product.Variants = []*ProductVariant{
+		{ID: 1, PriceOverride: sql.NullInt64{Int64: 1200, Valid: true}},
+		{ID: 2, PriceOverride: sql.NullInt64{Int64: 600, Valid: true}},
+	}
+	assert.Equal(t, Money{800, "IDR"}, product.EffectivePrice())
+
+	res := NewProductResponse(product)
+	assert.Equal(t, Money{1000, "IDR"}, res.Price)
+	assert.Equal(t, Money{800, "IDR"}, res.EffectivePrice)
+	assert.Equal(t, int64(3), res.Sale.ID)
+	assert.Equal(t, Money{800, "IDR"}, res.Variants[0].EffectivePrice)
+	assert.Equal(t, Money{600, "IDR"}, res.Variants[1].EffectivePrice)
+}
@@ -2,71 +2,190 @@ package model
 
 import (
 	"database/sql"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/exchange"
+	"github.com/SemmiDev/go-product/internal/search"
 	"time"
 )
 
 type Product struct {
 	ID        int64
 	Name      string
-	Price     float32
+	Price     Money
 	CreatedAt time.Time
 	UpdatedAt sql.NullTime
+	DeletedAt sql.NullTime
+	Version   int64
 
 	MerchantID int64
 	Merchant   Merchant
+
+	Images   []*ProductImage
+	Options  []*ProductOption
+	Variants []*ProductVariant
+
+	// PriceRule is the active price rule, if any
+	PriceRule *PriceRule
+}
+
+// DeletedWithMerchant reports whether the product was deleted together with its merchant,
+// which stamps both with the same deleted_at. Products deleted on their own before do not
+// come back when the merchant is restored.
+func (p *Product) DeletedWithMerchant() bool {
+	return p.DeletedAt.Valid && p.Merchant.DeletedAt.Valid && p.DeletedAt.Time.Equal(p.Merchant.DeletedAt.Time)
+}
+
+// PurgeDue reports whether a purge of everything deleted before the given time removes the
+// product: it was deleted before then, its merchant was, or its merchant, with a zero ID,
+// no longer exists.
+func (p *Product) PurgeDue(before time.Time) bool {
+	return deletedBefore(p.DeletedAt, before) || p.Merchant.ID == 0 || deletedBefore(p.Merchant.DeletedAt, before)
 }
 
+func deletedBefore(deletedAt sql.NullTime, before time.Time) bool {
+	return deletedAt.Valid && deletedAt.Time.Before(before)
+}
+
+// Price is read as a json.Number so a decimal like 19.99 never passes through a float.
+// Currency defaults to DefaultCurrency on create and to the current one on update.
 type ProductCreateRequest struct {
-	Name  string  `json:"name" validate:"required"`
-	Price float32 `json:"price" validate:"required"`
+	Name     string      `json:"name" validate:"required"`
+	Price    json.Number `json:"price" validate:"required"`
+	Currency string      `json:"currency" validate:"omitempty,len=3"`
 }
 
+// Currency, when set, asks for prices converted to it.
 type ProductListRequest struct {
-	Limit  int
-	Offset int
-	Title  string
+	Page     Page
+	Filter   ProductFilter
+	Currency string
 }
 
 type ProductGetRequest struct {
-	ID int64
+	ID       int64
+	Currency string
 }
 
 type ProductUpdateRequest struct {
-	ID    int64   `json:"-"`
-	Name  string  `json:"name" validate:"required"`
-	Price float32 `json:"price" validate:"required"`
+	ID       int64        `json:"-"`
+	IfMatch  Precondition `json:"-"`
+	Name     string       `json:"name" validate:"required"`
+	Price    json.Number  `json:"price" validate:"required"`
+	Currency string       `json:"currency" validate:"omitempty,len=3"`
+}
+
+// ProductPatchRequest carries an RFC 7396 merge patch of a ProductUpdateRequest.
+type ProductPatchRequest struct {
+	ID      int64
+	IfMatch Precondition
+	Patch   []byte
+}
+
+func NewProductUpdateRequest(payload *Product) *ProductUpdateRequest {
+	return &ProductUpdateRequest{
+		ID:       payload.ID,
+		Name:     payload.Name,
+		Price:    json.Number(payload.Price.String()),
+		Currency: payload.Price.Currency,
+	}
 }
 
 type ProductDeleteRequest struct {
+	ID      int64
+	IfMatch Precondition
+}
+
+type ProductRestoreRequest struct {
 	ID int64
 }
 
+// Price is the regular price and EffectivePrice what the product sells at now, lowered by
+// the active price rule described in Sale.
 type ProductResponse struct {
-	ID        int64      `json:"id"`
-	Name      string     `json:"name"`
-	Price     float32    `json:"price"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID             int64              `json:"id"`
+	Name           string             `json:"name"`
+	Price          Money              `json:"price"`
+	EffectivePrice Money              `json:"effective_price"`
+	Sale           *PriceRuleResponse `json:"sale,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      *time.Time         `json:"updated_at"`
+	DeletedAt      *time.Time         `json:"deleted_at,omitempty"`
+	Version        int64              `json:"version"`
+
+	OriginalPrice *Money                `json:"original_price,omitempty"`
+	ExchangeRate  *ExchangeRateResponse `json:"exchange_rate,omitempty"`
 
 	MerchantID int64             `json:"merchant_id"`
 	Merchant   *MerchantResponse `json:"merchant"`
+
+	Images   []*ProductImageResponse   `json:"images"`
+	Options  []*ProductOptionResponse  `json:"options"`
+	Variants []*ProductVariantResponse `json:"variants"`
 }
 
 func NewProductResponse(payload *Product) *ProductResponse {
 	res := &ProductResponse{
-		ID:         payload.ID,
-		Name:       payload.Name,
-		Price:      payload.Price,
-		CreatedAt:  payload.CreatedAt,
-		MerchantID: payload.MerchantID,
-		Merchant:   NewMerchantResponse(&payload.Merchant),
+		ID:             payload.ID,
+		Name:           payload.Name,
+		Price:          payload.Price,
+		EffectivePrice: payload.EffectivePrice(),
+		CreatedAt:      payload.CreatedAt,
+		Version:        payload.Version,
+		MerchantID:     payload.MerchantID,
+		Merchant:       NewMerchantResponse(&payload.Merchant),
+		Images:         NewProductImageListResponse(payload.Images),
+		Options:        NewProductOptionListResponse(payload.Options),
+		Variants:       NewProductVariantListResponse(payload.Variants, payload),
+	}
+	if payload.PriceRule != nil {
+		res.Sale = NewPriceRuleResponse(payload.PriceRule, payload.Price.Currency)
 	}
 	if payload.UpdatedAt.Valid {
 		res.UpdatedAt = &payload.UpdatedAt.Time
 	}
+	if payload.DeletedAt.Valid {
+		res.DeletedAt = &payload.DeletedAt.Time
+	}
 	return res
 }
 
+// ConvertPrice replaces the product, sale and variant prices with their value in currency,
+// keeping the original and the rate used. Prices already in currency are left alone.
+func (r *ProductResponse) ConvertPrice(currency string, rates *exchange.Rates) error {
+	if r.Price.Currency == currency {
+		return nil
+	}
+
+	rate, err := rates.Rate(r.Price.Currency, currency)
+	if err != nil {
+		return err
+	}
+
+	original := r.Price
+	r.Price = original.Convert(currency, rate)
+	r.EffectivePrice = r.EffectivePrice.Convert(currency, rate)
+	r.OriginalPrice = &original
+	if r.Sale != nil && r.Sale.SalePrice != nil {
+		salePrice := r.Sale.SalePrice.Convert(currency, rate)
+		r.Sale.SalePrice = &salePrice
+	}
+	for _, variant := range r.Variants {
+		variant.Price = variant.Price.Convert(currency, rate)
+		variant.EffectivePrice = variant.EffectivePrice.Convert(currency, rate)
+		if variant.PriceOverride != nil {
+			override := variant.PriceOverride.Convert(currency, rate)
+			variant.PriceOverride = &override
+		}
+	}
+	r.ExchangeRate = &ExchangeRateResponse{
+		From:      original.Currency,
+		To:        currency,
+		Rate:      rate,
+		Timestamp: rates.Timestamp,
+	}
+	return nil
+}
+
 func NewProductListResponse(payloads []*Product) []*ProductResponse {
 	res := make([]*ProductResponse, len(payloads))
 	for i, payload := range payloads {
@@ -74,3 +193,32 @@ func NewProductListResponse(payloads []*Product) []*ProductResponse {
 	}
 	return res
 }
+
+type ProductSearchRequest struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+type ProductSearchHit struct {
+	Product *Product
+	Score   float64
+}
+
+type ProductSearchResponse struct {
+	*ProductResponse
+	Score     float64 `json:"score"`
+	Highlight string  `json:"highlight"`
+}
+
+func NewProductSearchListResponse(payloads []*ProductSearchHit, query string) []*ProductSearchResponse {
+	res := make([]*ProductSearchResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = &ProductSearchResponse{
+			ProductResponse: NewProductResponse(payload.Product),
+			Score:           payload.Score,
+			Highlight:       search.Highlight(payload.Product.Name, query),
+		}
+	}
+	return res
+}
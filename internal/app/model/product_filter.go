@@ -0,0 +1,148 @@
+package model
+
+import (
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	DefaultProductSort = []SortField{{Field: "created_at", Desc: true}}
+
+	productSortFields = map[string]bool{
+		"name":       true,
+		"price":      true,
+		"created_at": true,
+	}
+)
+
+type ProductFilter struct {
+	Name               string
+	CategorySlug       string
+	IncludeDescendants bool
+	MerchantID         int64
+	MinPrice           *int64
+	MaxPrice           *int64
+	CreatedAfter       *time.Time
+	CreatedBefore      *time.Time
+	Sort               []SortField
+}
+
+// ParseProductFilter reads the product list query language: name, category,
+// include_descendants, merchant_id, min_price, max_price, created_after, created_before
+// and sort, a comma separated list of fields each optionally prefixed with - for
+// descending order, e.g. sort=price,-created_at. Price bounds are decimals compared with
+// each product's price in its own currency and held scaled to MaxCurrencyExponent.
+func ParseProductFilter(query url.Values) (ProductFilter, error) {
+	filter := ProductFilter{
+		Name:         query.Get("name"),
+		CategorySlug: query.Get("category"),
+	}
+
+	var err error
+	if value := query.Get("include_descendants"); value != "" {
+		filter.IncludeDescendants, err = strconv.ParseBool(value)
+		if err != nil {
+			return ProductFilter{}, invalidQueryParameter("include_descendants", value)
+		}
+	}
+
+	if value := query.Get("merchant_id"); value != "" {
+		filter.MerchantID, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || filter.MerchantID <= 0 {
+			return ProductFilter{}, invalidQueryParameter("merchant_id", value)
+		}
+	}
+
+	filter.MinPrice, err = parsePriceParameter(query, "min_price")
+	if err != nil {
+		return ProductFilter{}, err
+	}
+	filter.MaxPrice, err = parsePriceParameter(query, "max_price")
+	if err != nil {
+		return ProductFilter{}, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return ProductFilter{}, fmt.Errorf("%w: min_price is greater than max_price", constant.ErrUrlQueryParameter)
+	}
+
+	filter.CreatedAfter, err = parseTimeParameter(query, "created_after")
+	if err != nil {
+		return ProductFilter{}, err
+	}
+	filter.CreatedBefore, err = parseTimeParameter(query, "created_before")
+	if err != nil {
+		return ProductFilter{}, err
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return ProductFilter{}, fmt.Errorf("%w: created_after is later than created_before", constant.ErrUrlQueryParameter)
+	}
+
+	filter.Sort, err = ParseProductSort(query.Get("sort"))
+	if err != nil {
+		return ProductFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func ParseProductSort(value string) ([]SortField, error) {
+	if value == "" {
+		return DefaultProductSort, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		field := SortField{Field: strings.TrimSpace(part)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field, field.Desc = field.Field[1:], true
+		}
+
+		if !productSortFields[field.Field] || seen[field.Field] {
+			return nil, invalidQueryParameter("sort", part)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func parsePriceParameter(query url.Values, key string) (*int64, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := ParseDecimal(value, MaxCurrencyExponent)
+	if err != nil {
+		return nil, invalidQueryParameter(key, value)
+	}
+
+	return &price, nil
+}
+
+// parseTimeParameter accepts RFC 3339 timestamps or plain dates, read as midnight UTC.
+func parseTimeParameter(query url.Values, key string) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, invalidQueryParameter(key, value)
+}
+
+func invalidQueryParameter(key, value string) error {
+	return fmt.Errorf("%w: %s=%s", constant.ErrUrlQueryParameter, key, value)
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductFilter(t *testing.T) {
+	query, _ := url.ParseQuery("name=phone&merchant_id=3&min_price=10&max_price=99.5" +
+		"&created_after=2021-06-01&created_before=2021-07-01T12:00:00Z&sort=price,-created_at")
+
+	filter, err := ParseProductFilter(query)
+	assert.NoError(t, err)
+	assert.Equal(t, "phone", filter.Name)
+	assert.Equal(t, int64(3), filter.MerchantID)
+	assert.Equal(t, int64(10000), *filter.MinPrice)
+	assert.Equal(t, int64(99500), *filter.MaxPrice)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedAfter)
+	assert.Equal(t, time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC), *filter.CreatedBefore)
+	assert.Equal(t, []SortField{{Field: "price"}, {Field: "created_at", Desc: true}}, filter.Sort)
+	assert.Equal(t, "price,-created_at", SortString(filter.Sort))
+
+	filter, err = ParseProductFilter(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultProductSort, filter.Sort)
+	assert.Nil(t, filter.MinPrice)
+	assert.Nil(t, filter.CreatedAfter)
+}
+
+func TestParseProductFilterInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"sort=password",
+		"sort=price,price",
+		"sort=price%3Bdrop%20table%20product",
+		"merchant_id=abc",
+		"merchant_id=-1",
+		"min_price=-1",
+		"min_price=10&max_price=5",
+		"created_after=yesterday",
+		"created_after=2021-07-01&created_before=2021-06-01",
+		"include_descendants=maybe",
+	} {
+		query, _ := url.ParseQuery(raw)
+		_, err := ParseProductFilter(query)
+		assert.True(t, errors.Is(err, constant.ErrUrlQueryParameter), raw)
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+type ProductImage struct {
+	ID          int64
+	ProductID   int64
+	Key         string
+	URL         string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	Position    int
+	CreatedAt   time.Time
+}
+
+type ProductImageUploadRequest struct {
+	ProductID int64
+	Data      []byte
+}
+
+type ProductImageReorderRequest struct {
+	ProductID int64   `json:"-"`
+	ImageIDs  []int64 `json:"image_ids" validate:"required,min=1,dive,gt=0"`
+}
+
+type ProductImageDeleteRequest struct {
+	ProductID int64
+	ImageID   int64
+}
+
+type ProductImageResponse struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewProductImageResponse(payload *ProductImage) *ProductImageResponse {
+	return &ProductImageResponse{
+		ID:          payload.ID,
+		URL:         payload.URL,
+		ContentType: payload.ContentType,
+		Size:        payload.Size,
+		Width:       payload.Width,
+		Height:      payload.Height,
+		Position:    payload.Position,
+		CreatedAt:   payload.CreatedAt,
+	}
+}
+
+func NewProductImageListResponse(payloads []*ProductImage) []*ProductImageResponse {
+	res := make([]*ProductImageResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewProductImageResponse(payload)
+	}
+	return res
+}
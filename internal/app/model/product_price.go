@@ -0,0 +1,62 @@
+package model
+
+import (
+	"database/sql"
+	"net/url"
+	"time"
+)
+
+// ProductPrice is the price a product sold at from EffectiveFrom until EffectiveTo, the
+// current price has no EffectiveTo.
+type ProductPrice struct {
+	ID            int64
+	ProductID     int64
+	Price         Money
+	EffectiveFrom time.Time
+	EffectiveTo   sql.NullTime
+}
+
+type ProductPriceHistoryRequest struct {
+	ProductID int64
+	Page      Page
+}
+
+// ProductPriceAtRequest asks for the price in effect at At, the current price when At is nil.
+type ProductPriceAtRequest struct {
+	ProductID int64
+	At        *time.Time
+}
+
+func ParseProductPriceAtRequest(productID int64, query url.Values) (ProductPriceAtRequest, error) {
+	at, err := parseTimeParameter(query, "at")
+	if err != nil {
+		return ProductPriceAtRequest{}, err
+	}
+
+	return ProductPriceAtRequest{ProductID: productID, At: at}, nil
+}
+
+type ProductPriceResponse struct {
+	Price         Money      `json:"price"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+}
+
+func NewProductPriceResponse(payload *ProductPrice) *ProductPriceResponse {
+	res := &ProductPriceResponse{
+		Price:         payload.Price,
+		EffectiveFrom: payload.EffectiveFrom,
+	}
+	if payload.EffectiveTo.Valid {
+		res.EffectiveTo = &payload.EffectiveTo.Time
+	}
+	return res
+}
+
+func NewProductPriceListResponse(payloads []*ProductPrice) []*ProductPriceResponse {
+	res := make([]*ProductPriceResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewProductPriceResponse(payload)
+	}
+	return res
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProductPriceAtRequest(t *testing.T) {
+	req, err := ParseProductPriceAtRequest(7, url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, ProductPriceAtRequest{ProductID: 7}, req, "no time asks for the current price")
+
+	req, err = ParseProductPriceAtRequest(7, url.Values{"at": {"2021-06-01T10:30:00Z"}})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC), *req.At)
+
+	req, err = ParseProductPriceAtRequest(7, url.Values{"at": {"2021-06-01"}})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), *req.At)
+
+	_, err = ParseProductPriceAtRequest(7, url.Values{"at": {"yesterday"}})
+	assert.ErrorIs(t, err, constant.ErrUrlQueryParameter)
+}
+
+func TestNewProductPriceResponse(t *testing.T) {
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	res := NewProductPriceResponse(&ProductPrice{Price: Money{1999, "USD"}, EffectiveFrom: from})
+	assert.Nil(t, res.EffectiveTo, "the current price has not ended")
+	assert.Equal(t, Money{1999, "USD"}, res.Price)
+}
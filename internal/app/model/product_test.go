@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SemmiDev/go-product/internal/exchange"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductResponseConvertPrice(t *testing.T) {
+	timestamp := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	rates := &exchange.Rates{Base: "USD", Timestamp: timestamp, Rates: map[string]float64{"IDR": 14250, "EUR": 0.8}}
+
+	res := &ProductResponse{Price: Money{1999, "USD"}}
+	assert.NoError(t, res.ConvertPrice("IDR", rates))
+	assert.Equal(t, Money{28485750, "IDR"}, res.Price)
+	assert.Equal(t, &Money{1999, "USD"}, res.OriginalPrice)
+	assert.Equal(t, &ExchangeRateResponse{From: "USD", To: "IDR", Rate: 14250, Timestamp: timestamp}, res.ExchangeRate)
+
+	res = &ProductResponse{Price: Money{1999, "USD"}}
+	assert.NoError(t, res.ConvertPrice("USD", rates))
+	assert.Nil(t, res.ExchangeRate, "same currency is left alone")
+
+	res = &ProductResponse{Price: Money{1999, "USD"}}
+	assert.Error(t, res.ConvertPrice("GBP", rates))
+	assert.Equal(t, Money{1999, "USD"}, res.Price)
+}
+
+func TestPatchProductUpdateRequest(t *testing.T) {
+	product := &Product{ID: 7, Name: "Kopi", Price: Money{1990, "USD"}}
+	current, err := json.Marshal(NewProductUpdateRequest(product))
+	assert.NoError(t, err)
+
+	merged, err := MergePatch(current, []byte(`{"price":"2.50","name":null}`))
+	assert.NoError(t, err)
+
+	req := ProductUpdateRequest{ID: product.ID}
+	assert.NoError(t, json.Unmarshal(merged, &req))
+	assert.Equal(t, ProductUpdateRequest{ID: 7, Price: "2.50", Currency: "USD"}, req)
+}
+
+func TestProductDeletedWithMerchant(t *testing.T) {
+	at := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	deleted := func(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: true} }
+
+	product := &Product{DeletedAt: deleted(at), Merchant: Merchant{DeletedAt: deleted(at.In(time.Local))}}
+	assert.True(t, product.DeletedWithMerchant(), "the same moment in another zone matches")
+
+	product.DeletedAt = deleted(at.Add(-time.Second))
+	assert.False(t, product.DeletedWithMerchant(), "deleted on its own before the merchant")
+
+	product.DeletedAt = sql.NullTime{}
+	assert.False(t, product.DeletedWithMerchant())
+
+	product = &Product{DeletedAt: deleted(at)}
+	assert.False(t, product.DeletedWithMerchant(), "the merchant is not deleted")
+}
+
+func TestProductPurgeDue(t *testing.T) {
+	before := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	deleted := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: before.Add(d), Valid: true} }
+
+	tests := []struct {
+		name     string
+		product  *Product
+		purgeDue bool
+	}{
+		{"active", &Product{Merchant: Merchant{ID: 1}}, false},
+		{"deleted before the cutoff", &Product{DeletedAt: deleted(-time.Second), Merchant: Merchant{ID: 1}}, true},
+		{"deleted at the cutoff", &Product{DeletedAt: deleted(0), Merchant: Merchant{ID: 1}}, false},
+		{"deleted after the cutoff", &Product{DeletedAt: deleted(time.Hour), Merchant: Merchant{ID: 1}}, false},
+		{"merchant deleted before the cutoff", &Product{Merchant: Merchant{ID: 1, DeletedAt: deleted(-time.Hour)}}, true},
+		{"merchant deleted after the cutoff", &Product{Merchant: Merchant{ID: 1, DeletedAt: deleted(time.Hour)}}, false},
+		{"merchant gone", &Product{}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.purgeDue, tt.product.PurgeDue(before), tt.name)
+	}
+}
@@ -0,0 +1,300 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxProductVariants = 100
+	// MaxVariantSKULength and MaxVariantOptionsLength are the widths of the sku and
+	// options columns of product_variant.
+	MaxVariantSKULength     = 64
+	MaxVariantOptionsLength = 512
+)
+
+// ProductOption is one dimension a product varies in, e.g. Size with values S, M and L.
+type ProductOption struct {
+	ID        int64
+	ProductID int64
+	Name      string
+	Values    []string
+	Position  int
+}
+
+// ProductVariant is one combination of option values, Options maps every option name of
+// the product to one of its values. Without a PriceOverride it sells at the product price.
+type ProductVariant struct {
+	ID            int64
+	ProductID     int64
+	SKU           string
+	Options       map[string]string
+	PriceOverride sql.NullInt64
+	Available     bool
+	CreatedAt     time.Time
+	UpdatedAt     sql.NullTime
+}
+
+// OptionsKey is the canonical encoding of Options, json.Marshal sorts map keys.
+func (v *ProductVariant) OptionsKey() string {
+	key, _ := json.Marshal(v.Options)
+	return string(key)
+}
+
+func (v *ProductVariant) Price(productPrice Money) Money {
+	if v.PriceOverride.Valid {
+		return Money{Amount: v.PriceOverride.Int64, Currency: productPrice.Currency}
+	}
+	return productPrice
+}
+
+// MatchesOptions reports whether the variant picks exactly one existing value of every option.
+func (v *ProductVariant) MatchesOptions(options []*ProductOption) bool {
+	if len(v.Options) != len(options) || len(options) == 0 {
+		return false
+	}
+	for _, option := range options {
+		value, ok := v.Options[option.Name]
+		if !ok || !containsString(option.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateProductOptions requires unique, non-empty option names and values, compared
+// case-insensitively, at most MaxProductVariants combinations and that the longest
+// combination fits the options column.
+func ValidateProductOptions(options []*ProductOption) error {
+	combinations := 1
+	names := make(map[string]bool)
+	longest := &ProductVariant{Options: make(map[string]string, len(options))}
+	for _, option := range options {
+		name := strings.ToLower(strings.TrimSpace(option.Name))
+		if name == "" || names[name] || len(option.Values) == 0 {
+			return constant.ErrProductOptionsInvalid
+		}
+		names[name] = true
+
+		values := make(map[string]bool)
+		for _, value := range option.Values {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value == "" || values[value] {
+				return constant.ErrProductOptionsInvalid
+			}
+			values[value] = true
+		}
+		longest.Options[option.Name] = longestValue(option.Values)
+
+		combinations *= len(option.Values)
+		if combinations > MaxProductVariants {
+			return constant.ErrTooManyVariants
+		}
+	}
+
+	if utf8.RuneCountInString(longest.OptionsKey()) > MaxVariantOptionsLength {
+		return constant.ErrProductOptionsTooLong
+	}
+	return nil
+}
+
+// longestValue is the value taking the most room once JSON encoded, escapes included.
+func longestValue(values []string) string {
+	longest, length := "", -1
+	for _, value := range values {
+		encoded, _ := json.Marshal(value)
+		if n := utf8.RuneCount(encoded); n > length {
+			longest, length = value, n
+		}
+	}
+	return longest
+}
+
+// VariantCombinations lists every combination of option values, varying the last option
+// fastest. A product without options has none.
+func VariantCombinations(options []*ProductOption) []map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+
+	combinations := []map[string]string{{}}
+	for _, option := range options {
+		next := make([]map[string]string, 0, len(combinations)*len(option.Values))
+		for _, combination := range combinations {
+			for _, value := range option.Values {
+				extended := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					extended[k] = v
+				}
+				extended[option.Name] = value
+				next = append(next, extended)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// PlanVariants works out how to bring existing variants in line with new options: variants
+// whose combination survives are kept with their SKU, price and availability, the others
+// are removed and missing combinations are returned for creation.
+func PlanVariants(options []*ProductOption, existing []*ProductVariant) (remove []*ProductVariant, create []map[string]string) {
+	kept := make(map[string]bool)
+	for _, variant := range existing {
+		if variant.MatchesOptions(options) && !kept[variant.OptionsKey()] {
+			kept[variant.OptionsKey()] = true
+			continue
+		}
+		remove = append(remove, variant)
+	}
+
+	for _, combination := range VariantCombinations(options) {
+		variant := &ProductVariant{Options: combination}
+		if !kept[variant.OptionsKey()] {
+			create = append(create, combination)
+		}
+	}
+	return remove, create
+}
+
+// GenerateSKU builds a SKU from the product id and the variant's values in option order,
+// e.g. P12-M-RED, cut to MaxVariantSKULength. Values without letters or digits are left
+// out, different values may slugify alike so the SKU is not necessarily unique.
+func GenerateSKU(productID int64, options []*ProductOption, values map[string]string) string {
+	parts := []string{fmt.Sprintf("P%d", productID)}
+	for _, option := range options {
+		if slug := Slugify(values[option.Name]); slug != "" {
+			parts = append(parts, strings.ToUpper(slug))
+		}
+	}
+	return NumberSKU(strings.Join(parts, "-"), 1)
+}
+
+// NumberSKU appends -n to a generated SKU for n above one, e.g. P12-M-RED-2, shortening
+// the SKU so the suffix fits MaxVariantSKULength.
+func NumberSKU(sku string, n int) string {
+	suffix := ""
+	if n > 1 {
+		suffix = fmt.Sprintf("-%d", n)
+	}
+	if len(sku)+len(suffix) > MaxVariantSKULength {
+		sku = strings.TrimSuffix(sku[:MaxVariantSKULength-len(suffix)], "-")
+	}
+	return sku + suffix
+}
+
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+type ProductOptionRequest struct {
+	Name   string   `json:"name" validate:"required,max=64"`
+	Values []string `json:"values" validate:"required,min=1,dive,required,max=64"`
+}
+
+// ProductOptionsSetRequest replaces every option of a product, an empty list removes them
+// together with all variants.
+type ProductOptionsSetRequest struct {
+	ProductID int64                  `json:"-"`
+	Options   []ProductOptionRequest `json:"options" validate:"dive"`
+}
+
+type ProductVariantListRequest struct {
+	ProductID int64
+}
+
+type ProductVariantGetRequest struct {
+	ProductID int64
+	VariantID int64
+}
+
+// Price overrides the product price in the product's currency, null or absent to sell at
+// the product price. SKU is generated when empty and Available defaults to true.
+type ProductVariantCreateRequest struct {
+	ProductID int64             `json:"-"`
+	Options   map[string]string `json:"options" validate:"required"`
+	SKU       string            `json:"sku" validate:"omitempty,max=64"`
+	Price     *json.Number      `json:"price"`
+	Available *bool             `json:"available"`
+}
+
+type ProductVariantUpdateRequest struct {
+	ProductID int64        `json:"-"`
+	VariantID int64        `json:"-"`
+	SKU       string       `json:"sku" validate:"required,max=64"`
+	Price     *json.Number `json:"price"`
+	Available bool         `json:"available"`
+}
+
+type ProductVariantDeleteRequest struct {
+	ProductID int64
+	VariantID int64
+}
+
+type ProductOptionResponse struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+func NewProductOptionListResponse(payloads []*ProductOption) []*ProductOptionResponse {
+	res := make([]*ProductOptionResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = &ProductOptionResponse{Name: payload.Name, Values: payload.Values}
+	}
+	return res
+}
+
+// Price is the variant's regular price and EffectivePrice what it sells at now, with the
+// product's active price rule applied.
+type ProductVariantResponse struct {
+	ID             int64             `json:"id"`
+	SKU            string            `json:"sku"`
+	Options        map[string]string `json:"options"`
+	Price          Money             `json:"price"`
+	EffectivePrice Money             `json:"effective_price"`
+	PriceOverride  *Money            `json:"price_override"`
+	Available      bool              `json:"available"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      *time.Time        `json:"updated_at"`
+}
+
+func NewProductVariantResponse(payload *ProductVariant, product *Product) *ProductVariantResponse {
+	res := &ProductVariantResponse{
+		ID:             payload.ID,
+		SKU:            payload.SKU,
+		Options:        payload.Options,
+		Price:          payload.Price(product.Price),
+		EffectivePrice: payload.Price(product.Price),
+		Available:      payload.Available,
+		CreatedAt:      payload.CreatedAt,
+	}
+	if product.PriceRule != nil {
+		res.EffectivePrice = product.PriceRule.Apply(res.Price)
+	}
+	if payload.PriceOverride.Valid {
+		override := res.Price
+		res.PriceOverride = &override
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
+
+func NewProductVariantListResponse(payloads []*ProductVariant, product *Product) []*ProductVariantResponse {
+	res := make([]*ProductVariantResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewProductVariantResponse(payload, product)
+	}
+	return res
+}
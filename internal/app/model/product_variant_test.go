@@ -0,0 +1,117 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProductOptions(t *testing.T) {
+	assert.NoError(t, ValidateProductOptions(nil))
+	assert.NoError(t, ValidateProductOptions([]*ProductOption{
+		{Name: "Size", Values: []string{"S", "M", "L"}},
+		{Name: "Colour", Values: []string{"red", "blue"}},
+	}))
+
+	for _, options := range [][]*ProductOption{
+		{{Name: "", Values: []string{"S"}}},
+		{{Name: "Size", Values: nil}},
+		{{Name: "Size", Values: []string{"S", "s"}}},
+		{{Name: "Size", Values: []string{"S", " "}}},
+		{{Name: "Size", Values: []string{"S"}}, {Name: "size", Values: []string{"M"}}},
+	} {
+		assert.Equal(t, constant.ErrProductOptionsInvalid, ValidateProductOptions(options))
+	}
+
+	values := make([]string, 11)
+	for i := range values {
+		values[i] = string(rune('a' + i))
+	}
+	assert.Equal(t, constant.ErrTooManyVariants, ValidateProductOptions([]*ProductOption{
+		{Name: "a", Values: values}, {Name: "b", Values: values},
+	}))
+
+	long := func(name string) *ProductOption {
+		return &ProductOption{Name: strings.Repeat(name, 64), Values: []string{"S", strings.Repeat("x", 64)}}
+	}
+	assert.NoError(t, ValidateProductOptions([]*ProductOption{
+		long("a"), long("b"), long("c"), {Name: "d", Values: []string{strings.Repeat("x", 64)}},
+	}))
+	assert.Equal(t, constant.ErrProductOptionsTooLong, ValidateProductOptions([]*ProductOption{
+		long("a"), long("b"), long("c"), long("d"),
+	}), "the longest combination must fit the options column")
+	assert.Equal(t, constant.ErrProductOptionsTooLong, ValidateProductOptions([]*ProductOption{
+		long("a"), long("b"), long("c"), {Name: "d", Values: []string{strings.Repeat("<", 64)}},
+	}), "escaped characters count as encoded")
+}
+
+func TestVariantCombinations(t *testing.T) {
+	options := []*ProductOption{
+		{Name: "Size", Values: []string{"S", "M"}},
+		{Name: "Colour", Values: []string{"red", "blue"}},
+	}
+
+	assert.Equal(t, []map[string]string{
+		{"Size": "S", "Colour": "red"},
+		{"Size": "S", "Colour": "blue"},
+		{"Size": "M", "Colour": "red"},
+		{"Size": "M", "Colour": "blue"},
+	}, VariantCombinations(options))
+	assert.Empty(t, VariantCombinations(nil))
+
+	assert.Equal(t, "P12-M-RED", GenerateSKU(12, options, map[string]string{"Colour": "red", "Size": "M"}))
+	assert.Equal(t, "P12-M", GenerateSKU(12, options, map[string]string{"Colour": "***", "Size": "M"}), "values without letters or digits are left out")
+
+	long := strings.Repeat("x", 64)
+	sku := GenerateSKU(12, options, map[string]string{"Colour": long, "Size": long})
+	assert.Len(t, sku, MaxVariantSKULength)
+	assert.True(t, strings.HasPrefix(sku, "P12-"+strings.ToUpper(long[:10])))
+}
+
+func TestNumberSKU(t *testing.T) {
+	assert.Equal(t, "P12-M-RED", NumberSKU("P12-M-RED", 1))
+	assert.Equal(t, "P12-M-RED-2", NumberSKU("P12-M-RED", 2))
+
+	long := "P12-" + strings.Repeat("A", 57) + "-BC"
+	numbered := NumberSKU(long, 12)
+	assert.Len(t, numbered, MaxVariantSKULength)
+	assert.True(t, strings.HasSuffix(numbered, "A-12"), "the SKU is shortened to fit the suffix")
+	assert.Equal(t, "P12-"+strings.Repeat("A", 57)+"-2", NumberSKU(long, 2), "no double dash")
+}
+
+func TestPlanVariants(t *testing.T) {
+	existing := []*ProductVariant{
+		{ID: 1, Options: map[string]string{"Size": "S"}},
+		{ID: 2, Options: map[string]string{"Size": "M"}},
+		{ID: 3, Options: map[string]string{"Size": "XL"}},
+	}
+	options := []*ProductOption{
+		{Name: "Size", Values: []string{"S", "M", "L"}},
+	}
+
+	remove, create := PlanVariants(options, existing)
+	assert.Equal(t, []*ProductVariant{existing[2]}, remove)
+	assert.Equal(t, []map[string]string{{"Size": "L"}}, create)
+
+	options = append(options, &ProductOption{Name: "Colour", Values: []string{"red"}})
+	remove, create = PlanVariants(options, existing)
+	assert.Len(t, remove, 3, "adding an option replaces every variant")
+	assert.Len(t, create, 3)
+
+	remove, create = PlanVariants(nil, existing)
+	assert.Len(t, remove, 3)
+	assert.Empty(t, create)
+}
+
+func TestProductVariantPrice(t *testing.T) {
+	price := Money{1000, "USD"}
+	variant := &ProductVariant{}
+	assert.Equal(t, price, variant.Price(price))
+
+	variant.PriceOverride = sql.NullInt64{Int64: 1250, Valid: true}
+	assert.Equal(t, Money{1250, "USD"}, variant.Price(price))
+	assert.Equal(t, `{"Colour":"red","Size":"M"}`, (&ProductVariant{Options: map[string]string{"Size": "M", "Colour": "red"}}).OptionsKey())
+}
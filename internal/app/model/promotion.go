@@ -0,0 +1,450 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type PromotionType string
+
+const (
+	PromotionPercentage PromotionType = "percentage"
+	PromotionFixed      PromotionType = "fixed"
+	PromotionBuyXGetY   PromotionType = "buy_x_get_y"
+)
+
+// promotionRank is the order stacked promotions apply in: free items first, then
+// percentages of what is left, then fixed amounts. Ties are broken by code.
+var promotionRank = map[PromotionType]int{
+	PromotionBuyXGetY:   0,
+	PromotionPercentage: 1,
+	PromotionFixed:      2,
+}
+
+var promotionCodePattern = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+
+// Promotion is a coupon code of a merchant discounting its products, all of them unless
+// ProductIDs lists some. Value is the percentage off or, for fixed promotions, the amount
+// off in Currency. A percentage or buy-X-get-Y promotion without a minimum spend may leave
+// Currency empty to apply in any currency.
+type Promotion struct {
+	ID               int64
+	MerchantID       int64
+	Code             string
+	Type             PromotionType
+	Value            int64
+	BuyQuantity      int
+	GetQuantity      int
+	Currency         string
+	MinSpend         sql.NullInt64
+	UsageLimit       sql.NullInt64
+	PerCustomerLimit sql.NullInt64
+	StartsAt         time.Time
+	EndsAt           sql.NullTime
+	ProductIDs       []int64
+	CreatedAt        time.Time
+
+	// Redemptions counts every use, CustomerRedemptions the uses of the customer it was
+	// loaded for.
+	Redemptions         int64
+	CustomerRedemptions int64
+}
+
+// NormalizePromotionCode makes codes case-insensitive.
+func NormalizePromotionCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the fields the promotion's type relies on.
+func (p *Promotion) Validate() error {
+	if !promotionCodePattern.MatchString(p.Code) {
+		return constant.ErrPromotionCodeInvalid
+	}
+
+	valid := true
+	switch p.Type {
+	case PromotionPercentage:
+		valid = p.Value >= 1 && p.Value <= 100
+	case PromotionFixed:
+		valid = p.Value > 0 && p.Currency != ""
+	case PromotionBuyXGetY:
+		valid = p.BuyQuantity > 0 && p.GetQuantity > 0
+	default:
+		valid = false
+	}
+	if !valid || p.MinSpend.Valid && p.Currency == "" || p.EndsAt.Valid && !p.EndsAt.Time.After(p.StartsAt) {
+		return constant.ErrPromotionInvalid
+	}
+
+	return nil
+}
+
+// Check tells why the promotion cannot be redeemed at now, nil when it can.
+func (p *Promotion) Check(now time.Time) error {
+	switch {
+	case now.Before(p.StartsAt), p.EndsAt.Valid && !now.Before(p.EndsAt.Time):
+		return constant.ErrPromotionInactive
+	case p.UsageLimit.Valid && p.Redemptions >= p.UsageLimit.Int64:
+		return constant.ErrPromotionUsedUp
+	case p.PerCustomerLimit.Valid && p.CustomerRedemptions >= p.PerCustomerLimit.Int64:
+		return constant.ErrPromotionCustomerLimit
+	default:
+		return nil
+	}
+}
+
+func (p *Promotion) Eligible(item *OrderItem) bool {
+	if item.MerchantID != p.MerchantID {
+		return false
+	}
+	if len(p.ProductIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ProductIDs {
+		if id == item.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkItems tells why the promotion does not apply to items priced in currency.
+func (p *Promotion) checkItems(items []*OrderItem, currency string) error {
+	if p.Currency != "" && p.Currency != currency {
+		return constant.ErrPromotionNotApplicable
+	}
+
+	var eligible int
+	var spend int64
+	for _, item := range items {
+		if p.Eligible(item) {
+			eligible++
+			spend += item.Subtotal.Amount
+		}
+	}
+
+	switch {
+	case eligible == 0:
+		return constant.ErrPromotionNotApplicable
+	case p.MinSpend.Valid && spend < p.MinSpend.Int64:
+		return constant.ErrPromotionMinSpend
+	default:
+		return nil
+	}
+}
+
+// discounts is the discount on each item, never more than what remains of it after the
+// promotions applied before.
+func (p *Promotion) discounts(items []*OrderItem, remaining []int64) []int64 {
+	discounts := make([]int64, len(items))
+
+	switch p.Type {
+	case PromotionPercentage:
+		for i, item := range items {
+			if p.Eligible(item) {
+				discounts[i] = (remaining[i]*p.Value + 50) / 100
+			}
+		}
+	case PromotionFixed:
+		left := p.Value
+		for i, item := range items {
+			if left == 0 {
+				break
+			}
+			if p.Eligible(item) {
+				discounts[i] = minAmount(left, remaining[i])
+				left -= discounts[i]
+			}
+		}
+	case PromotionBuyXGetY:
+		// units line up from the most to the least expensive, of every buy+get units in a
+		// row the last get ones, the cheapest, are free
+		order := make([]int, 0, len(items))
+		for i, item := range items {
+			if p.Eligible(item) {
+				order = append(order, i)
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return items[order[a]].UnitPrice.Amount > items[order[b]].UnitPrice.Amount
+		})
+
+		var position int64
+		for _, i := range order {
+			quantity := int64(items[i].Quantity)
+			free := p.freeUnits(position+quantity) - p.freeUnits(position)
+			position += quantity
+			discounts[i] = minAmount(free*items[i].UnitPrice.Amount, remaining[i])
+		}
+	}
+
+	return discounts
+}
+
+// freeUnits counts the free units among the first n.
+func (p *Promotion) freeUnits(n int64) int64 {
+	group := int64(p.BuyQuantity + p.GetQuantity)
+	free := n / group * int64(p.GetQuantity)
+	if rest := n%group - int64(p.BuyQuantity); rest > 0 {
+		free += rest
+	}
+	return free
+}
+
+func minAmount(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type AppliedPromotion struct {
+	Promotion *Promotion
+	Discount  Money
+}
+
+type RejectedPromotion struct {
+	Code string
+	Err  error
+}
+
+// PromotionEvaluation is the outcome of applying codes to items, Total is Subtotal less
+// Discount.
+type PromotionEvaluation struct {
+	Subtotal Money
+	Discount Money
+	Total    Money
+	Applied  []*AppliedPromotion
+	Rejected []*RejectedPromotion
+}
+
+// EvaluatePromotions applies the promotions found for codes to items, which must share a
+// currency. The result depends only on its arguments: items are taken in product order,
+// promotions in promotionRank order whatever the order of codes, and each one discounts
+// what the ones before it left. Codes without a promotion, and promotions that cannot be
+// redeemed or discount nothing, are rejected.
+func EvaluatePromotions(items []*OrderItem, codes []string, promotions []*Promotion, now time.Time) *PromotionEvaluation {
+	items = append([]*OrderItem{}, items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+
+	var currency string
+	if len(items) > 0 {
+		currency = items[0].Subtotal.Currency
+	}
+	evaluation := &PromotionEvaluation{
+		Subtotal: Money{Currency: currency},
+		Applied:  []*AppliedPromotion{},
+		Rejected: []*RejectedPromotion{},
+	}
+
+	remaining := make([]int64, len(items))
+	for i, item := range items {
+		remaining[i] = item.Subtotal.Amount
+		evaluation.Subtotal.Amount += item.Subtotal.Amount
+	}
+
+	byCode := make(map[string]*Promotion, len(promotions))
+	for _, promotion := range promotions {
+		byCode[promotion.Code] = promotion
+	}
+
+	seen := make(map[string]bool, len(codes))
+	candidates := []*Promotion{}
+	for _, code := range codes {
+		code = NormalizePromotionCode(code)
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		if promotion, found := byCode[code]; found {
+			candidates = append(candidates, promotion)
+		} else {
+			evaluation.Rejected = append(evaluation.Rejected, &RejectedPromotion{code, constant.ErrPromotionNotFound})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if promotionRank[candidates[i].Type] != promotionRank[candidates[j].Type] {
+			return promotionRank[candidates[i].Type] < promotionRank[candidates[j].Type]
+		}
+		return candidates[i].Code < candidates[j].Code
+	})
+
+	var discount int64
+	for _, promotion := range candidates {
+		err := promotion.Check(now)
+		if err == nil {
+			err = promotion.checkItems(items, currency)
+		}
+		if err != nil {
+			evaluation.Rejected = append(evaluation.Rejected, &RejectedPromotion{promotion.Code, err})
+			continue
+		}
+
+		var amount int64
+		discounts := promotion.discounts(items, remaining)
+		for i := range discounts {
+			remaining[i] -= discounts[i]
+			amount += discounts[i]
+		}
+		if amount == 0 {
+			evaluation.Rejected = append(evaluation.Rejected, &RejectedPromotion{promotion.Code, constant.ErrPromotionNotApplicable})
+			continue
+		}
+
+		discount += amount
+		evaluation.Applied = append(evaluation.Applied, &AppliedPromotion{promotion, Money{amount, currency}})
+	}
+	sort.Slice(evaluation.Rejected, func(i, j int) bool { return evaluation.Rejected[i].Code < evaluation.Rejected[j].Code })
+
+	evaluation.Discount = Money{discount, currency}
+	evaluation.Total = Money{evaluation.Subtotal.Amount - discount, currency}
+	return evaluation
+}
+
+type PromotionCreateRequest struct {
+	Code             string        `json:"code" validate:"required,max=64"`
+	Type             PromotionType `json:"type" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Percentage       int64         `json:"percentage" validate:"omitempty,min=1,max=100"`
+	Amount           *json.Number  `json:"amount"`
+	BuyQuantity      int           `json:"buy_quantity" validate:"omitempty,min=1"`
+	GetQuantity      int           `json:"get_quantity" validate:"omitempty,min=1"`
+	Currency         string        `json:"currency" validate:"omitempty,len=3"`
+	MinSpend         *json.Number  `json:"min_spend"`
+	UsageLimit       *int64        `json:"usage_limit" validate:"omitempty,min=1"`
+	PerCustomerLimit *int64        `json:"per_customer_limit" validate:"omitempty,min=1"`
+	StartsAt         *time.Time    `json:"starts_at"`
+	EndsAt           *time.Time    `json:"ends_at"`
+	ProductIDs       []int64       `json:"product_ids" validate:"omitempty,dive,required"`
+}
+
+type PromotionListRequest struct {
+	MerchantID int64
+	Page       Page
+}
+
+type PromotionGetRequest struct {
+	ID int64
+}
+
+type PromotionDeleteRequest struct {
+	ID int64
+}
+
+type PromotionEvaluateRequest struct {
+	Items []OrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	Codes []string           `json:"codes" validate:"required,min=1,max=10,dive,required"`
+}
+
+type PromotionResponse struct {
+	ID               int64         `json:"id"`
+	MerchantID       int64         `json:"merchant_id"`
+	Code             string        `json:"code"`
+	Type             PromotionType `json:"type"`
+	Percentage       *int64        `json:"percentage,omitempty"`
+	Amount           *Money        `json:"amount,omitempty"`
+	BuyQuantity      *int          `json:"buy_quantity,omitempty"`
+	GetQuantity      *int          `json:"get_quantity,omitempty"`
+	Currency         *string       `json:"currency"`
+	MinSpend         *Money        `json:"min_spend"`
+	UsageLimit       *int64        `json:"usage_limit"`
+	PerCustomerLimit *int64        `json:"per_customer_limit"`
+	Redemptions      int64         `json:"redemptions"`
+	StartsAt         time.Time     `json:"starts_at"`
+	EndsAt           *time.Time    `json:"ends_at"`
+	ProductIDs       []int64       `json:"product_ids"`
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+func NewPromotionResponse(payload *Promotion) *PromotionResponse {
+	res := &PromotionResponse{
+		ID:          payload.ID,
+		MerchantID:  payload.MerchantID,
+		Code:        payload.Code,
+		Type:        payload.Type,
+		Redemptions: payload.Redemptions,
+		StartsAt:    payload.StartsAt,
+		ProductIDs:  payload.ProductIDs,
+		CreatedAt:   payload.CreatedAt,
+	}
+	switch payload.Type {
+	case PromotionPercentage:
+		res.Percentage = &payload.Value
+	case PromotionFixed:
+		res.Amount = &Money{Amount: payload.Value, Currency: payload.Currency}
+	case PromotionBuyXGetY:
+		res.BuyQuantity = &payload.BuyQuantity
+		res.GetQuantity = &payload.GetQuantity
+	}
+	if payload.Currency != "" {
+		res.Currency = &payload.Currency
+	}
+	if payload.MinSpend.Valid {
+		res.MinSpend = &Money{Amount: payload.MinSpend.Int64, Currency: payload.Currency}
+	}
+	if payload.UsageLimit.Valid {
+		res.UsageLimit = &payload.UsageLimit.Int64
+	}
+	if payload.PerCustomerLimit.Valid {
+		res.PerCustomerLimit = &payload.PerCustomerLimit.Int64
+	}
+	if payload.EndsAt.Valid {
+		res.EndsAt = &payload.EndsAt.Time
+	}
+	return res
+}
+
+func NewPromotionListResponse(payloads []*Promotion) []*PromotionResponse {
+	res := make([]*PromotionResponse, len(payloads))
+	for i, payload := range payloads {
+		res[i] = NewPromotionResponse(payload)
+	}
+	return res
+}
+
+type AppliedPromotionResponse struct {
+	PromotionID int64         `json:"promotion_id"`
+	Code        string        `json:"code"`
+	Type        PromotionType `json:"type"`
+	Discount    Money         `json:"discount"`
+}
+
+type RejectedPromotionResponse struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type PromotionEvaluationResponse struct {
+	Subtotal Money                        `json:"subtotal"`
+	Discount Money                        `json:"discount"`
+	Total    Money                        `json:"total"`
+	Applied  []*AppliedPromotionResponse  `json:"applied"`
+	Rejected []*RejectedPromotionResponse `json:"rejected"`
+}
+
+func NewPromotionEvaluationResponse(payload *PromotionEvaluation) *PromotionEvaluationResponse {
+	res := &PromotionEvaluationResponse{
+		Subtotal: payload.Subtotal,
+		Discount: payload.Discount,
+		Total:    payload.Total,
+		Applied:  make([]*AppliedPromotionResponse, len(payload.Applied)),
+		Rejected: make([]*RejectedPromotionResponse, len(payload.Rejected)),
+	}
+	for i, applied := range payload.Applied {
+		res.Applied[i] = &AppliedPromotionResponse{
+			PromotionID: applied.Promotion.ID,
+			Code:        applied.Promotion.Code,
+			Type:        applied.Promotion.Type,
+			Discount:    applied.Discount,
+		}
+	}
+	for i, rejected := range payload.Rejected {
+		res.Rejected[i] = &RejectedPromotionResponse{Code: rejected.Code, Reason: rejected.Err.Error()}
+	}
+	return res
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func promotionItems(t *testing.T) []*OrderItem {
+	order := new(Order)
+	assert.NoError(t, order.AddItem(&Product{ID: 3, Price: Money{500, "USD"}, MerchantID: 7}, 3))
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{2000, "USD"}, MerchantID: 7}, 1))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{1000, "USD"}, MerchantID: 8}, 2))
+	return order.Items
+}
+
+func TestPromotionValidate(t *testing.T) {
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	valid := []*Promotion{
+		{Code: "SUMMER-10", Type: PromotionPercentage, Value: 10, StartsAt: at},
+		{Code: "FIVE", Type: PromotionFixed, Value: 500, Currency: "USD", StartsAt: at},
+		{Code: "B2G1", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1, StartsAt: at, EndsAt: sql.NullTime{Time: at.Add(time.Hour), Valid: true}},
+	}
+	for _, promotion := range valid {
+		assert.NoError(t, promotion.Validate(), promotion.Code)
+	}
+
+	assert.Equal(t, constant.ErrPromotionCodeInvalid, (&Promotion{Code: "NO SPACES", Type: PromotionPercentage, Value: 10}).Validate())
+	invalid := []*Promotion{
+		{Code: "A", Type: PromotionPercentage, Value: 101},
+		{Code: "A", Type: PromotionFixed, Value: 500},
+		{Code: "A", Type: PromotionBuyXGetY, BuyQuantity: 2},
+		{Code: "A", Type: PromotionPercentage, Value: 10, MinSpend: sql.NullInt64{Int64: 100, Valid: true}},
+		{Code: "A", Type: PromotionPercentage, Value: 10, StartsAt: at, EndsAt: sql.NullTime{Time: at, Valid: true}},
+		{Code: "A", Type: "bogus"},
+	}
+	for i, promotion := range invalid {
+		assert.Equal(t, constant.ErrPromotionInvalid, promotion.Validate(), "case %d", i)
+	}
+}
+
+func TestEvaluatePromotions(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	promotions := []*Promotion{
+		{ID: 1, MerchantID: 7, Code: "TEN", Type: PromotionPercentage, Value: 10},
+		{ID: 2, MerchantID: 7, Code: "B2G1", Type: PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1},
+		{ID: 3, MerchantID: 8, Code: "FIVE", Type: PromotionFixed, Value: 500, Currency: "USD"},
+		{ID: 4, MerchantID: 8, Code: "BIG", Type: PromotionPercentage, Value: 50, Currency: "USD", MinSpend: sql.NullInt64{Int64: 5000, Valid: true}},
+		{ID: 5, MerchantID: 7, Code: "OVER", Type: PromotionPercentage, Value: 5, UsageLimit: sql.NullInt64{Int64: 3, Valid: true}, Redemptions: 3},
+		{ID: 6, MerchantID: 7, Code: "ONCE", Type: PromotionPercentage, Value: 5, PerCustomerLimit: sql.NullInt64{Int64: 1, Valid: true}, CustomerRedemptions: 1},
+		{ID: 7, MerchantID: 7, Code: "LATER", Type: PromotionPercentage, Value: 5, StartsAt: now.Add(time.Hour)},
+		{ID: 8, MerchantID: 7, Code: "EUR", Type: PromotionFixed, Value: 100, Currency: "EUR"},
+		{ID: 9, MerchantID: 7, Code: "SHIRT", Type: PromotionPercentage, Value: 20, ProductIDs: []int64{3}},
+	}
+	codes := []string{"ten", "FIVE", " b2g1 ", "BIG", "OVER", "ONCE", "LATER", "EUR", "MISSING", "TEN"}
+
+	evaluation := EvaluatePromotions(promotionItems(t), codes, promotions, now)
+
+	// 3 x 5.00 of product 3 and 1 x 20.00 of product 1 from merchant 7, 2 x 10.00 from merchant 8
+	assert.Equal(t, Money{5500, "USD"}, evaluation.Subtotal)
+	applied := map[string]Money{}
+	var order []string
+	for _, promotion := range evaluation.Applied {
+		applied[promotion.Promotion.Code] = promotion.Discount
+		order = append(order, promotion.Promotion.Code)
+	}
+	assert.Equal(t, []string{"B2G1", "TEN", "FIVE"}, order)
+	// of the 20.00, 5.00, 5.00, 5.00 units the third, 5.00, is free
+	assert.Equal(t, Money{500, "USD"}, applied["B2G1"])
+	// 10% of the remaining 20.00 and 10.00
+	assert.Equal(t, Money{300, "USD"}, applied["TEN"])
+	assert.Equal(t, Money{500, "USD"}, applied["FIVE"])
+	assert.Equal(t, Money{1300, "USD"}, evaluation.Discount)
+	assert.Equal(t, Money{4200, "USD"}, evaluation.Total)
+
+	rejected := map[string]error{}
+	var rejectedOrder []string
+	for _, promotion := range evaluation.Rejected {
+		rejected[promotion.Code] = promotion.Err
+		rejectedOrder = append(rejectedOrder, promotion.Code)
+	}
+	assert.Equal(t, []string{"BIG", "EUR", "LATER", "MISSING", "ONCE", "OVER"}, rejectedOrder)
+	assert.Equal(t, constant.ErrPromotionMinSpend, rejected["BIG"])
+	assert.Equal(t, constant.ErrPromotionNotApplicable, rejected["EUR"])
+	assert.Equal(t, constant.ErrPromotionInactive, rejected["LATER"])
+	assert.Equal(t, constant.ErrPromotionNotFound, rejected["MISSING"])
+	assert.Equal(t, constant.ErrPromotionCustomerLimit, rejected["ONCE"])
+	assert.Equal(t, constant.ErrPromotionUsedUp, rejected["OVER"])
+
+	reversed := make([]string, len(codes))
+	for i, code := range codes {
+		reversed[len(codes)-1-i] = code
+	}
+	items := promotionItems(t)
+	items[0], items[2] = items[2], items[0]
+	assert.Equal(t, evaluation, EvaluatePromotions(items, reversed, promotions, now), "input order")
+
+	scoped := EvaluatePromotions(promotionItems(t), []string{"SHIRT"}, promotions, now)
+	assert.Equal(t, Money{300, "USD"}, scoped.Discount)
+}
+
+func TestPromotionBuyXGetYAcrossLines(t *testing.T) {
+	promotion := &Promotion{MerchantID: 7, Code: "B1G1", Type: PromotionBuyXGetY, BuyQuantity: 1, GetQuantity: 1}
+	order := new(Order)
+	assert.NoError(t, order.AddItem(&Product{ID: 1, Price: Money{300, "USD"}, MerchantID: 7}, 3))
+	assert.NoError(t, order.AddItem(&Product{ID: 2, Price: Money{100, "USD"}, MerchantID: 7}, 2))
+
+	// 3.00, 3.00, 3.00, 1.00, 1.00: the second and fourth units are free
+	evaluation := EvaluatePromotions(order.Items, []string{"B1G1"}, []*Promotion{promotion}, time.Now())
+	assert.Equal(t, Money{400, "USD"}, evaluation.Discount)
+
+	order.ApplyPromotions(evaluation)
+	assert.Equal(t, Money{700, "USD"}, order.Total)
+	assert.Equal(t, Money{1100, "USD"}, NewOrderResponse(order).Subtotal)
+
+	single := new(Order)
+	assert.NoError(t, single.AddItem(&Product{ID: 1, Price: Money{300, "USD"}, MerchantID: 7}, 1))
+	evaluation = EvaluatePromotions(single.Items, []string{"B1G1"}, []*Promotion{promotion}, time.Now())
+	assert.Equal(t, constant.ErrPromotionNotApplicable, evaluation.Rejected[0].Err)
+}
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"time"
+)
+
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationConfirmed ReservationStatus = "confirmed"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+type Stock struct {
+	ProductID int64
+	OnHand    int
+	Reserved  int
+	UpdatedAt sql.NullTime
+}
+
+func (s *Stock) Available() int {
+	return s.OnHand - s.Reserved
+}
+
+// Adjust changes the stock on hand by delta, which cannot take it below what is reserved.
+func (s *Stock) Adjust(delta int) error {
+	if s.OnHand+delta < s.Reserved {
+		return constant.ErrInsufficientStock
+	}
+	s.OnHand += delta
+	return nil
+}
+
+func (s *Stock) Reserve(quantity int) error {
+	if s.Available() < quantity {
+		return constant.ErrInsufficientStock
+	}
+	s.Reserved += quantity
+	return nil
+}
+
+// Expire gives back the quantity held by pending reservations that ran out of time.
+func (s *Stock) Expire(quantity int) {
+	s.Reserved -= quantity
+}
+
+type StockReservation struct {
+	ID        int64
+	ProductID int64
+	OwnerID   int64
+	Quantity  int
+	Status    ReservationStatus
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt sql.NullTime
+}
+
+// Settle moves a pending reservation to status: confirming takes its quantity off the stock
+// on hand, releasing only gives it back. A reservation past its expiry can no longer settle.
+func (r *StockReservation) Settle(stock *Stock, status ReservationStatus, now time.Time) error {
+	switch {
+	case r.Status == ReservationExpired, r.Status == ReservationPending && !r.ExpiresAt.After(now):
+		return constant.ErrReservationExpired
+	case r.Status != ReservationPending:
+		return constant.ErrReservationNotPending
+	}
+
+	stock.Reserved -= r.Quantity
+	if status == ReservationConfirmed {
+		stock.OnHand -= r.Quantity
+	}
+	stock.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+
+	r.Status = status
+	r.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	return nil
+}
+
+type StockGetRequest struct {
+	ProductID int64
+}
+
+type StockAdjustRequest struct {
+	ProductID int64 `json:"-"`
+	Delta     int   `json:"delta" validate:"required"`
+}
+
+type StockReservationCreateRequest struct {
+	ProductID  int64 `json:"-"`
+	Quantity   int   `json:"quantity" validate:"required,gt=0"`
+	TTLSeconds int   `json:"ttl_seconds" validate:"omitempty,gt=0"`
+}
+
+type StockReservationConfirmRequest struct {
+	ProductID     int64
+	ReservationID int64
+}
+
+type StockReservationReleaseRequest struct {
+	ProductID     int64
+	ReservationID int64
+}
+
+type StockResponse struct {
+	ProductID int64      `json:"product_id"`
+	OnHand    int        `json:"on_hand"`
+	Reserved  int        `json:"reserved"`
+	Available int        `json:"available"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func NewStockResponse(payload *Stock) *StockResponse {
+	res := &StockResponse{
+		ProductID: payload.ProductID,
+		OnHand:    payload.OnHand,
+		Reserved:  payload.Reserved,
+		Available: payload.Available(),
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
+
+type StockReservationResponse struct {
+	ID        int64             `json:"id"`
+	ProductID int64             `json:"product_id"`
+	Quantity  int               `json:"quantity"`
+	Status    ReservationStatus `json:"status"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+func NewStockReservationResponse(payload *StockReservation) *StockReservationResponse {
+	res := &StockReservationResponse{
+		ID:        payload.ID,
+		ProductID: payload.ProductID,
+		Quantity:  payload.Quantity,
+		Status:    payload.Status,
+		ExpiresAt: payload.ExpiresAt,
+		CreatedAt: payload.CreatedAt,
+	}
+	if payload.UpdatedAt.Valid {
+		res.UpdatedAt = &payload.UpdatedAt.Time
+	}
+	return res
+}
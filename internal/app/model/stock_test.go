@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockAdjustAndReserve(t *testing.T) {
+	stock := &Stock{OnHand: 10}
+
+	assert.NoError(t, stock.Reserve(4))
+	assert.Equal(t, 6, stock.Available())
+	assert.Equal(t, constant.ErrInsufficientStock, stock.Reserve(7))
+
+	assert.Equal(t, constant.ErrInsufficientStock, stock.Adjust(-7), "on hand cannot drop below reserved")
+	assert.NoError(t, stock.Adjust(-6))
+	assert.Equal(t, 4, stock.OnHand)
+	assert.Equal(t, 0, stock.Available())
+
+	stock.Expire(4)
+	assert.Equal(t, 0, stock.Reserved)
+	assert.Equal(t, 4, stock.Available())
+}
+
+func TestStockReservationSettle(t *testing.T) {
+	now := time.Now()
+	pending := func() (*Stock, *StockReservation) {
+		return &Stock{OnHand: 10, Reserved: 3},
+			&StockReservation{Quantity: 3, Status: ReservationPending, ExpiresAt: now.Add(time.Minute)}
+	}
+
+	stock, reservation := pending()
+	assert.NoError(t, reservation.Settle(stock, ReservationConfirmed, now))
+	assert.Equal(t, ReservationConfirmed, reservation.Status)
+	assert.Equal(t, 7, stock.OnHand, "confirming takes the quantity off the stock on hand")
+	assert.Equal(t, 0, stock.Reserved)
+	assert.True(t, reservation.UpdatedAt.Valid)
+	assert.Equal(t, constant.ErrReservationNotPending, reservation.Settle(stock, ReservationReleased, now))
+
+	stock, reservation = pending()
+	assert.NoError(t, reservation.Settle(stock, ReservationReleased, now))
+	assert.Equal(t, ReservationReleased, reservation.Status)
+	assert.Equal(t, 10, stock.OnHand, "releasing leaves the stock on hand")
+	assert.Equal(t, 0, stock.Reserved)
+	assert.Equal(t, 10, stock.Available())
+}
+
+func TestStockReservationSettleExpired(t *testing.T) {
+	now := time.Now()
+
+	stock := &Stock{OnHand: 10, Reserved: 3}
+	reservation := &StockReservation{Quantity: 3, Status: ReservationPending, ExpiresAt: now}
+	assert.Equal(t, constant.ErrReservationExpired, reservation.Settle(stock, ReservationConfirmed, now))
+	assert.Equal(t, ReservationPending, reservation.Status)
+	assert.Equal(t, Stock{OnHand: 10, Reserved: 3}, *stock, "an expired reservation leaves the stock alone")
+
+	reservation.Status = ReservationExpired
+	reservation.ExpiresAt = now.Add(time.Minute)
+	assert.Equal(t, constant.ErrReservationExpired, reservation.Settle(stock, ReservationReleased, now))
+}
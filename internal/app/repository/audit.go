@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+)
+
+// AuditRepository is append-only, entries are never updated or deleted.
+type AuditRepository interface {
+	Create(ctx context.Context, entry *model.AuditEntry) error
+	List(ctx context.Context, page model.Page, filter model.AuditFilter) ([]*model.AuditEntry, *model.PageInfo, error)
+	Count(ctx context.Context, filter model.AuditFilter) (int64, error)
+}
+
+type auditRepository struct {
+	mysqlClient mysql.Client
+}
+
+func NewAuditRepository(mysqlClient mysql.Client) AuditRepository {
+	return &auditRepository{mysqlClient}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *model.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	INSERT INTO
+		audit_log (actor_id, actor_role, action, entity_type, entity_id, merchant_id, changes, request_id, ip, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ActorID, entry.ActorRole, entry.Action, entry.EntityType, entry.EntityID, entry.MerchantID,
+		changes, entry.RequestID, entry.IP, entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	entry.ID, err = res.LastInsertId()
+	return err
+}
+
+var (
+	auditSort        = []model.SortField{{Field: "created_at", Desc: true}}
+	auditSortColumns = map[string]sortColumn{
+		"created_at": {"created_at", parseTimeKey},
+	}
+)
+
+func (r *auditRepository) List(ctx context.Context, page model.Page, filter model.AuditFilter) ([]*model.AuditEntry, *model.PageInfo, error) {
+	keyset, err := newKeyset(page, auditSort, auditSortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := []*model.AuditEntry{}
+	where, args := auditFilterClause(filter)
+	args = append(append(args, keyset.args...), keyset.limit(), keyset.offset())
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, actor_id, actor_role, action, entity_type, entity_id, merchant_id, changes, request_id, ip, created_at
+	FROM
+		audit_log
+	WHERE
+		`+where+` AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
+	LIMIT
+		? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var changes []byte
+		entry := new(model.AuditEntry)
+		err := rows.Scan(
+			&entry.ID, &entry.ActorID, &entry.ActorRole, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&entry.MerchantID, &changes, &entry.RequestID, &entry.IP, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = json.Unmarshal(changes, &entry.Changes)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	info := keyset.result(len(entries),
+		func(n int) { entries = entries[:n] },
+		func() { reverseAuditEntries(entries) },
+		func(i int) *model.Cursor {
+			return newCursor(auditSort, []string{timeKey(entries[i].CreatedAt)}, entries[i].ID)
+		},
+	)
+
+	return entries, info, nil
+}
+
+func (r *auditRepository) Count(ctx context.Context, filter model.AuditFilter) (int64, error) {
+	var count int64
+	where, args := auditFilterClause(filter)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		audit_log
+	WHERE
+		`+where+`
+	`, args...,
+	).Scan(&count)
+	return count, err
+}
+
+func auditFilterClause(filter model.AuditFilter) (string, []interface{}) {
+	where := "merchant_id = ?"
+	args := []interface{}{filter.MerchantID}
+
+	if filter.EntityType != "" {
+		where += " AND entity_type = ?"
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		where += " AND entity_id = ?"
+		args = append(args, filter.EntityID)
+	}
+
+	return where, args
+}
+
+func reverseAuditEntries(entries []*model.AuditEntry) {
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+	"strconv"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// CartRepository keeps every cart in a redis hash of product id to item. Carts expire
+// CartTTL after their last change.
+type CartRepository interface {
+	List(ctx context.Context, owner model.CartOwner) ([]*model.CartItem, error)
+	Get(ctx context.Context, owner model.CartOwner, productID int64) (*model.CartItem, error)
+	Add(ctx context.Context, owner model.CartOwner, item *model.CartItem) error
+	Save(ctx context.Context, owner model.CartOwner, items ...*model.CartItem) error
+	Remove(ctx context.Context, owner model.CartOwner, productID int64) error
+	Clear(ctx context.Context, owner model.CartOwner) error
+	Merge(ctx context.Context, from, to model.CartOwner) ([]*model.CartItem, error)
+}
+
+// cartAddAttempts bounds how often Add retries when the cart changes under it.
+const cartAddAttempts = 3
+
+// cartRepository talks to redis directly instead of through Cache(), a cart changes on
+// every request and must look the same on every replica.
+type cartRepository struct {
+	redisClient redis.Client
+}
+
+func NewCartRepository(redisClient redis.Client) CartRepository {
+	return &cartRepository{redisClient}
+}
+
+func (r *cartRepository) List(ctx context.Context, owner model.CartOwner) ([]*model.CartItem, error) {
+	values, err := r.redisClient.Conn().HGetAll(ctx, owner.Key()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeCartItems(values)
+}
+
+// Get returns nil without an error when the product is not in the cart.
+func (r *cartRepository) Get(ctx context.Context, owner model.CartOwner, productID int64) (*model.CartItem, error) {
+	value, err := r.redisClient.Conn().HGet(ctx, owner.Key(), strconv.FormatInt(productID, 10)).Bytes()
+	if err == goredis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	item := new(model.CartItem)
+	return item, json.Unmarshal(value, item)
+}
+
+// Add adds the quantity of item to the product's line at item's price, starting a new
+// line unless the cart already holds MaxCartItems products, which fails with
+// constant.ErrCartFull. The cart is watched so the count cannot change between the check
+// and the write. item is left holding the line as saved.
+func (r *cartRepository) Add(ctx context.Context, owner model.CartOwner, item *model.CartItem) error {
+	field := strconv.FormatInt(item.ProductID, 10)
+	add := func(tx *goredis.Tx) error {
+		line := *item
+		value, err := tx.HGet(ctx, owner.Key(), field).Bytes()
+		if err == goredis.Nil {
+			count, err := tx.HLen(ctx, owner.Key()).Result()
+			if err != nil {
+				return err
+			} else if count >= model.MaxCartItems {
+				return constant.ErrCartFull
+			}
+		} else if err != nil {
+			return err
+		} else {
+			existing := new(model.CartItem)
+			err = json.Unmarshal(value, existing)
+			if err != nil {
+				return err
+			}
+			line.Quantity += existing.Quantity
+			line.AddedAt = existing.AddedAt
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			return saveCartItems(ctx, pipe, owner, []*model.CartItem{&line})
+		})
+		if err == nil {
+			*item = line
+		}
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < cartAddAttempts; attempt++ {
+		err = r.redisClient.Conn().Watch(ctx, add, owner.Key())
+		if err != goredis.TxFailedErr {
+			return err
+		}
+	}
+	return err
+}
+
+func (r *cartRepository) Save(ctx context.Context, owner model.CartOwner, items ...*model.CartItem) error {
+	_, err := r.redisClient.Conn().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		return saveCartItems(ctx, pipe, owner, items)
+	})
+	return err
+}
+
+func (r *cartRepository) Remove(ctx context.Context, owner model.CartOwner, productID int64) error {
+	return r.redisClient.Conn().HDel(ctx, owner.Key(), strconv.FormatInt(productID, 10)).Err()
+}
+
+func (r *cartRepository) Clear(ctx context.Context, owner model.CartOwner) error {
+	return r.redisClient.Conn().Del(ctx, owner.Key()).Err()
+}
+
+// Merge moves the items of cart from into cart to and deletes from. Products that do not
+// fit into to stay in from and are returned. Both carts are watched so a concurrent change
+// makes the merge fail rather than lose items.
+func (r *cartRepository) Merge(ctx context.Context, from, to model.CartOwner) ([]*model.CartItem, error) {
+	var left []*model.CartItem
+	err := r.redisClient.Conn().Watch(ctx, func(tx *goredis.Tx) error {
+		values, err := tx.HGetAll(ctx, from.Key()).Result()
+		if err != nil {
+			return err
+		}
+		anonymous, err := decodeCartItems(values)
+		if err != nil || len(anonymous) == 0 {
+			return err
+		}
+
+		values, err = tx.HGetAll(ctx, to.Key()).Result()
+		if err != nil {
+			return err
+		}
+		account, err := decodeCartItems(values)
+		if err != nil {
+			return err
+		}
+
+		var merged []*model.CartItem
+		merged, left = model.MergeCartItems(account, anonymous)
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			err := saveCartItems(ctx, pipe, to, merged)
+			if err != nil {
+				return err
+			}
+			if len(left) == 0 {
+				return pipe.Del(ctx, from.Key()).Err()
+			}
+
+			kept := make(map[int64]bool, len(left))
+			for _, item := range left {
+				kept[item.ProductID] = true
+			}
+			moved := make([]string, 0, len(anonymous)-len(left))
+			for _, item := range anonymous {
+				if !kept[item.ProductID] {
+					moved = append(moved, strconv.FormatInt(item.ProductID, 10))
+				}
+			}
+			if len(moved) == 0 {
+				return nil
+			}
+			return pipe.HDel(ctx, from.Key(), moved...).Err()
+		})
+		return err
+	}, from.Key(), to.Key())
+	if err != nil {
+		return nil, err
+	}
+	return left, nil
+}
+
+func saveCartItems(ctx context.Context, pipe goredis.Pipeliner, owner model.CartOwner, items []*model.CartItem) error {
+	for _, item := range items {
+		value, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		pipe.HSet(ctx, owner.Key(), strconv.FormatInt(item.ProductID, 10), value)
+	}
+	pipe.Expire(ctx, owner.Key(), config.Cfg().CartTTL)
+	return nil
+}
+
+func decodeCartItems(values map[string]string) ([]*model.CartItem, error) {
+	items := make([]*model.CartItem, 0, len(values))
+	for _, value := range values {
+		item := new(model.CartItem)
+		err := json.Unmarshal([]byte(value), item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	model.SortCartItems(items)
+	return items, nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"strings"
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *model.Category) error
+	List(ctx context.Context) ([]*model.Category, error)
+	ListByIDs(ctx context.Context, ids []int64) ([]*model.Category, error)
+	Get(ctx context.Context, id int64) (*model.Category, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Category, error)
+	ListDescendantIDs(ctx context.Context, id int64) ([]int64, error)
+	Update(ctx context.Context, category *model.Category) error
+	Delete(ctx context.Context, id, merchantID int64) error
+
+	ListByProduct(ctx context.Context, productID int64) ([]*model.Category, error)
+	SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error
+}
+
+type categoryRepository struct {
+	mysqlClient mysql.Client
+}
+
+func NewCategoryRepository(mysqlClient mysql.Client) CategoryRepository {
+	return &categoryRepository{mysqlClient}
+}
+
+func (r *categoryRepository) Create(ctx context.Context, category *model.Category) error {
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	INSERT INTO
+		category (parent_id, merchant_id, name, slug, position, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?)
+	`, category.ParentID, category.MerchantID, category.Name, category.Slug, category.Position, category.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	category.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *categoryRepository) List(ctx context.Context) ([]*model.Category, error) {
+	return r.query(ctx, `
+	SELECT
+		id, parent_id, merchant_id, name, slug, position, created_at, updated_at
+	FROM
+		category
+	ORDER BY
+		position, name
+	`)
+}
+
+func (r *categoryRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.Category, error) {
+	if len(ids) == 0 {
+		return []*model.Category{}, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return r.query(ctx, `
+	SELECT
+		id, parent_id, merchant_id, name, slug, position, created_at, updated_at
+	FROM
+		category
+	WHERE
+		id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+	ORDER BY
+		position, name
+	`, args...)
+}
+
+func (r *categoryRepository) Get(ctx context.Context, id int64) (*model.Category, error) {
+	category := new(model.Category)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, parent_id, merchant_id, name, slug, position, created_at, updated_at
+	FROM
+		category
+	WHERE
+		id = ?
+	`, id,
+	).Scan(
+		&category.ID, &category.ParentID, &category.MerchantID, &category.Name, &category.Slug, &category.Position, &category.CreatedAt, &category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+func (r *categoryRepository) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	category := new(model.Category)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, parent_id, merchant_id, name, slug, position, created_at, updated_at
+	FROM
+		category
+	WHERE
+		slug = ?
+	`, slug,
+	).Scan(
+		&category.ID, &category.ParentID, &category.MerchantID, &category.Name, &category.Slug, &category.Position, &category.CreatedAt, &category.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// ListDescendantIDs returns id and the ids of every category below it.
+func (r *categoryRepository) ListDescendantIDs(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	WITH RECURSIVE tree AS (
+		SELECT id FROM category WHERE id = ?
+		UNION ALL
+		SELECT category.id FROM category INNER JOIN tree ON category.parent_id = tree.id
+	)
+	SELECT id FROM tree
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var descendantID int64
+		err := rows.Scan(&descendantID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, descendantID)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *categoryRepository) Update(ctx context.Context, category *model.Category) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	UPDATE
+		category
+	SET
+		parent_id = ?, name = ?, slug = ?, position = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, category.ParentID, category.Name, category.Slug, category.Position, category.UpdatedAt, category.ID)
+	return err
+}
+
+// Delete removes the category unless products of merchants other than merchantID are
+// filed under it, deleted products included since they may be restored.
+func (r *categoryRepository) Delete(ctx context.Context, id, merchantID int64) error {
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		category
+	WHERE
+		id = ? AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				product_category
+			INNER JOIN
+				product
+			ON
+				product_category.product_id = product.id
+			WHERE
+				product_category.category_id = ? AND product.merchant_id <> ?
+		)
+	`, id, id, merchantID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return constant.ErrCategoryInUse
+	}
+	return nil
+}
+
+func (r *categoryRepository) ListByProduct(ctx context.Context, productID int64) ([]*model.Category, error) {
+	return r.query(ctx, `
+	SELECT
+		category.id, category.parent_id, category.merchant_id, category.name, category.slug, category.position, category.created_at, category.updated_at
+	FROM
+		category
+	INNER JOIN
+		product_category
+	ON
+		product_category.category_id = category.id
+	WHERE
+		product_category.product_id = ?
+	ORDER BY
+		category.position, category.name
+	`, productID)
+}
+
+func (r *categoryRepository) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM
+		product_category
+	WHERE
+		product_id = ?
+	`, productID)
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoryIDs {
+		_, err = tx.ExecContext(ctx, `
+		INSERT IGNORE INTO
+			product_category (product_id, category_id)
+		VALUES
+			(?, ?)
+		`, productID, categoryID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *categoryRepository) query(ctx context.Context, query string, args ...interface{}) ([]*model.Category, error) {
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*model.Category{}
+	for rows.Next() {
+		category := new(model.Category)
+		err := rows.Scan(
+			&category.ID, &category.ParentID, &category.MerchantID, &category.Name, &category.Slug, &category.Position, &category.CreatedAt, &category.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
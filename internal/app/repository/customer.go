@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+	"github.com/go-redis/cache/v8"
+)
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *model.Customer) error
+	Get(ctx context.Context, id int64) (*model.Customer, error)
+	GetByEmail(ctx context.Context, email string) (*model.Customer, error)
+	Update(ctx context.Context, customer *model.Customer) error
+}
+
+func NewCustomerRepository(mysqlClient mysql.Client, redisClient redis.Client) CustomerRepository {
+	return &customerRepository{mysqlClient, redisClient}
+}
+
+type customerRepository struct {
+	mysqlClient mysql.Client
+	redisClient redis.Client
+}
+
+func (r *customerRepository) Create(ctx context.Context, customer *model.Customer) error {
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	INSERT INTO
+		customer (name, email, password, created_at)
+	VALUES
+		(?, ?, ?, ?)
+	`, customer.Name, customer.Email, customer.Password, customer.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	customer.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	temp, err := r.Get(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	*customer = *temp
+	return nil
+}
+
+func (r *customerRepository) Get(ctx context.Context, id int64) (*model.Customer, error) {
+	customer := new(model.Customer)
+	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("customer_%d", id), customer)
+	if err != nil && err != cache.ErrCacheMiss {
+		return nil, err
+	} else if err == nil {
+		return customer, nil
+	}
+
+	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, name, email, password, created_at, updated_at
+	FROM
+		customer
+	WHERE
+		id = ?
+	`, id,
+	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Password, &customer.CreatedAt, &customer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, r.redisClient.Cache().Set(&cache.Item{
+		Ctx:   ctx,
+		Key:   fmt.Sprintf("customer_%d", id),
+		Value: customer,
+		TTL:   config.Cfg().RedisTTL,
+	})
+}
+
+func (r *customerRepository) GetByEmail(ctx context.Context, email string) (*model.Customer, error) {
+	customer := new(model.Customer)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, name, email, password, created_at, updated_at
+	FROM
+		customer
+	WHERE
+		email = ?
+	`, email,
+	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Password, &customer.CreatedAt, &customer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) Update(ctx context.Context, customer *model.Customer) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	UPDATE
+		customer
+	SET
+		name = ?, email = ?, password = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, customer.Name, customer.Email, customer.Password, customer.UpdatedAt.Time, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("customer_%d", customer.ID))
+	if err != nil && err != cache.ErrCacheMiss {
+		return err
+	}
+
+	temp, err := r.Get(ctx, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	*customer = *temp
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+
+	"github.com/go-redis/cache/v8"
+)
+
+const exchangeRatesKey = "exchange_rates"
+
+type ExchangeRateRepository interface {
+	Get(ctx context.Context) (*model.ExchangeRates, error)
+	Set(ctx context.Context, rates *model.ExchangeRates) error
+}
+
+type exchangeRateRepository struct {
+	redisClient redis.Client
+}
+
+func NewExchangeRateRepository(redisClient redis.Client) ExchangeRateRepository {
+	return &exchangeRateRepository{redisClient}
+}
+
+// Get returns the cached rates or nil when there are none.
+func (r *exchangeRateRepository) Get(ctx context.Context) (*model.ExchangeRates, error) {
+	rates := new(model.ExchangeRates)
+	err := r.redisClient.Cache().Get(ctx, exchangeRatesKey, rates)
+	if err == cache.ErrCacheMiss {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// Set keeps the rates for ExchangeRateMaxAge, past which they are too stale to serve.
+func (r *exchangeRateRepository) Set(ctx context.Context, rates *model.ExchangeRates) error {
+	return r.redisClient.Cache().Set(&cache.Item{
+		Ctx:   ctx,
+		Key:   exchangeRatesKey,
+		Value: rates,
+		TTL:   config.Cfg().ExchangeRateMaxAge,
+	})
+}
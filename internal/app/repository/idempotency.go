@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+type IdempotencyRepository interface {
+	GetIdempotentResponse(ctx context.Context, key string) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, key string, res *model.IdempotentResponse) error
+	LockIdempotencyKey(ctx context.Context, key string) (string, error)
+	UnlockIdempotencyKey(ctx context.Context, key, token string) error
+}
+
+func NewIdempotencyRepository(redisClient redis.Client) IdempotencyRepository {
+	return &idempotencyRepository{redisClient}
+}
+
+// idempotencyRepository talks to redis directly instead of through Cache(), a retry may
+// reach another replica than the first request.
+type idempotencyRepository struct {
+	redisClient redis.Client
+}
+
+// GetIdempotentResponse returns nil without an error when no response is kept for key.
+func (r *idempotencyRepository) GetIdempotentResponse(ctx context.Context, key string) (*model.IdempotentResponse, error) {
+	value, err := r.redisClient.Conn().Get(ctx, fmt.Sprintf("idempotency_%s", key)).Bytes()
+	if err == goredis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res := new(model.IdempotentResponse)
+	return res, json.Unmarshal(value, res)
+}
+
+func (r *idempotencyRepository) SaveIdempotentResponse(ctx context.Context, key string, res *model.IdempotentResponse) error {
+	value, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("idempotency_%s", key), value, config.Cfg().IdempotencyTTL).Err()
+}
+
+// LockIdempotencyKey returns the token the lock was taken with, or an empty token when
+// someone else holds it. The lock expires after IdempotencyLockTTL in case its holder dies
+// before unlocking.
+func (r *idempotencyRepository) LockIdempotencyKey(ctx context.Context, key string) (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	locked, err := r.redisClient.Conn().SetNX(ctx, fmt.Sprintf("idempotency_lock_%s", key), token, config.Cfg().IdempotencyLockTTL).Result()
+	if err != nil || !locked {
+		return "", err
+	}
+	return token, nil
+}
+
+// unlockIdempotencyKey deletes the lock only while it holds the given token, a request that
+// outlived its lock must not release the lock a retry took after it expired.
+var unlockIdempotencyKey = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *idempotencyRepository) UnlockIdempotencyKey(ctx context.Context, key, token string) error {
+	return unlockIdempotencyKey.Run(ctx, r.redisClient.Conn(), []string{fmt.Sprintf("idempotency_lock_%s", key)}, token).Err()
+}
@@ -2,21 +2,31 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/db/mysql"
 	"github.com/SemmiDev/go-product/internal/db/redis"
 	"github.com/go-redis/cache/v8"
+	"strings"
+	"time"
 )
 
 type MerchantRepository interface {
 	Create(ctx context.Context, merchant *model.Merchant) error
-	List(ctx context.Context, limit, offset int, name string) ([]*model.Merchant, error)
+	List(ctx context.Context, page model.Page, name string) ([]*model.Merchant, *model.PageInfo, error)
+	Count(ctx context.Context, name string) (int64, error)
 	Get(ctx context.Context, id int64) (*model.Merchant, error)
+	GetLatest(ctx context.Context, id int64) (*model.Merchant, error)
 	GetByEmail(ctx context.Context, email string) (*model.Merchant, error)
-	Update(ctx context.Context, merchant *model.Merchant) error
-	Delete(ctx context.Context, id int64) error
+	EmailTaken(ctx context.Context, email string, exceptID int64) (bool, error)
+	Update(ctx context.Context, merchant *model.Merchant, ifMatch model.Precondition) error
+	Patch(ctx context.Context, merchant *model.Merchant, ifMatch model.Precondition, columns ...string) error
+	Delete(ctx context.Context, id int64, ifMatch model.Precondition, deletedAt time.Time) ([]int64, error)
+	Restore(ctx context.Context, id int64) (time.Time, []int64, error)
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 func NewMerchantRepository(mysqlClient mysql.Client, redisClient redis.Client) MerchantRepository {
@@ -49,33 +59,79 @@ func (r *merchantRepository) Create(ctx context.Context, merchant *model.Merchan
 	return err
 }
 
-func (r *merchantRepository) List(ctx context.Context, limit, offset int, name string) ([]*model.Merchant, error) {
-	var merchants []*model.Merchant
+var (
+	merchantSort        = []model.SortField{{Field: "created_at", Desc: true}}
+	merchantSortColumns = map[string]sortColumn{
+		"created_at": {"created_at", parseTimeKey},
+	}
+)
+
+func (r *merchantRepository) List(ctx context.Context, page model.Page, name string) ([]*model.Merchant, *model.PageInfo, error) {
+	keyset, err := newKeyset(page, merchantSort, merchantSortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merchants := []*model.Merchant{}
+	where, args := merchantFilterClause(name)
+	args = append(append(args, keyset.args...), keyset.limit(), keyset.offset())
 	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
 	SELECT
-		id, name, email, created_at, updated_at
+		id, name, email, created_at, updated_at, version
 	FROM
 		merchant
 	WHERE
-		name LIKE ?
+		`+where+` AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
 	LIMIT
 		? OFFSET ?
-	`, "%"+name+"%", limit, offset)
+	`, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		merchant := new(model.Merchant)
-		err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.CreatedAt, &merchant.UpdatedAt)
+		err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.CreatedAt, &merchant.UpdatedAt, &merchant.Version)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		merchants = append(merchants, merchant)
 	}
 
-	return merchants, nil
+	info := keyset.result(len(merchants),
+		func(n int) { merchants = merchants[:n] },
+		func() { reverseMerchants(merchants) },
+		func(i int) *model.Cursor {
+			return newCursor(merchantSort, []string{timeKey(merchants[i].CreatedAt)}, merchants[i].ID)
+		},
+	)
+
+	return merchants, info, nil
+}
+
+func (r *merchantRepository) Count(ctx context.Context, name string) (int64, error) {
+	where, args := merchantFilterClause(name)
+	return countCached(ctx, r.mysqlClient, r.redisClient, "merchant", `
+	SELECT
+		COUNT(*)
+	FROM
+		merchant
+	WHERE
+		`+where+`
+	`, args)
+}
+
+func merchantFilterClause(name string) (string, []interface{}) {
+	return "name LIKE ? AND deleted_at IS NULL", []interface{}{"%" + name + "%"}
+}
+
+func reverseMerchants(merchants []*model.Merchant) {
+	for i, j := 0, len(merchants)-1; i < j; i, j = i+1, j-1 {
+		merchants[i], merchants[j] = merchants[j], merchants[i]
+	}
 }
 
 func (r *merchantRepository) Get(ctx context.Context, id int64) (*model.Merchant, error) {
@@ -87,15 +143,7 @@ func (r *merchantRepository) Get(ctx context.Context, id int64) (*model.Merchant
 		return merchant, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
-	SELECT
-		id, name, email, password, created_at, updated_at
-	FROM
-		merchant
-	WHERE
-		id = ?
-	`, id,
-	).Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.Password, &merchant.CreatedAt, &merchant.UpdatedAt)
+	merchant, err = r.GetLatest(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +156,26 @@ func (r *merchantRepository) Get(ctx context.Context, id int64) (*model.Merchant
 	})
 }
 
+// GetLatest reads the merchant from MySQL, skipping the cache whose local layer other
+// instances cannot invalidate, for callers that need its current version.
+func (r *merchantRepository) GetLatest(ctx context.Context, id int64) (*model.Merchant, error) {
+	merchant := new(model.Merchant)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, name, email, password, created_at, updated_at, version
+	FROM
+		merchant
+	WHERE
+		id = ? AND deleted_at IS NULL
+	`, id,
+	).Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.Password, &merchant.CreatedAt, &merchant.UpdatedAt, &merchant.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
 func (r *merchantRepository) GetByEmail(ctx context.Context, email string) (*model.Merchant, error) {
 	merchant := new(model.Merchant)
 	err := r.redisClient.Cache().Get(ctx, fmt.Sprintf("merchant_%s", email), merchant)
@@ -119,13 +187,13 @@ func (r *merchantRepository) GetByEmail(ctx context.Context, email string) (*mod
 
 	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
 	SELECT
-		id, name, email, password, created_at, updated_at
+		id, name, email, password, created_at, updated_at, version
 	FROM
 		merchant
 	WHERE
-		email = ?
+		email = ? AND deleted_at IS NULL
 	`, email,
-	).Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.Password, &merchant.CreatedAt, &merchant.UpdatedAt)
+	).Scan(&merchant.ID, &merchant.Name, &merchant.Email, &merchant.Password, &merchant.CreatedAt, &merchant.UpdatedAt, &merchant.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -138,43 +206,310 @@ func (r *merchantRepository) GetByEmail(ctx context.Context, email string) (*mod
 	})
 }
 
-func (r *merchantRepository) Update(ctx context.Context, merchant *model.Merchant) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+// EmailTaken also counts deleted merchants, their email stays reserved until they are purged.
+func (r *merchantRepository) EmailTaken(ctx context.Context, email string, exceptID int64) (bool, error) {
+	var count int
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		merchant
+	WHERE
+		email = ? AND id <> ?
+	`, email, exceptID,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *merchantRepository) Update(ctx context.Context, merchant *model.Merchant, ifMatch model.Precondition) error {
+	return r.Patch(ctx, merchant, ifMatch, "name", "email", "password")
+}
+
+// Patch writes the given columns of the merchant and updated_at, moving it to the next
+// version. It fails with constant.ErrPreconditionFailed unless ifMatch holds for the
+// version the merchant is at.
+func (r *merchantRepository) Patch(ctx context.Context, merchant *model.Merchant, ifMatch model.Precondition, columns ...string) error {
+	values := map[string]interface{}{"name": merchant.Name, "email": merchant.Email, "password": merchant.Password}
+	set, args := "updated_at = ?, version = version + 1", []interface{}{merchant.UpdatedAt.Time}
+	for _, column := range columns {
+		value, found := values[column]
+		if !found {
+			return fmt.Errorf("merchant column %q cannot be patched", column)
+		}
+		set += ", " + column + " = ?"
+		args = append(args, value)
+	}
+
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		email   string
+		version int64
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		email, version
+	FROM
+		merchant
+	WHERE
+		id = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, merchant.ID,
+	).Scan(&email, &version)
+	if err != nil {
+		return err
+	} else if !ifMatch.Holds(version) {
+		return constant.ErrPreconditionFailed
+	}
+
+	_, err = tx.ExecContext(ctx, `
 	UPDATE
 		merchant
 	SET
-		name = ?, email = ?, password = ?, updated_at = ?
+		`+set+`
 	WHERE
 		id = ?
-	`, merchant.Name, merchant.Email, merchant.Password, merchant.UpdatedAt.Time, merchant.ID)
+	`, append(args, merchant.ID)...)
 	if err != nil {
 		return err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("merchant_%d", merchant.ID))
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	// the merchant is also cached under its email, the old one and the new one alike
+	err = r.deleteCache(ctx, merchant.ID, email, nil)
+	if err != nil {
+		return err
+	}
+	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("merchant_%s", merchant.Email))
 	if err != nil && err != cache.ErrCacheMiss {
 		return err
 	}
 
-	temp, err := r.Get(ctx, merchant.ID)
+	temp, err := r.GetLatest(ctx, merchant.ID)
+	if err != nil {
+		return err
+	}
 	*merchant = *temp
-	return err
+	return nil
 }
 
-func (r *merchantRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
-	DELETE FROM
+// Delete soft-deletes the merchant together with its products, stamping them with the
+// same deletedAt so Restore brings back exactly the products deleted with it. It returns
+// the ids of those products, or constant.ErrPreconditionFailed unless ifMatch holds for
+// the version the merchant is at.
+func (r *merchantRepository) Delete(ctx context.Context, id int64, ifMatch model.Precondition, deletedAt time.Time) ([]int64, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		email   string
+		version int64
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		email, version
+	FROM
 		merchant
+	WHERE
+		id = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, id,
+	).Scan(&email, &version)
+	if err != nil {
+		return nil, err
+	} else if !ifMatch.Holds(version) {
+		return nil, constant.ErrPreconditionFailed
+	}
+
+	productIDs, err := merchantProductIDs(ctx, tx, id, sql.NullTime{})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		merchant
+	SET
+		deleted_at = ?, version = version + 1
+	WHERE
+		id = ?
+	`, deletedAt, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		product
+	SET
+		deleted_at = ?, version = version + 1
+	WHERE
+		merchant_id = ? AND deleted_at IS NULL
+	`, deletedAt, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return productIDs, r.deleteCache(ctx, id, email, productIDs)
+}
+
+// Restore undoes Delete, products the merchant deleted on their own stay deleted. It
+// returns when the merchant was deleted and the ids of the restored products.
+func (r *merchantRepository) Restore(ctx context.Context, id int64) (time.Time, []int64, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	defer tx.Rollback()
+
+	var (
+		email     string
+		deletedAt sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		email, deleted_at
+	FROM
+		merchant
+	WHERE
+		id = ? AND deleted_at IS NOT NULL
+	FOR UPDATE
+	`, id,
+	).Scan(&email, &deletedAt)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	productIDs, err := merchantProductIDs(ctx, tx, id, deletedAt)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		merchant
+	SET
+		deleted_at = NULL, version = version + 1
 	WHERE
 		id = ?
 	`, id)
 	if err != nil {
-		return err
+		return time.Time{}, nil, err
 	}
 
-	err = r.redisClient.Cache().Delete(ctx, fmt.Sprintf("merchant_%d", id))
-	if err != nil && err != cache.ErrCacheMiss {
-		return err
+	if len(productIDs) > 0 {
+		ids := make([]interface{}, len(productIDs))
+		for i, productID := range productIDs {
+			ids[i] = productID
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			product
+		SET
+			deleted_at = NULL, version = version + 1
+		WHERE
+			id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+		`, ids...)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return deletedAt.Time, productIDs, r.deleteCache(ctx, id, email, productIDs)
+}
+
+// Purge hard-deletes merchants deleted before the given time. Their products are purged
+// by ProductRepository.Purge, which must run first.
+func (r *merchantRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		merchant
+	WHERE
+		deleted_at < ?
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// merchantProductIDs lists the merchant's active products, or with a valid deletedAt the
+// products deleted together with the merchant at that moment.
+func merchantProductIDs(ctx context.Context, tx *sql.Tx, merchantID int64, deletedAt sql.NullTime) ([]int64, error) {
+	where := "merchant_id = ? AND deleted_at IS NULL"
+	if deletedAt.Valid {
+		where = "merchant_id = ? AND deleted_at IS NOT NULL"
+	}
+
+	ids := []int64{}
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		id, deleted_at
+	FROM
+		product
+	WHERE
+		`+where+`
+	FOR UPDATE
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		product := &model.Product{Merchant: model.Merchant{DeletedAt: deletedAt}}
+		err := rows.Scan(&product.ID, &product.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		if !deletedAt.Valid || product.DeletedWithMerchant() {
+			ids = append(ids, product.ID)
+		}
+	}
+
+	return ids, rows.Err()
+}
+
+// deleteCache drops the cached merchant under both of its keys and the cached products,
+// which embed the merchant.
+func (r *merchantRepository) deleteCache(ctx context.Context, id int64, email string, productIDs []int64) error {
+	keys := []string{fmt.Sprintf("merchant_%d", id), fmt.Sprintf("merchant_%s", email)}
+	for _, productID := range productIDs {
+		keys = append(keys, fmt.Sprintf("product_%d", productID))
+	}
+
+	for _, key := range keys {
+		err := r.redisClient.Cache().Delete(ctx, key)
+		if err != nil && err != cache.ErrCacheMiss {
+			return err
+		}
 	}
 
 	return nil
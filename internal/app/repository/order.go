@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"strings"
+)
+
+type OrderRepository interface {
+	Create(ctx context.Context, order *model.Order) error
+	List(ctx context.Context, limit, offset int, buyerID, merchantID int64) ([]*model.Order, error)
+	Get(ctx context.Context, id int64) (*model.Order, error)
+	UpdateStatus(ctx context.Context, id int64, change func(order *model.Order) error) (*model.Order, error)
+}
+
+type orderRepository struct {
+	mysqlClient mysql.Client
+}
+
+func NewOrderRepository(mysqlClient mysql.Client) OrderRepository {
+	return &orderRepository{mysqlClient}
+}
+
+func (r *orderRepository) Create(ctx context.Context, order *model.Order) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		sales_order (buyer_id, status, total, discount, currency, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?)
+	`, order.BuyerID, order.Status, order.Total.Amount, order.Discount.Amount, order.Total.Currency, order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	order.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		item.OrderID = order.ID
+		res, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			order_item (order_id, product_id, merchant_id, name, status, unit_price, quantity, subtotal)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?)
+		`, item.OrderID, item.ProductID, item.MerchantID, item.Name, item.Status, item.UnitPrice.Amount, item.Quantity, item.Subtotal.Amount)
+		if err != nil {
+			return err
+		}
+
+		item.ID, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, applied := range order.Promotions {
+		err = redeemPromotion(ctx, tx, order, applied)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// redeemPromotion records the use of a promotion by the order. The promotion row stays
+// locked until the order commits, so concurrent orders cannot exceed its limits.
+func redeemPromotion(ctx context.Context, tx *sql.Tx, order *model.Order, applied *model.AppliedPromotion) error {
+	var usageLimit, perCustomerLimit sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+	SELECT
+		usage_limit, per_customer_limit
+	FROM
+		promotion
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, applied.Promotion.ID,
+	).Scan(&usageLimit, &perCustomerLimit)
+	if err == sql.ErrNoRows {
+		return constant.ErrPromotionNotFound
+	} else if err != nil {
+		return err
+	}
+
+	var total, customer int64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*), COALESCE(SUM(customer_id = ?), 0)
+	FROM
+		promotion_redemption
+	WHERE
+		promotion_id = ?
+	`, order.BuyerID, applied.Promotion.ID,
+	).Scan(&total, &customer)
+	if err != nil {
+		return err
+	}
+
+	if usageLimit.Valid && total >= usageLimit.Int64 {
+		return constant.ErrPromotionUsedUp
+	} else if perCustomerLimit.Valid && customer >= perCustomerLimit.Int64 {
+		return constant.ErrPromotionCustomerLimit
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO
+		promotion_redemption (promotion_id, customer_id, order_id, discount, created_at)
+	VALUES
+		(?, ?, ?, ?, ?)
+	`, applied.Promotion.ID, order.BuyerID, order.ID, applied.Discount.Amount, order.CreatedAt)
+	return err
+}
+
+func (r *orderRepository) List(ctx context.Context, limit, offset int, buyerID, merchantID int64) ([]*model.Order, error) {
+	var orders []*model.Order
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, buyer_id, status, total, discount, currency, created_at, updated_at
+	FROM
+		sales_order
+	WHERE
+		buyer_id = ? OR id IN (SELECT order_id FROM order_item WHERE merchant_id = ?)
+	ORDER BY
+		id DESC
+	LIMIT
+		? OFFSET ?
+	`, buyerID, merchantID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]*model.Order)
+	for rows.Next() {
+		order := new(model.Order)
+		err := rows.Scan(&order.ID, &order.BuyerID, &order.Status, &order.Total.Amount, &order.Discount.Amount, &order.Total.Currency, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+		byID[order.ID] = order
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+
+	items, err := listOrderItems(ctx, r.mysqlClient.Conn(), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		order := byID[item.OrderID]
+		order.Items = append(order.Items, item)
+	}
+
+	return orders, nil
+}
+
+func (r *orderRepository) Get(ctx context.Context, id int64) (*model.Order, error) {
+	order := new(model.Order)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, buyer_id, status, total, discount, currency, created_at, updated_at
+	FROM
+		sales_order
+	WHERE
+		id = ?
+	`, id,
+	).Scan(&order.ID, &order.BuyerID, &order.Status, &order.Total.Amount, &order.Discount.Amount, &order.Total.Currency, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Items, err = listOrderItems(ctx, r.mysqlClient.Conn(), order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// UpdateStatus applies change to the order under a lock on it, so merchants moving their
+// lines at the same time each see the others' lines, and payments, which lock it too,
+// cannot settle meanwhile. Cancelling the order gives back the promotion uses it counted
+// for.
+func (r *orderRepository) UpdateStatus(ctx context.Context, id int64, change func(order *model.Order) error) (*model.Order, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order := new(model.Order)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		id, buyer_id, status, total, discount, currency, created_at, updated_at
+	FROM
+		sales_order
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, id,
+	).Scan(&order.ID, &order.BuyerID, &order.Status, &order.Total.Amount, &order.Discount.Amount, &order.Total.Currency, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	order.Items, err = listOrderItems(ctx, tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending int
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		payment
+	WHERE
+		order_id = ? AND status = ?
+	LOCK IN SHARE MODE
+	`, order.ID, model.PaymentPending,
+	).Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+	order.PaymentPending = pending > 0
+
+	from := order.Status
+	err = change(order)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		sales_order
+	SET
+		status = ?, total = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, order.Status, order.Total.Amount, order.UpdatedAt.Time, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range order.Items {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			order_item
+		SET
+			status = ?
+		WHERE
+			id = ?
+		`, item.Status, item.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if order.Status == model.OrderCancelled && from != model.OrderCancelled {
+		_, err = tx.ExecContext(ctx, `
+		DELETE FROM
+			promotion_redemption
+		WHERE
+			order_id = ?
+		`, order.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order, tx.Commit()
+}
+
+func listOrderItems(ctx context.Context, db queryer, orderIDs ...interface{}) ([]*model.OrderItem, error) {
+	var items []*model.OrderItem
+	rows, err := db.QueryContext(ctx, `
+	SELECT
+		order_item.id, order_item.order_id, order_item.product_id, order_item.merchant_id, order_item.name,
+		order_item.status, order_item.unit_price, order_item.quantity, order_item.subtotal, sales_order.currency
+	FROM
+		order_item
+	INNER JOIN
+		sales_order
+	ON
+		order_item.order_id = sales_order.id
+	WHERE
+		order_item.order_id IN (?`+strings.Repeat(", ?", len(orderIDs)-1)+`)
+	ORDER BY
+		order_item.id
+	`, orderIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := new(model.OrderItem)
+		err := rows.Scan(
+			&item.ID, &item.OrderID, &item.ProductID, &item.MerchantID, &item.Name,
+			&item.Status, &item.UnitPrice.Amount, &item.Quantity, &item.Subtotal.Amount, &item.UnitPrice.Currency,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.Subtotal.Currency = item.UnitPrice.Currency
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/cache/v8"
+)
+
+// sortColumn whitelists a sortable field: the SQL column it maps to and how to turn a
+// cursor key back into a query argument.
+type sortColumn struct {
+	column string
+	parse  func(key string) (interface{}, error)
+}
+
+func parseTimeKey(key string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, key)
+}
+
+func parseIntKey(key string) (interface{}, error) {
+	return strconv.ParseInt(key, 10, 64)
+}
+
+func parseStringKey(key string) (interface{}, error) {
+	return key, nil
+}
+
+func timeKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// keyset builds the ORDER BY, LIMIT and OFFSET of a list query and, in cursor mode, the
+// condition selecting the rows past the cursor. The id column always ends the order so
+// rows with equal sort keys still have a stable position.
+type keyset struct {
+	page  model.Page
+	sort  []model.SortField
+	order []string
+	where string
+	args  []interface{}
+}
+
+func newKeyset(page model.Page, sort []model.SortField, columns map[string]sortColumn, idColumn string) (*keyset, error) {
+	k := &keyset{page: page, sort: sort, where: "TRUE"}
+
+	backward := page.Cursor != nil && page.Cursor.Backward
+	lastDesc := len(sort) > 0 && sort[len(sort)-1].Desc
+
+	type term struct {
+		column string
+		desc   bool
+		arg    interface{}
+	}
+	terms := make([]term, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := columns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("sort field %q is not whitelisted", field.Field)
+		}
+		terms = append(terms, term{column: column.column, desc: field.Desc != backward})
+	}
+	terms = append(terms, term{column: idColumn, desc: lastDesc != backward})
+
+	if page.UseCursor && page.Cursor != nil {
+		cursor := page.Cursor
+		if cursor.Sort != model.SortString(sort) || len(cursor.Keys) != len(sort) {
+			return nil, constant.ErrInvalidCursor
+		}
+
+		for i, field := range sort {
+			arg, err := columns[field.Field].parse(cursor.Keys[i])
+			if err != nil {
+				return nil, constant.ErrInvalidCursor
+			}
+			terms[i].arg = arg
+		}
+		terms[len(terms)-1].arg = cursor.ID
+
+		// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?)
+		var or []string
+		for i := range terms {
+			var and []string
+			for _, prev := range terms[:i] {
+				and = append(and, prev.column+" = ?")
+				k.args = append(k.args, prev.arg)
+			}
+			op := " > ?"
+			if terms[i].desc {
+				op = " < ?"
+			}
+			and = append(and, terms[i].column+op)
+			k.args = append(k.args, terms[i].arg)
+			or = append(or, "("+strings.Join(and, " AND ")+")")
+		}
+		k.where = "(" + strings.Join(or, " OR ") + ")"
+	}
+
+	for _, t := range terms {
+		if t.desc {
+			k.order = append(k.order, t.column+" DESC")
+		} else {
+			k.order = append(k.order, t.column+" ASC")
+		}
+	}
+
+	return k, nil
+}
+
+func (k *keyset) orderBy() string {
+	return strings.Join(k.order, ", ")
+}
+
+// limit fetches one extra row to learn whether there is another page.
+func (k *keyset) limit() int {
+	return k.page.Limit + 1
+}
+
+func (k *keyset) offset() int {
+	if k.page.UseCursor {
+		return 0
+	}
+	return k.page.Offset
+}
+
+// result takes the number of rows fetched and calls truncate and reverse to leave the
+// rows in display order, after which cursorAt(i) must return the forward cursor of row
+// i. In offset mode only HasMore is set.
+func (k *keyset) result(fetched int, truncate func(n int), reverse func(), cursorAt func(i int) *model.Cursor) *model.PageInfo {
+	info := &model.PageInfo{HasMore: fetched > k.page.Limit}
+	if info.HasMore {
+		truncate(k.page.Limit)
+		fetched = k.page.Limit
+	}
+	if !k.page.UseCursor {
+		return info
+	}
+
+	backward := k.page.Cursor != nil && k.page.Cursor.Backward
+	if backward {
+		reverse()
+	}
+	if fetched == 0 {
+		return info
+	}
+
+	first := cursorAt(0)
+	first.Backward = true
+	last := cursorAt(fetched - 1)
+
+	switch {
+	case backward:
+		info.Next = last
+		if info.HasMore {
+			info.Prev = first
+		}
+	default:
+		if info.HasMore {
+			info.Next = last
+		}
+		if k.page.Cursor != nil {
+			info.Prev = first
+		}
+	}
+
+	return info
+}
+
+func newCursor(sort []model.SortField, keys []string, id int64) *model.Cursor {
+	return &model.Cursor{Sort: model.SortString(sort), Keys: keys, ID: id}
+}
+
+// countCached runs a COUNT query, caching the result for ListCountTTL under a key derived
+// from the query and its arguments so every distinct filter is cached separately.
+func countCached(ctx context.Context, mysqlClient mysql.Client, redisClient redis.Client, prefix, query string, args []interface{}) (int64, error) {
+	sum := sha256.Sum256([]byte(fmt.Sprint(query, args)))
+	key := fmt.Sprintf("%s_count_%s", prefix, hex.EncodeToString(sum[:]))
+
+	var total int64
+	err := redisClient.Cache().Get(ctx, key, &total)
+	if err != nil && err != cache.ErrCacheMiss {
+		return 0, err
+	} else if err == nil {
+		return total, nil
+	}
+
+	err = mysqlClient.Conn().QueryRowContext(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+
+	return total, redisClient.Cache().Set(&cache.Item{
+		Ctx:   ctx,
+		Key:   key,
+		Value: total,
+		TTL:   config.Cfg().ListCountTTL,
+	})
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSortColumns = map[string]sortColumn{
+	"name":       {"name", parseStringKey},
+	"created_at": {"created_at", parseTimeKey},
+}
+
+func TestKeysetFirstPage(t *testing.T) {
+	sort := []model.SortField{{Field: "created_at", Desc: true}}
+
+	k, err := newKeyset(model.Page{Limit: 10, UseCursor: true}, sort, testSortColumns, "id")
+	require.NoError(t, err)
+	assert.Equal(t, "TRUE", k.where)
+	assert.Equal(t, "created_at DESC, id DESC", k.orderBy())
+	assert.Equal(t, 11, k.limit(), "one extra row tells whether there is another page")
+	assert.Equal(t, 0, k.offset())
+
+	k, err = newKeyset(model.Page{Limit: 10, Offset: 20}, sort, testSortColumns, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 11, k.limit(), "offset pages fetch the extra row too")
+	assert.Equal(t, 20, k.offset())
+}
+
+func TestKeysetOffsetResult(t *testing.T) {
+	k, err := newKeyset(model.Page{Limit: 2, Offset: 2}, []model.SortField{{Field: "name"}}, testSortColumns, "id")
+	require.NoError(t, err)
+
+	ids := []int64{3, 4, 5}
+	info := k.result(len(ids), func(n int) { ids = ids[:n] }, nil, nil)
+	assert.Equal(t, []int64{3, 4}, ids)
+	assert.Equal(t, &model.PageInfo{HasMore: true}, info)
+
+	ids = []int64{3, 4}
+	info = k.result(len(ids), func(n int) { ids = ids[:n] }, nil, nil)
+	assert.Equal(t, []int64{3, 4}, ids)
+	assert.False(t, info.HasMore, "has_more does not depend on the cached total")
+}
+
+func TestKeysetAfterCursor(t *testing.T) {
+	sort := []model.SortField{{Field: "name"}, {Field: "created_at", Desc: true}}
+	at := time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC)
+	cursor := newCursor(sort, []string{"Ayam", timeKey(at)}, 42)
+
+	k, err := newKeyset(model.Page{Limit: 10, UseCursor: true, Cursor: cursor}, sort, testSortColumns, "id")
+	require.NoError(t, err)
+	assert.Equal(t, "((name > ?) OR (name = ? AND created_at < ?) OR (name = ? AND created_at = ? AND id < ?))", k.where)
+	assert.Equal(t, []interface{}{"Ayam", "Ayam", at, "Ayam", at, int64(42)}, k.args)
+	assert.Equal(t, "name ASC, created_at DESC, id DESC", k.orderBy())
+
+	cursor.Backward = true
+	k, err = newKeyset(model.Page{Limit: 10, UseCursor: true, Cursor: cursor}, sort, testSortColumns, "id")
+	require.NoError(t, err)
+	assert.Equal(t, "((name < ?) OR (name = ? AND created_at > ?) OR (name = ? AND created_at = ? AND id > ?))", k.where)
+	assert.Equal(t, "name DESC, created_at ASC, id ASC", k.orderBy(), "backward pages read in reverse")
+}
+
+func TestKeysetRejectsCursor(t *testing.T) {
+	sort := []model.SortField{{Field: "created_at", Desc: true}}
+	at := timeKey(time.Now())
+
+	tests := map[string]*model.Cursor{
+		"other sort":      newCursor([]model.SortField{{Field: "name"}}, []string{"a"}, 1),
+		"other direction": newCursor([]model.SortField{{Field: "created_at"}}, []string{at}, 1),
+		"missing key":     {Sort: "-created_at", ID: 1},
+		"extra key":       {Sort: "-created_at", Keys: []string{at, at}, ID: 1},
+		"malformed key":   newCursor(sort, []string{"yesterday"}, 1),
+	}
+	for name, cursor := range tests {
+		_, err := newKeyset(model.Page{Limit: 10, UseCursor: true, Cursor: cursor}, sort, testSortColumns, "id")
+		assert.Equal(t, constant.ErrInvalidCursor, err, name)
+	}
+
+	_, err := newKeyset(model.Page{Limit: 10}, []model.SortField{{Field: "password"}}, testSortColumns, "id")
+	assert.Error(t, err, "sort fields are whitelisted")
+}
+
+func TestKeysetResult(t *testing.T) {
+	sort := []model.SortField{{Field: "name"}}
+	page := func(cursor *model.Cursor) *keyset {
+		k, err := newKeyset(model.Page{Limit: 2, UseCursor: true, Cursor: cursor}, sort, testSortColumns, "id")
+		require.NoError(t, err)
+		return k
+	}
+	run := func(k *keyset, ids ...int64) ([]int64, *model.PageInfo) {
+		info := k.result(len(ids),
+			func(n int) { ids = ids[:n] },
+			func() {
+				for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+					ids[i], ids[j] = ids[j], ids[i]
+				}
+			},
+			func(i int) *model.Cursor { return newCursor(sort, []string{"n"}, ids[i]) },
+		)
+		return ids, info
+	}
+
+	ids, info := run(page(nil), 1, 2, 3)
+	assert.Equal(t, []int64{1, 2}, ids)
+	assert.True(t, info.HasMore)
+	assert.Equal(t, int64(2), info.Next.ID)
+	assert.Nil(t, info.Prev, "the first page has no previous page")
+
+	ids, info = run(page(info.Next), 3, 4)
+	assert.Equal(t, []int64{3, 4}, ids)
+	assert.False(t, info.HasMore)
+	assert.Nil(t, info.Next)
+	assert.Equal(t, int64(3), info.Prev.ID)
+	assert.True(t, info.Prev.Backward)
+
+	// going back from 3 reads 2, 1 in reverse
+	ids, info = run(page(info.Prev), 2, 1)
+	assert.Equal(t, []int64{1, 2}, ids)
+	assert.False(t, info.HasMore)
+	assert.Nil(t, info.Prev)
+	assert.Equal(t, int64(2), info.Next.ID)
+
+	_, info = run(page(nil))
+	assert.False(t, info.HasMore)
+	assert.Nil(t, info.Next)
+	assert.Nil(t, info.Prev)
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *model.Payment) error
+	ListByOrder(ctx context.Context, orderID int64) ([]*model.Payment, error)
+	GetByIntent(ctx context.Context, provider, intentID string) (*model.Payment, error)
+	UpdateStatus(ctx context.Context, id int64, change func(payment *model.Payment, order model.OrderStatus)) (*model.Payment, error)
+}
+
+type paymentRepository struct {
+	mysqlClient mysql.Client
+}
+
+func NewPaymentRepository(mysqlClient mysql.Client) PaymentRepository {
+	return &paymentRepository{mysqlClient}
+}
+
+const paymentColumns = `id, order_id, provider, intent_id, idempotency_key, amount, currency, status,
+		client_secret, created_at, updated_at`
+
+// Create stores the payment unless one with its idempotency key exists, then loads
+// whichever was stored first into payment. The order is locked and has to be pending, so
+// it cannot be cancelled at the same time, constant.ErrOrderNotPayable otherwise.
+func (r *paymentRepository) Create(ctx context.Context, payment *model.Payment) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockOrderStatus(ctx, tx, payment.OrderID)
+	if err != nil {
+		return err
+	} else if status != model.OrderPending {
+		return constant.ErrOrderNotPayable
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT IGNORE INTO
+		payment (order_id, provider, intent_id, idempotency_key, amount, currency, status, client_secret, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, payment.OrderID, payment.Provider, payment.IntentID, payment.IdempotencyKey, payment.Amount.Amount,
+		payment.Amount.Currency, payment.Status, payment.ClientSecret, payment.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		`+paymentColumns+`
+	FROM
+		payment
+	WHERE
+		provider = ? AND idempotency_key = ?
+	`, payment.Provider, payment.IdempotencyKey,
+	).Scan(paymentFields(payment)...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *paymentRepository) ListByOrder(ctx context.Context, orderID int64) ([]*model.Payment, error) {
+	payments := []*model.Payment{}
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		`+paymentColumns+`
+	FROM
+		payment
+	WHERE
+		order_id = ?
+	ORDER BY
+		id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payment := new(model.Payment)
+		err := rows.Scan(paymentFields(payment)...)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *paymentRepository) GetByIntent(ctx context.Context, provider, intentID string) (*model.Payment, error) {
+	payment := new(model.Payment)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		`+paymentColumns+`
+	FROM
+		payment
+	WHERE
+		provider = ? AND intent_id = ?
+	`, provider, intentID,
+	).Scan(paymentFields(payment)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// UpdateStatus lets change settle a pending payment, given the status of its order. The
+// order is locked first, like everywhere else its status changes, and a succeeded payment
+// marks it paid in the same transaction. A payment that is no longer pending is returned
+// as it is, so webhooks delivered twice or out of order change nothing.
+func (r *paymentRepository) UpdateStatus(ctx context.Context, id int64, change func(payment *model.Payment, order model.OrderStatus)) (*model.Payment, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var orderID int64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		order_id
+	FROM
+		payment
+	WHERE
+		id = ?
+	`, id,
+	).Scan(&orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := lockOrderStatus(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := new(model.Payment)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		`+paymentColumns+`
+	FROM
+		payment
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, id,
+	).Scan(paymentFields(payment)...)
+	if err != nil || payment.Status != model.PaymentPending {
+		return payment, err
+	}
+
+	change(payment, status)
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		payment
+	SET
+		status = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, payment.Status, payment.UpdatedAt.Time, payment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == model.PaymentSucceeded {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			sales_order
+		SET
+			status = ?, updated_at = ?
+		WHERE
+			id = ?
+		`, model.OrderPaid, payment.UpdatedAt.Time, payment.OrderID)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			order_item
+		SET
+			status = ?
+		WHERE
+			order_id = ? AND status = ?
+		`, model.OrderPaid, payment.OrderID, model.OrderPending)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return payment, tx.Commit()
+}
+
+// lockOrderStatus locks the order row, serializing payments with status changes.
+func lockOrderStatus(ctx context.Context, tx *sql.Tx, orderID int64) (model.OrderStatus, error) {
+	var status model.OrderStatus
+	err := tx.QueryRowContext(ctx, `
+	SELECT
+		status
+	FROM
+		sales_order
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, orderID,
+	).Scan(&status)
+	return status, err
+}
+
+func paymentFields(payment *model.Payment) []interface{} {
+	return []interface{}{
+		&payment.ID, &payment.OrderID, &payment.Provider, &payment.IntentID, &payment.IdempotencyKey,
+		&payment.Amount.Amount, &payment.Amount.Currency, &payment.Status, &payment.ClientSecret,
+		&payment.CreatedAt, &payment.UpdatedAt,
+	}
+}
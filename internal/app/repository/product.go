@@ -5,17 +5,63 @@ import (
 	"fmt"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/db/mysql"
 	"github.com/SemmiDev/go-product/internal/db/redis"
 	"github.com/go-redis/cache/v8"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ProductRepository interface {
 	Create(ctx context.Context, product *model.Product) error
-	List(ctx context.Context, limit, offset int, title string) ([]*model.Product, error)
+	List(ctx context.Context, page model.Page, filter model.ProductFilter) ([]*model.Product, *model.PageInfo, error)
+	Count(ctx context.Context, filter model.ProductFilter) (int64, error)
 	Get(ctx context.Context, id int64) (*model.Product, error)
-	Update(ctx context.Context, product *model.Product) error
-	Delete(ctx context.Context, id int64) error
+	GetLatest(ctx context.Context, id int64) (*model.Product, error)
+	ListByIDs(ctx context.Context, ids []int64) ([]*model.Product, error)
+	Update(ctx context.Context, product *model.Product, ifMatch model.Precondition) error
+	Patch(ctx context.Context, product *model.Product, ifMatch model.Precondition, columns ...string) error
+	Delete(ctx context.Context, id int64, ifMatch model.Precondition, deletedAt time.Time) error
+
+	ListDeleted(ctx context.Context, page model.Page, merchantID int64) ([]*model.Product, *model.PageInfo, error)
+	CountDeleted(ctx context.Context, merchantID int64) (int64, error)
+	GetDeleted(ctx context.Context, id int64) (*model.Product, error)
+	Restore(ctx context.Context, id int64) error
+	Purge(ctx context.Context, before time.Time) (int64, []string, error)
+
+	ListPriceHistory(ctx context.Context, page model.Page, productID int64) ([]*model.ProductPrice, *model.PageInfo, error)
+	CountPriceHistory(ctx context.Context, productID int64) (int64, error)
+	GetPriceAt(ctx context.Context, productID int64, t time.Time) (*model.ProductPrice, error)
+
+	ListPriceRules(ctx context.Context, productID int64) ([]*model.PriceRule, error)
+	GetPriceRule(ctx context.Context, id int64) (*model.PriceRule, error)
+	CreatePriceRule(ctx context.Context, rule *model.PriceRule) error
+	DeletePriceRule(ctx context.Context, rule *model.PriceRule) error
+	SyncPriceRules(ctx context.Context, now time.Time) ([]int64, error)
+
+	ListImages(ctx context.Context, productID int64) ([]*model.ProductImage, error)
+	GetImage(ctx context.Context, id int64) (*model.ProductImage, error)
+	CreateImage(ctx context.Context, image *model.ProductImage) error
+	ReorderImages(ctx context.Context, productID int64, imageIDs []int64) error
+	DeleteImage(ctx context.Context, image *model.ProductImage) error
+
+	ListOptions(ctx context.Context, productID int64) ([]*model.ProductOption, error)
+	SetOptions(ctx context.Context, productID int64, options []*model.ProductOption, remove, create []*model.ProductVariant) error
+	ListVariants(ctx context.Context, productID int64) ([]*model.ProductVariant, error)
+	GetVariant(ctx context.Context, id int64) (*model.ProductVariant, error)
+	GetVariantBySKU(ctx context.Context, sku string) (*model.ProductVariant, error)
+	CreateVariant(ctx context.Context, variant *model.ProductVariant) error
+	UpdateVariant(ctx context.Context, variant *model.ProductVariant) error
+	DeleteVariant(ctx context.Context, variant *model.ProductVariant) error
+
+	GetStock(ctx context.Context, productID int64) (*model.Stock, error)
+	AdjustStock(ctx context.Context, productID int64, delta int) (*model.Stock, error)
+	CreateReservation(ctx context.Context, reservation *model.StockReservation) error
+	GetReservation(ctx context.Context, id int64) (*model.StockReservation, error)
+	ConfirmReservation(ctx context.Context, id int64) (*model.StockReservation, error)
+	ReleaseReservation(ctx context.Context, id int64) (*model.StockReservation, error)
 }
 
 type productRepository struct {
@@ -27,13 +73,20 @@ func NewProductRepository(mysqlClient mysql.Client, redisClient redis.Client) Pr
 	return &productRepository{mysqlClient, redisClient}
 }
 
+// Create saves the product and opens its price history at its creation time.
 func (r *productRepository) Create(ctx context.Context, product *model.Product) error {
-	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
 	INSERT INTO
-		product (name, price, merchant_id, created_at)
+		product (name, price, currency, merchant_id, created_at)
 	VALUES
-		(?, ?, ?, ?)
-	`, product.Name, product.Price, product.MerchantID, product.CreatedAt)
+		(?, ?, ?, ?, ?)
+	`, product.Name, product.Price.Amount, product.Price.Currency, product.MerchantID, product.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -43,17 +96,58 @@ func (r *productRepository) Create(ctx context.Context, product *model.Product)
 		return err
 	}
 
+	err = insertPrice(ctx, tx, product.ID, product.Price, product.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
 	temp, err := r.Get(ctx, product.ID)
 	*product = *temp
 	return nil
 }
 
-func (r productRepository) List(ctx context.Context, limit, offset int, title string) ([]*model.Product, error) {
-	var products []*model.Product
+// scaledPrice is product.price in 10^-MaxCurrencyExponent units of its currency, so prices
+// in currencies with a different number of decimals sort and filter by face value.
+var scaledPrice = scaledPriceExpr("product.price", "product.currency")
+
+func scaledPriceExpr(amountColumn, currencyColumn string) string {
+	expr := amountColumn + " * CASE " + currencyColumn
+	for _, code := range model.Currencies() {
+		scale := model.Money{Amount: 1, Currency: code}.Scaled(model.MaxCurrencyExponent)
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", code, scale)
+	}
+	return expr + " END"
+}
+
+var productSortColumns = map[string]sortColumn{
+	"name":       {"product.name", parseStringKey},
+	"price":      {scaledPrice, parseIntKey},
+	"created_at": {"product.created_at", parseTimeKey},
+}
+
+func (r productRepository) List(ctx context.Context, page model.Page, filter model.ProductFilter) ([]*model.Product, *model.PageInfo, error) {
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = model.DefaultProductSort
+	}
+
+	keyset, err := newKeyset(page, sort, productSortColumns, "product.id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products := []*model.Product{}
+	where, args := productFilterClause(filter)
+	args = append(append(args, keyset.args...), keyset.limit(), keyset.offset())
 	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
 	SELECT
-		product.id, product.name, product.price, product.created_at, product.updated_at, product.merchant_id,
-		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at
+		product.id, product.name, product.price, product.currency, product.created_at, product.updated_at, product.version, product.merchant_id,
+		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at, merchant.version
 	FROM
 		product
 	INNER JOIN
@@ -61,28 +155,153 @@ func (r productRepository) List(ctx context.Context, limit, offset int, title st
 	ON
 		product.merchant_id = merchant.id
 	WHERE
-		product.name LIKE ?
+		`+where+` AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
 	LIMIT
 		? OFFSET ?
-	`, "%"+title+"%", limit, offset)
+	`, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		product := new(model.Product)
 		err := rows.Scan(
-			&product.ID, &product.Name, &product.Price, &product.CreatedAt, &product.UpdatedAt, &product.MerchantID,
-			&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt,
+			&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.CreatedAt, &product.UpdatedAt, &product.Version, &product.MerchantID,
+			&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt, &product.Merchant.Version,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		products = append(products, product)
 	}
 
-	return products, nil
+	info := keyset.result(len(products),
+		func(n int) { products = products[:n] },
+		func() { reverseProducts(products) },
+		func(i int) *model.Cursor {
+			return newCursor(sort, productSortKeys(sort, products[i]), products[i].ID)
+		},
+	)
+
+	return products, info, r.attachDetails(ctx, products)
+}
+
+func (r productRepository) Count(ctx context.Context, filter model.ProductFilter) (int64, error) {
+	where, args := productFilterClause(filter)
+	return countCached(ctx, r.mysqlClient, r.redisClient, "product", `
+	SELECT
+		COUNT(*)
+	FROM
+		product
+	INNER JOIN
+		merchant
+	ON
+		product.merchant_id = merchant.id
+	WHERE
+		`+where+`
+	`, args)
+}
+
+// attachDetails loads the images, options, variants and active price rule embedded in a
+// product.
+func (r productRepository) attachDetails(ctx context.Context, products []*model.Product) error {
+	err := r.attachImages(ctx, products)
+	if err != nil {
+		return err
+	}
+	err = r.attachVariants(ctx, products)
+	if err != nil {
+		return err
+	}
+	return r.attachPriceRules(ctx, products)
+}
+
+func productSortKeys(sort []model.SortField, product *model.Product) []string {
+	keys := make([]string, len(sort))
+	for i, field := range sort {
+		switch field.Field {
+		case "name":
+			keys[i] = product.Name
+		case "price":
+			keys[i] = strconv.FormatInt(product.Price.Scaled(model.MaxCurrencyExponent), 10)
+		case "created_at":
+			keys[i] = timeKey(product.CreatedAt)
+		}
+	}
+	return keys
+}
+
+func reverseProducts(products []*model.Product) {
+	for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+		products[i], products[j] = products[j], products[i]
+	}
+}
+
+func productFilterClause(filter model.ProductFilter) (string, []interface{}) {
+	where := "product.name LIKE ? AND product.deleted_at IS NULL"
+	args := []interface{}{"%" + filter.Name + "%"}
+
+	if filter.MerchantID != 0 {
+		where += " AND product.merchant_id = ?"
+		args = append(args, filter.MerchantID)
+	}
+	if filter.MinPrice != nil {
+		where += " AND " + scaledPrice + " >= ?"
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where += " AND " + scaledPrice + " <= ?"
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.CreatedAfter != nil {
+		where += " AND product.created_at >= ?"
+		args = append(args, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		where += " AND product.created_at < ?"
+		args = append(args, *filter.CreatedBefore)
+	}
+
+	switch {
+	case filter.CategorySlug != "" && filter.IncludeDescendants:
+		where += `
+		AND product.id IN (
+			SELECT
+				product_category.product_id
+			FROM
+				product_category
+			WHERE
+				product_category.category_id IN (
+					WITH RECURSIVE tree AS (
+						SELECT id FROM category WHERE slug = ?
+						UNION ALL
+						SELECT category.id FROM category INNER JOIN tree ON category.parent_id = tree.id
+					)
+					SELECT id FROM tree
+				)
+		)`
+		args = append(args, filter.CategorySlug)
+	case filter.CategorySlug != "":
+		where += `
+		AND product.id IN (
+			SELECT
+				product_category.product_id
+			FROM
+				product_category
+			INNER JOIN
+				category
+			ON
+				product_category.category_id = category.id
+			WHERE
+				category.slug = ?
+		)`
+		args = append(args, filter.CategorySlug)
+	}
+
+	return where, args
 }
 
 func (r productRepository) Get(ctx context.Context, id int64) (*model.Product, error) {
@@ -94,10 +313,33 @@ func (r productRepository) Get(ctx context.Context, id int64) (*model.Product, e
 		return product, nil
 	}
 
-	err = r.mysqlClient.Conn().QueryRowContext(ctx, `
+	product, err = r.GetLatest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := productCacheTTL(product, time.Now())
+	if ttl < time.Second {
+		// the sale is ending and the scheduler has yet to deactivate it
+		return product, nil
+	}
+
+	return product, r.redisClient.Cache().Set(&cache.Item{
+		Ctx:   ctx,
+		Key:   fmt.Sprintf("product_%d", id),
+		Value: product,
+		TTL:   ttl,
+	})
+}
+
+// GetLatest reads the product from MySQL, skipping the cache whose local layer other
+// instances cannot invalidate, for callers that need its current version.
+func (r productRepository) GetLatest(ctx context.Context, id int64) (*model.Product, error) {
+	product := new(model.Product)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
 	SELECT
-		product.id, product.name, product.price, product.created_at, product.updated_at, product.merchant_id,
-		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at
+		product.id, product.name, product.price, product.currency, product.created_at, product.updated_at, product.version, product.merchant_id,
+		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at, merchant.version
 	FROM
 		product
 	INNER JOIN
@@ -105,33 +347,142 @@ func (r productRepository) Get(ctx context.Context, id int64) (*model.Product, e
 	ON
 		product.merchant_id = merchant.id
 	WHERE
-		product.id = ?
+		product.id = ? AND product.deleted_at IS NULL
 	`, id,
 	).Scan(
-		&product.ID, &product.Name, &product.Price, &product.CreatedAt, &product.UpdatedAt, &product.MerchantID,
-		&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt,
+		&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.CreatedAt, &product.UpdatedAt, &product.Version, &product.MerchantID,
+		&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt, &product.Merchant.Version,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return product, r.redisClient.Cache().Set(&cache.Item{
-		Ctx:   ctx,
-		Key:   fmt.Sprintf("product_%d", id),
-		Value: product,
-		TTL:   config.Cfg().RedisTTL,
-	})
+	return product, r.attachDetails(ctx, []*model.Product{product})
 }
 
-func (r productRepository) Update(ctx context.Context, product *model.Product) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+// productCacheTTL keeps a product on sale cached no longer than its sale lasts, so a late
+// scheduler run cannot serve the sale price past its end.
+func productCacheTTL(product *model.Product, now time.Time) time.Duration {
+	ttl := config.Cfg().RedisTTL
+	if product.PriceRule != nil {
+		if remaining := product.PriceRule.EndsAt.Sub(now); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return ttl
+}
+
+func (r productRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.Product, error) {
+	products := []*model.Product{}
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		product.id, product.name, product.price, product.currency, product.created_at, product.updated_at, product.version, product.merchant_id,
+		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at, merchant.version
+	FROM
+		product
+	INNER JOIN
+		merchant
+	ON
+		product.merchant_id = merchant.id
+	WHERE
+		product.id IN (?`+strings.Repeat(", ?", len(ids)-1)+`) AND product.deleted_at IS NULL
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		product := new(model.Product)
+		err := rows.Scan(
+			&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.CreatedAt, &product.UpdatedAt, &product.Version, &product.MerchantID,
+			&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt, &product.Merchant.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, r.attachDetails(ctx, products)
+}
+
+func (r productRepository) Update(ctx context.Context, product *model.Product, ifMatch model.Precondition) error {
+	return r.Patch(ctx, product, ifMatch, "name", "price", "currency")
+}
+
+// Patch writes the given columns of the product and updated_at, closing the current price
+// history entry and opening a new one at UpdatedAt when a written price or currency
+// changed, and moves it to the next version. It fails with constant.ErrPreconditionFailed
+// unless ifMatch holds for the version the product is at.
+func (r productRepository) Patch(ctx context.Context, product *model.Product, ifMatch model.Precondition, columns ...string) error {
+	values := map[string]interface{}{"name": product.Name, "price": product.Price.Amount, "currency": product.Price.Currency}
+	set, args := "updated_at = ?, version = version + 1", []interface{}{product.UpdatedAt.Time}
+	for _, column := range columns {
+		value, found := values[column]
+		if !found {
+			return fmt.Errorf("product column %q cannot be patched", column)
+		}
+		set += ", " + column + " = ?"
+		args = append(args, value)
+	}
+
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		current model.Money
+		version int64
+	)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		price, currency, version
+	FROM
+		product
+	WHERE
+		id = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, product.ID,
+	).Scan(&current.Amount, &current.Currency, &version)
+	if err != nil {
+		return err
+	} else if !ifMatch.Holds(version) {
+		return constant.ErrPreconditionFailed
+	}
+
+	_, err = tx.ExecContext(ctx, `
 	UPDATE
 		product
 	SET
-		name = ?, price = ?, updated_at = ?
+		`+set+`
 	WHERE
 		id = ?
-	`, product.Name, product.Price, product.UpdatedAt.Time, product.ID)
+	`, append(args, product.ID)...)
+	if err != nil {
+		return err
+	}
+
+	written := patchedPrice(current, product.Price, columns)
+	if written != current {
+		err = insertPrice(ctx, tx, product.ID, written, product.UpdatedAt.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return err
 	}
@@ -141,18 +492,68 @@ func (r productRepository) Update(ctx context.Context, product *model.Product) e
 		return err
 	}
 
-	temp, err := r.Get(ctx, product.ID)
+	temp, err := r.GetLatest(ctx, product.ID)
+	if err != nil {
+		return err
+	}
 	*product = *temp
-	return err
+	return nil
+}
+
+// patchedPrice is the price a product at current has once columns of price are written,
+// the parts of price outside columns are not.
+func patchedPrice(current, price model.Money, columns []string) model.Money {
+	for _, column := range columns {
+		switch column {
+		case "price":
+			current.Amount = price.Amount
+		case "currency":
+			current.Currency = price.Currency
+		}
+	}
+	return current
 }
 
-func (r productRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
-	DELETE FROM
+// Delete soft-deletes the product, it stays restorable until the purge job removes it. It
+// fails with constant.ErrPreconditionFailed unless ifMatch holds for the version the
+// product is at.
+func (r productRepository) Delete(ctx context.Context, id int64, ifMatch model.Precondition, deletedAt time.Time) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		version
+	FROM
+		product
+	WHERE
+		id = ? AND deleted_at IS NULL
+	FOR UPDATE
+	`, id,
+	).Scan(&version)
+	if err != nil {
+		return err
+	} else if !ifMatch.Holds(version) {
+		return constant.ErrPreconditionFailed
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
 		product
+	SET
+		deleted_at = ?, version = version + 1
 	WHERE
 		id = ?
-	`, id)
+	`, deletedAt, id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return err
 	}
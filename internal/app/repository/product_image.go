@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"strings"
+
+	"github.com/go-redis/cache/v8"
+)
+
+func (r *productRepository) ListImages(ctx context.Context, productID int64) ([]*model.ProductImage, error) {
+	return r.listImages(ctx, productID)
+}
+
+func (r *productRepository) GetImage(ctx context.Context, id int64) (*model.ProductImage, error) {
+	image := new(model.ProductImage)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, product_id, storage_key, url, content_type, size, width, height, position, created_at
+	FROM
+		product_image
+	WHERE
+		id = ?
+	`, id,
+	).Scan(
+		&image.ID, &image.ProductID, &image.Key, &image.URL, &image.ContentType,
+		&image.Size, &image.Width, &image.Height, &image.Position, &image.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// CreateImage appends image after the product's current last image.
+func (r *productRepository) CreateImage(ctx context.Context, image *model.ProductImage) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// locking the product row serializes concurrent uploads picking the next position
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		id
+	FROM
+		product
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, image.ProductID,
+	).Scan(&image.ProductID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		COALESCE(MAX(position) + 1, 0)
+	FROM
+		product_image
+	WHERE
+		product_id = ?
+	`, image.ProductID,
+	).Scan(&image.Position)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		product_image (product_id, storage_key, url, content_type, size, width, height, position, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, image.ProductID, image.Key, image.URL, image.ContentType, image.Size, image.Width, image.Height, image.Position, image.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	image.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, image.ProductID)
+}
+
+func (r *productRepository) ReorderImages(ctx context.Context, productID int64, imageIDs []int64) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for position, id := range imageIDs {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			product_image
+		SET
+			position = ?
+		WHERE
+			id = ? AND product_id = ?
+		`, position, id, productID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, productID)
+}
+
+func (r *productRepository) DeleteImage(ctx context.Context, image *model.ProductImage) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		product_image
+	WHERE
+		id = ?
+	`, image.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, image.ProductID)
+}
+
+func (r *productRepository) listImages(ctx context.Context, productIDs ...interface{}) ([]*model.ProductImage, error) {
+	images := []*model.ProductImage{}
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, product_id, storage_key, url, content_type, size, width, height, position, created_at
+	FROM
+		product_image
+	WHERE
+		product_id IN (?`+strings.Repeat(", ?", len(productIDs)-1)+`)
+	ORDER BY
+		position, id
+	`, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		image := new(model.ProductImage)
+		err := rows.Scan(
+			&image.ID, &image.ProductID, &image.Key, &image.URL, &image.ContentType,
+			&image.Size, &image.Width, &image.Height, &image.Position, &image.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+func (r *productRepository) attachImages(ctx context.Context, products []*model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Product, len(products))
+	ids := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		product.Images = []*model.ProductImage{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	images, err := r.listImages(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		product := byID[image.ProductID]
+		product.Images = append(product.Images, image)
+	}
+
+	return nil
+}
+
+func (r *productRepository) deleteProductCache(ctx context.Context, productID int64) error {
+	err := r.redisClient.Cache().Delete(ctx, fmt.Sprintf("product_%d", productID))
+	if err != nil && err != cache.ErrCacheMiss {
+		return err
+	}
+	return nil
+}
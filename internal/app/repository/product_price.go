@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"time"
+)
+
+var (
+	priceHistorySort        = []model.SortField{{Field: "effective_from", Desc: true}}
+	priceHistorySortColumns = map[string]sortColumn{
+		"effective_from": {"effective_from", parseTimeKey},
+	}
+)
+
+// ListPriceHistory lists the prices of a product, the current one first.
+func (r *productRepository) ListPriceHistory(ctx context.Context, page model.Page, productID int64) ([]*model.ProductPrice, *model.PageInfo, error) {
+	keyset, err := newKeyset(page, priceHistorySort, priceHistorySortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prices := []*model.ProductPrice{}
+	args := append(append([]interface{}{productID}, keyset.args...), keyset.limit(), keyset.offset())
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, product_id, price, currency, effective_from, effective_to
+	FROM
+		product_price_history
+	WHERE
+		product_id = ? AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
+	LIMIT
+		? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		price := new(model.ProductPrice)
+		err := rows.Scan(
+			&price.ID, &price.ProductID, &price.Price.Amount, &price.Price.Currency,
+			&price.EffectiveFrom, &price.EffectiveTo,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		prices = append(prices, price)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	info := keyset.result(len(prices),
+		func(n int) { prices = prices[:n] },
+		func() { reversePrices(prices) },
+		func(i int) *model.Cursor {
+			return newCursor(priceHistorySort, []string{timeKey(prices[i].EffectiveFrom)}, prices[i].ID)
+		},
+	)
+
+	return prices, info, nil
+}
+
+func reversePrices(prices []*model.ProductPrice) {
+	for i, j := 0, len(prices)-1; i < j; i, j = i+1, j-1 {
+		prices[i], prices[j] = prices[j], prices[i]
+	}
+}
+
+func (r *productRepository) CountPriceHistory(ctx context.Context, productID int64) (int64, error) {
+	var count int64
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		product_price_history
+	WHERE
+		product_id = ?
+	`, productID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetPriceAt returns the price in effect at t: the latest entry starting at or before t
+// that had not yet ended. Before the product existed there is none, sql.ErrNoRows.
+func (r *productRepository) GetPriceAt(ctx context.Context, productID int64, t time.Time) (*model.ProductPrice, error) {
+	price := new(model.ProductPrice)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, product_id, price, currency, effective_from, effective_to
+	FROM
+		product_price_history
+	WHERE
+		product_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)
+	ORDER BY
+		effective_from DESC, id DESC
+	LIMIT
+		1
+	`, productID, t, t,
+	).Scan(
+		&price.ID, &price.ProductID, &price.Price.Amount, &price.Price.Currency,
+		&price.EffectiveFrom, &price.EffectiveTo,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return price, nil
+}
+
+// insertPrice ends the product's current price at from and starts price there.
+func insertPrice(ctx context.Context, db execer, productID int64, price model.Money, from time.Time) error {
+	_, err := db.ExecContext(ctx, `
+	UPDATE
+		product_price_history
+	SET
+		effective_to = ?
+	WHERE
+		product_id = ? AND effective_to IS NULL
+	`, from, productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+	INSERT INTO
+		product_price_history (product_id, price, currency, effective_from)
+	VALUES
+		(?, ?, ?, ?)
+	`, productID, price.Amount, price.Currency, from)
+	return err
+}
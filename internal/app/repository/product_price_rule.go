@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"strings"
+	"time"
+)
+
+func (r *productRepository) ListPriceRules(ctx context.Context, productID int64) ([]*model.PriceRule, error) {
+	return r.queryPriceRules(ctx, `
+	SELECT
+		id, product_id, starts_at, ends_at, sale_price, percentage, active, created_at
+	FROM
+		price_rule
+	WHERE
+		product_id = ?
+	ORDER BY
+		starts_at, id
+	`, productID)
+}
+
+func (r *productRepository) GetPriceRule(ctx context.Context, id int64) (*model.PriceRule, error) {
+	rule := new(model.PriceRule)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, product_id, starts_at, ends_at, sale_price, percentage, active, created_at
+	FROM
+		price_rule
+	WHERE
+		id = ?
+	`, id,
+	).Scan(
+		&rule.ID, &rule.ProductID, &rule.StartsAt, &rule.EndsAt,
+		&rule.SalePrice, &rule.Percentage, &rule.Active, &rule.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// CreatePriceRule saves rule unless its period overlaps another rule of the product, so at
+// most one rule is ever active per product.
+func (r *productRepository) CreatePriceRule(ctx context.Context, rule *model.PriceRule) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// locking the product row serializes concurrent overlap checks
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		id
+	FROM
+		product
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, rule.ProductID,
+	).Scan(&rule.ProductID)
+	if err != nil {
+		return err
+	}
+
+	var overlapping int
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		price_rule
+	WHERE
+		product_id = ? AND starts_at < ? AND ends_at > ?
+	`, rule.ProductID, rule.EndsAt, rule.StartsAt,
+	).Scan(&overlapping)
+	if err != nil {
+		return err
+	} else if overlapping > 0 {
+		return constant.ErrPriceRuleOverlap
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		price_rule (product_id, starts_at, ends_at, sale_price, percentage, active, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?)
+	`, rule.ProductID, rule.StartsAt, rule.EndsAt, rule.SalePrice, rule.Percentage, rule.Active, rule.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	rule.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, rule.ProductID)
+}
+
+func (r *productRepository) DeletePriceRule(ctx context.Context, rule *model.PriceRule) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		price_rule
+	WHERE
+		id = ?
+	`, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, rule.ProductID)
+}
+
+// SyncPriceRules activates the rules whose period contains now and deactivates the others,
+// returning the products whose price changed. Their cached copies are dropped so the next
+// Get loads the new effective price.
+func (r *productRepository) SyncPriceRules(ctx context.Context, now time.Time) ([]int64, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rules, err := queryPriceRules(ctx, tx, `
+	SELECT
+		id, product_id, starts_at, ends_at, sale_price, percentage, active, created_at
+	FROM
+		price_rule
+	WHERE
+		active <> (starts_at <= ? AND ends_at > ?)
+	FOR UPDATE
+	`, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := []int64{}
+	seen := make(map[int64]bool, len(rules))
+	for _, rule := range rules {
+		_, err = tx.ExecContext(ctx, `
+		UPDATE
+			price_rule
+		SET
+			active = ?
+		WHERE
+			id = ?
+		`, !rule.Active, rule.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !seen[rule.ProductID] {
+			seen[rule.ProductID] = true
+			productIDs = append(productIDs, rule.ProductID)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, productID := range productIDs {
+		err = r.deleteProductCache(ctx, productID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return productIDs, nil
+}
+
+func (r *productRepository) queryPriceRules(ctx context.Context, query string, args ...interface{}) ([]*model.PriceRule, error) {
+	return queryPriceRules(ctx, r.mysqlClient.Conn(), query, args...)
+}
+
+func queryPriceRules(ctx context.Context, db queryer, query string, args ...interface{}) ([]*model.PriceRule, error) {
+	rules := []*model.PriceRule{}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rule := new(model.PriceRule)
+		err := rows.Scan(
+			&rule.ID, &rule.ProductID, &rule.StartsAt, &rule.EndsAt,
+			&rule.SalePrice, &rule.Percentage, &rule.Active, &rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// attachPriceRules loads the active rule of each product, the one setting its effective price.
+func (r *productRepository) attachPriceRules(ctx context.Context, products []*model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Product, len(products))
+	ids := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		product.PriceRule = nil
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	rules, err := r.queryPriceRules(ctx, `
+	SELECT
+		id, product_id, starts_at, ends_at, sale_price, percentage, active, created_at
+	FROM
+		price_rule
+	WHERE
+		product_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`) AND active = 1
+	`, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		byID[rule.ProductID].PriceRule = rule
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"github.com/SemmiDev/go-product/internal/search"
+)
+
+type ProductSearcher interface {
+	Search(ctx context.Context, query string, limit, offset int) ([]*model.ProductSearchHit, error)
+	Index(ctx context.Context, product *model.Product) error
+	Remove(ctx context.Context, id int64) error
+}
+
+// mysqlProductSearcher relies on the ngram FULLTEXT index on product.name. Matching on
+// character bigrams makes prefixes match and lets a query with a typo still share most
+// bigrams with the intended name, MySQL ranks by how many and how rare those are.
+type mysqlProductSearcher struct {
+	mysqlClient       mysql.Client
+	productRepository ProductRepository
+}
+
+func NewMySQLProductSearcher(mysqlClient mysql.Client, productRepository ProductRepository) ProductSearcher {
+	return &mysqlProductSearcher{mysqlClient, productRepository}
+}
+
+func (s *mysqlProductSearcher) Search(ctx context.Context, query string, limit, offset int) ([]*model.ProductSearchHit, error) {
+	rows, err := s.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, MATCH (name) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+	FROM
+		product
+	WHERE
+		MATCH (name) AGAINST (? IN NATURAL LANGUAGE MODE) AND deleted_at IS NULL
+	ORDER BY
+		score DESC, id
+	LIMIT
+		? OFFSET ?
+	`, query, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hits []search.Hit
+	for rows.Next() {
+		var hit search.Hit
+		err := rows.Scan(&hit.ID, &hit.Score)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return loadSearchHits(ctx, s.productRepository, hits)
+}
+
+func (s *mysqlProductSearcher) Index(ctx context.Context, product *model.Product) error {
+	return nil
+}
+
+func (s *mysqlProductSearcher) Remove(ctx context.Context, id int64) error {
+	return nil
+}
+
+// memoryProductSearcher keeps an inverted index of product names in process. It is built
+// from the database on start and only sees writes made through this process, so it suits
+// a single instance deployment.
+type memoryProductSearcher struct {
+	index             *search.Index
+	productRepository ProductRepository
+}
+
+func NewMemoryProductSearcher(ctx context.Context, mysqlClient mysql.Client, productRepository ProductRepository) (ProductSearcher, error) {
+	index := search.NewIndex()
+
+	rows, err := mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, name
+	FROM
+		product
+	WHERE
+		deleted_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		err := rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+		index.Add(id, name)
+	}
+
+	return &memoryProductSearcher{index, productRepository}, rows.Err()
+}
+
+func (s *memoryProductSearcher) Search(ctx context.Context, query string, limit, offset int) ([]*model.ProductSearchHit, error) {
+	hits := s.index.Search(query)
+	if offset >= len(hits) {
+		return []*model.ProductSearchHit{}, nil
+	}
+	hits = hits[offset:]
+	if limit < len(hits) {
+		hits = hits[:limit]
+	}
+
+	return loadSearchHits(ctx, s.productRepository, hits)
+}
+
+func (s *memoryProductSearcher) Index(ctx context.Context, product *model.Product) error {
+	s.index.Add(product.ID, product.Name)
+	return nil
+}
+
+func (s *memoryProductSearcher) Remove(ctx context.Context, id int64) error {
+	s.index.Remove(id)
+	return nil
+}
+
+// loadSearchHits loads the products of hits, keeping the ranking and skipping products
+// that disappeared since they were indexed.
+func loadSearchHits(ctx context.Context, productRepository ProductRepository, hits []search.Hit) ([]*model.ProductSearchHit, error) {
+	ids := make([]int64, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+
+	products, err := productRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*model.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	res := make([]*model.ProductSearchHit, 0, len(hits))
+	for _, hit := range hits {
+		if product, ok := byID[hit.ID]; ok {
+			res = append(res, &model.ProductSearchHit{Product: product, Score: hit.Score})
+		}
+	}
+
+	return res, nil
+}
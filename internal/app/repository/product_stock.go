@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"time"
+)
+
+// GetStock reads the stock without locking or writing it: a product without a stock row
+// has none, and pending reservations past their expiry no longer count as reserved.
+func (r *productRepository) GetStock(ctx context.Context, productID int64) (*model.Stock, error) {
+	stock := new(model.Stock)
+	var expired sql.NullInt64
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		product.id, COALESCE(product_stock.on_hand, 0), COALESCE(product_stock.reserved, 0), product_stock.updated_at,
+		(
+			SELECT
+				SUM(quantity)
+			FROM
+				stock_reservation
+			WHERE
+				product_id = product.id AND status = ? AND expires_at <= ?
+		)
+	FROM
+		product
+	LEFT JOIN
+		product_stock
+	ON
+		product_stock.product_id = product.id
+	WHERE
+		product.id = ?
+	`, model.ReservationPending, time.Now(), productID,
+	).Scan(&stock.ProductID, &stock.OnHand, &stock.Reserved, &stock.UpdatedAt, &expired)
+	if err != nil {
+		return nil, err
+	}
+
+	stock.Expire(int(expired.Int64))
+	return stock, nil
+}
+
+func (r *productRepository) AdjustStock(ctx context.Context, productID int64, delta int) (*model.Stock, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stock, err := r.lockStock(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = stock.Adjust(delta)
+	if err != nil {
+		return nil, err
+	}
+	stock.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	err = r.saveStock(ctx, tx, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	return stock, tx.Commit()
+}
+
+func (r *productRepository) CreateReservation(ctx context.Context, reservation *model.StockReservation) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stock, err := r.lockStock(ctx, tx, reservation.ProductID)
+	if err != nil {
+		return err
+	}
+
+	err = stock.Reserve(reservation.Quantity)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		stock_reservation (product_id, owner_id, quantity, status, expires_at, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?)
+	`, reservation.ProductID, reservation.OwnerID, reservation.Quantity, reservation.Status, reservation.ExpiresAt, reservation.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	reservation.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	stock.UpdatedAt = sql.NullTime{Time: reservation.CreatedAt, Valid: true}
+
+	err = r.saveStock(ctx, tx, stock)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *productRepository) GetReservation(ctx context.Context, id int64) (*model.StockReservation, error) {
+	reservation := new(model.StockReservation)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		id, product_id, owner_id, quantity, status, expires_at, created_at, updated_at
+	FROM
+		stock_reservation
+	WHERE
+		id = ?
+	`, id,
+	).Scan(
+		&reservation.ID, &reservation.ProductID, &reservation.OwnerID, &reservation.Quantity, &reservation.Status,
+		&reservation.ExpiresAt, &reservation.CreatedAt, &reservation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (r *productRepository) ConfirmReservation(ctx context.Context, id int64) (*model.StockReservation, error) {
+	return r.settleReservation(ctx, id, model.ReservationConfirmed)
+}
+
+func (r *productRepository) ReleaseReservation(ctx context.Context, id int64) (*model.StockReservation, error) {
+	return r.settleReservation(ctx, id, model.ReservationReleased)
+}
+
+// settleReservation locks the stock row before the reservation row, in the same order as
+// CreateReservation, so concurrent reserve/confirm/release calls cannot deadlock.
+func (r *productRepository) settleReservation(ctx context.Context, id int64, status model.ReservationStatus) (*model.StockReservation, error) {
+	reservation, err := r.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stock, err := r.lockStock(ctx, tx, reservation.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		status
+	FROM
+		stock_reservation
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, id,
+	).Scan(&reservation.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	err = reservation.Settle(stock, status, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		stock_reservation
+	SET
+		status = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, reservation.Status, reservation.UpdatedAt, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.saveStock(ctx, tx, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, tx.Commit()
+}
+
+// lockStock takes a row lock on the product's stock, creating it on first use, and
+// expires pending reservations that ran out of time so they no longer hold stock. Only
+// writes lock the stock, GetStock reads it as is.
+func (r *productRepository) lockStock(ctx context.Context, tx *sql.Tx, productID int64) (*model.Stock, error) {
+	_, err := tx.ExecContext(ctx, `
+	INSERT IGNORE INTO
+		product_stock (product_id)
+	SELECT
+		id
+	FROM
+		product
+	WHERE
+		id = ?
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	stock := new(model.Stock)
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		product_id, on_hand, reserved, updated_at
+	FROM
+		product_stock
+	WHERE
+		product_id = ?
+	FOR UPDATE
+	`, productID,
+	).Scan(&stock.ProductID, &stock.OnHand, &stock.Reserved, &stock.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var expired sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		SUM(quantity)
+	FROM
+		stock_reservation
+	WHERE
+		product_id = ? AND status = ? AND expires_at <= ?
+	FOR UPDATE
+	`, productID, model.ReservationPending, now,
+	).Scan(&expired)
+	if err != nil {
+		return nil, err
+	} else if !expired.Valid {
+		return stock, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	UPDATE
+		stock_reservation
+	SET
+		status = ?, updated_at = ?
+	WHERE
+		product_id = ? AND status = ? AND expires_at <= ?
+	`, model.ReservationExpired, now, productID, model.ReservationPending, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stock.Expire(int(expired.Int64))
+	stock.UpdatedAt = sql.NullTime{Time: now, Valid: true}
+	return stock, r.saveStock(ctx, tx, stock)
+}
+
+func (r *productRepository) saveStock(ctx context.Context, tx *sql.Tx, stock *model.Stock) error {
+	_, err := tx.ExecContext(ctx, `
+	UPDATE
+		product_stock
+	SET
+		on_hand = ?, reserved = ?, updated_at = ?
+	WHERE
+		product_id = ?
+	`, stock.OnHand, stock.Reserved, stock.UpdatedAt, stock.ProductID)
+	return err
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchedPrice(t *testing.T) {
+	current := model.Money{Amount: 1500000, Currency: "IDR"}
+	price := model.Money{Amount: 1250, Currency: "USD"}
+
+	assert.Equal(t, current, patchedPrice(current, price, []string{"name"}), "a rename keeps the price")
+	assert.Equal(t, current, patchedPrice(current, price, nil))
+	assert.Equal(t, model.Money{Amount: 1250, Currency: "IDR"}, patchedPrice(current, price, []string{"price"}))
+	assert.Equal(t, model.Money{Amount: 1500000, Currency: "USD"}, patchedPrice(current, price, []string{"currency"}))
+	assert.Equal(t, price, patchedPrice(current, price, []string{"name", "price", "currency"}))
+}
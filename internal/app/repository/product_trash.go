@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"strings"
+	"time"
+)
+
+var (
+	deletedProductSort        = []model.SortField{{Field: "deleted_at", Desc: true}}
+	deletedProductSortColumns = map[string]sortColumn{
+		"deleted_at": {"product.deleted_at", parseTimeKey},
+	}
+)
+
+// ListDeleted lists the merchant's soft-deleted products, the most recently deleted first.
+func (r *productRepository) ListDeleted(ctx context.Context, page model.Page, merchantID int64) ([]*model.Product, *model.PageInfo, error) {
+	keyset, err := newKeyset(page, deletedProductSort, deletedProductSortColumns, "product.id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products := []*model.Product{}
+	args := append(append([]interface{}{merchantID}, keyset.args...), keyset.limit(), keyset.offset())
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		product.id, product.name, product.price, product.currency, product.created_at, product.updated_at, product.deleted_at, product.version, product.merchant_id,
+		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at, merchant.deleted_at, merchant.version
+	FROM
+		product
+	INNER JOIN
+		merchant
+	ON
+		product.merchant_id = merchant.id
+	WHERE
+		product.merchant_id = ? AND product.deleted_at IS NOT NULL AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
+	LIMIT
+		? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		product := new(model.Product)
+		err := rows.Scan(
+			&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt, &product.Version, &product.MerchantID,
+			&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt, &product.Merchant.DeletedAt, &product.Merchant.Version,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		products = append(products, product)
+	}
+
+	info := keyset.result(len(products),
+		func(n int) { products = products[:n] },
+		func() { reverseProducts(products) },
+		func(i int) *model.Cursor {
+			return newCursor(deletedProductSort, []string{timeKey(products[i].DeletedAt.Time)}, products[i].ID)
+		},
+	)
+
+	return products, info, r.attachDetails(ctx, products)
+}
+
+func (r *productRepository) CountDeleted(ctx context.Context, merchantID int64) (int64, error) {
+	var count int64
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		product
+	WHERE
+		merchant_id = ? AND deleted_at IS NOT NULL
+	`, merchantID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetDeleted reads a soft-deleted product without caching it. The merchant is loaded even
+// when it is deleted too, its DeletedAt tells.
+func (r *productRepository) GetDeleted(ctx context.Context, id int64) (*model.Product, error) {
+	product := new(model.Product)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		product.id, product.name, product.price, product.currency, product.created_at, product.updated_at, product.deleted_at, product.version, product.merchant_id,
+		merchant.id, merchant.name, merchant.email, merchant.password, merchant.created_at, merchant.updated_at, merchant.deleted_at, merchant.version
+	FROM
+		product
+	INNER JOIN
+		merchant
+	ON
+		product.merchant_id = merchant.id
+	WHERE
+		product.id = ? AND product.deleted_at IS NOT NULL
+	`, id,
+	).Scan(
+		&product.ID, &product.Name, &product.Price.Amount, &product.Price.Currency, &product.CreatedAt, &product.UpdatedAt, &product.DeletedAt, &product.Version, &product.MerchantID,
+		&product.Merchant.ID, &product.Merchant.Name, &product.Merchant.Email, &product.Merchant.Password, &product.Merchant.CreatedAt, &product.Merchant.UpdatedAt, &product.Merchant.DeletedAt, &product.Merchant.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (r *productRepository) Restore(ctx context.Context, id int64) error {
+	res, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	UPDATE
+		product
+	SET
+		deleted_at = NULL, version = version + 1
+	WHERE
+		id = ? AND deleted_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return r.deleteProductCache(ctx, id)
+}
+
+// Purge hard-deletes products deleted before the given time, along with every product of a
+// merchant deleted before it or no longer existing. Images, stock, variants and category
+// links go with them through their foreign keys, price history is kept; the storage keys
+// of the images are returned so their objects can be removed as well.
+func (r *productRepository) Purge(ctx context.Context, before time.Time) (int64, []string, error) {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := purgeDueProductIDs(ctx, tx, before)
+	if err != nil || len(ids) == 0 {
+		return 0, nil, err
+	}
+
+	in := "(?" + strings.Repeat(", ?", len(ids)-1) + ")"
+	keys := []string{}
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		storage_key
+	FROM
+		product_image
+	WHERE
+		product_id IN `+in+`
+	`, ids...)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		err := rows.Scan(&key)
+		if err != nil {
+			return 0, nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	DELETE FROM
+		product
+	WHERE
+		id IN `+in+`
+	`, ids...)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	purged, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return purged, keys, tx.Commit()
+}
+
+// purgeDueProductIDs locks the deleted products and those of deleted or missing merchants,
+// and lists the ones model.Product.PurgeDue selects.
+func purgeDueProductIDs(ctx context.Context, tx *sql.Tx, before time.Time) ([]interface{}, error) {
+	ids := []interface{}{}
+	rows, err := tx.QueryContext(ctx, `
+	SELECT
+		product.id, product.deleted_at, COALESCE(merchant.id, 0), merchant.deleted_at
+	FROM
+		product
+	LEFT JOIN
+		merchant
+	ON
+		product.merchant_id = merchant.id
+	WHERE
+		product.deleted_at IS NOT NULL OR merchant.deleted_at IS NOT NULL OR merchant.id IS NULL
+	FOR UPDATE
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		product := new(model.Product)
+		err := rows.Scan(&product.ID, &product.DeletedAt, &product.Merchant.ID, &product.Merchant.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		if product.PurgeDue(before) {
+			ids = append(ids, product.ID)
+		}
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func (r *productRepository) ListOptions(ctx context.Context, productID int64) ([]*model.ProductOption, error) {
+	return r.listOptions(ctx, productID)
+}
+
+func (r *productRepository) ListVariants(ctx context.Context, productID int64) ([]*model.ProductVariant, error) {
+	return r.listVariants(ctx, productID)
+}
+
+func (r *productRepository) GetVariant(ctx context.Context, id int64) (*model.ProductVariant, error) {
+	return r.getVariant(ctx, "id = ?", id)
+}
+
+func (r *productRepository) GetVariantBySKU(ctx context.Context, sku string) (*model.ProductVariant, error) {
+	return r.getVariant(ctx, "sku = ?", sku)
+}
+
+// SetOptions replaces the options of a product and, in the same transaction, removes and
+// creates variants so every variant matches the new options.
+func (r *productRepository) SetOptions(ctx context.Context, productID int64, options []*model.ProductOption, remove, create []*model.ProductVariant) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// locking the product row serializes concurrent option changes
+	err = tx.QueryRowContext(ctx, `
+	SELECT
+		id
+	FROM
+		product
+	WHERE
+		id = ?
+	FOR UPDATE
+	`, productID,
+	).Scan(&productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM
+		product_option
+	WHERE
+		product_id = ?
+	`, productID)
+	if err != nil {
+		return err
+	}
+
+	for position, option := range options {
+		values, err := json.Marshal(option.Values)
+		if err != nil {
+			return err
+		}
+
+		option.ProductID, option.Position = productID, position
+		res, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			product_option (product_id, name, option_values, position)
+		VALUES
+			(?, ?, ?, ?)
+		`, option.ProductID, option.Name, values, option.Position)
+		if err != nil {
+			return err
+		}
+
+		option.ID, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, variant := range remove {
+		_, err = tx.ExecContext(ctx, `
+		DELETE FROM
+			product_variant
+		WHERE
+			id = ? AND product_id = ?
+		`, variant.ID, productID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, variant := range create {
+		err = insertVariant(ctx, tx, variant)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, productID)
+}
+
+func (r *productRepository) CreateVariant(ctx context.Context, variant *model.ProductVariant) error {
+	err := insertVariant(ctx, r.mysqlClient.Conn(), variant)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, variant.ProductID)
+}
+
+func (r *productRepository) UpdateVariant(ctx context.Context, variant *model.ProductVariant) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	UPDATE
+		product_variant
+	SET
+		sku = ?, price = ?, available = ?, updated_at = ?
+	WHERE
+		id = ?
+	`, variant.SKU, variant.PriceOverride, variant.Available, variant.UpdatedAt.Time, variant.ID)
+	if err != nil {
+		return variantConflict(err)
+	}
+
+	return r.deleteProductCache(ctx, variant.ProductID)
+}
+
+func (r *productRepository) DeleteVariant(ctx context.Context, variant *model.ProductVariant) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		product_variant
+	WHERE
+		id = ?
+	`, variant.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.deleteProductCache(ctx, variant.ProductID)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func insertVariant(ctx context.Context, db execer, variant *model.ProductVariant) error {
+	res, err := db.ExecContext(ctx, `
+	INSERT INTO
+		product_variant (product_id, sku, options, price, available, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?)
+	`, variant.ProductID, variant.SKU, variant.OptionsKey(), variant.PriceOverride, variant.Available, variant.CreatedAt)
+	if err != nil {
+		return variantConflict(err)
+	}
+
+	variant.ID, err = res.LastInsertId()
+	return err
+}
+
+// variantConflict turns a unique key violation into the matching conflict error, the
+// service checks SKUs and combinations up front but a concurrent request can still win.
+func variantConflict(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+		return err
+	}
+	if strings.Contains(mysqlErr.Message, "product_id_options") {
+		return constant.ErrVariantExists
+	}
+	return constant.ErrVariantSKUTaken
+}
+
+func (r *productRepository) getVariant(ctx context.Context, where string, arg interface{}) (*model.ProductVariant, error) {
+	variants, err := r.queryVariants(ctx, `
+	SELECT
+		id, product_id, sku, options, price, available, created_at, updated_at
+	FROM
+		product_variant
+	WHERE
+		`+where+`
+	`, arg)
+	if err != nil {
+		return nil, err
+	} else if len(variants) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return variants[0], nil
+}
+
+func (r *productRepository) listVariants(ctx context.Context, productIDs ...interface{}) ([]*model.ProductVariant, error) {
+	return r.queryVariants(ctx, `
+	SELECT
+		id, product_id, sku, options, price, available, created_at, updated_at
+	FROM
+		product_variant
+	WHERE
+		product_id IN (?`+strings.Repeat(", ?", len(productIDs)-1)+`)
+	ORDER BY
+		id
+	`, productIDs...)
+}
+
+func (r *productRepository) queryVariants(ctx context.Context, query string, args ...interface{}) ([]*model.ProductVariant, error) {
+	variants := []*model.ProductVariant{}
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var options []byte
+		variant := new(model.ProductVariant)
+		err := rows.Scan(
+			&variant.ID, &variant.ProductID, &variant.SKU, &options, &variant.PriceOverride,
+			&variant.Available, &variant.CreatedAt, &variant.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(options, &variant.Options)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+
+	return variants, rows.Err()
+}
+
+func (r *productRepository) listOptions(ctx context.Context, productIDs ...interface{}) ([]*model.ProductOption, error) {
+	options := []*model.ProductOption{}
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		id, product_id, name, option_values, position
+	FROM
+		product_option
+	WHERE
+		product_id IN (?`+strings.Repeat(", ?", len(productIDs)-1)+`)
+	ORDER BY
+		position
+	`, productIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var values []byte
+		option := new(model.ProductOption)
+		err := rows.Scan(&option.ID, &option.ProductID, &option.Name, &values, &option.Position)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(values, &option.Values)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return options, rows.Err()
+}
+
+func (r *productRepository) attachVariants(ctx context.Context, products []*model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Product, len(products))
+	ids := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		product.Options = []*model.ProductOption{}
+		product.Variants = []*model.ProductVariant{}
+		byID[product.ID] = product
+		ids = append(ids, product.ID)
+	}
+
+	options, err := r.listOptions(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, option := range options {
+		product := byID[option.ProductID]
+		product.Options = append(product.Options, option)
+	}
+
+	variants, err := r.listVariants(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		product := byID[variant.ProductID]
+		product.Variants = append(product.Variants, variant)
+	}
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"strings"
+)
+
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *model.Promotion) error
+	List(ctx context.Context, page model.Page, merchantID int64) ([]*model.Promotion, *model.PageInfo, error)
+	Count(ctx context.Context, merchantID int64) (int64, error)
+	Get(ctx context.Context, id int64) (*model.Promotion, error)
+	ListByCodes(ctx context.Context, codes []string, customerID int64) ([]*model.Promotion, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type promotionRepository struct {
+	mysqlClient mysql.Client
+}
+
+func NewPromotionRepository(mysqlClient mysql.Client) PromotionRepository {
+	return &promotionRepository{mysqlClient}
+}
+
+const promotionColumns = `id, merchant_id, code, type, value, buy_quantity, get_quantity, currency,
+		min_spend, usage_limit, per_customer_limit, starts_at, ends_at, created_at`
+
+func (r *promotionRepository) Create(ctx context.Context, promotion *model.Promotion) error {
+	tx, err := r.mysqlClient.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+	INSERT INTO
+		promotion (merchant_id, code, type, value, buy_quantity, get_quantity, currency,
+			min_spend, usage_limit, per_customer_limit, starts_at, ends_at, created_at)
+	VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, promotion.MerchantID, promotion.Code, promotion.Type, promotion.Value, promotion.BuyQuantity, promotion.GetQuantity,
+		promotion.Currency, promotion.MinSpend, promotion.UsageLimit, promotion.PerCustomerLimit,
+		promotion.StartsAt, promotion.EndsAt, promotion.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	promotion.ID, err = res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, productID := range promotion.ProductIDs {
+		_, err = tx.ExecContext(ctx, `
+		INSERT INTO
+			promotion_product (promotion_id, product_id)
+		VALUES
+			(?, ?)
+		`, promotion.ID, productID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+var (
+	promotionSort        = []model.SortField{{Field: "created_at", Desc: true}}
+	promotionSortColumns = map[string]sortColumn{
+		"created_at": {"created_at", parseTimeKey},
+	}
+)
+
+func (r *promotionRepository) List(ctx context.Context, page model.Page, merchantID int64) ([]*model.Promotion, *model.PageInfo, error) {
+	keyset, err := newKeyset(page, promotionSort, promotionSortColumns, "id")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := append(append([]interface{}{merchantID}, keyset.args...), keyset.limit(), keyset.offset())
+	promotions, err := r.queryPromotions(ctx, `
+	SELECT
+		`+promotionColumns+`
+	FROM
+		promotion
+	WHERE
+		merchant_id = ? AND `+keyset.where+`
+	ORDER BY
+		`+keyset.orderBy()+`
+	LIMIT
+		? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := keyset.result(len(promotions),
+		func(n int) { promotions = promotions[:n] },
+		func() { reversePromotions(promotions) },
+		func(i int) *model.Cursor {
+			return newCursor(promotionSort, []string{timeKey(promotions[i].CreatedAt)}, promotions[i].ID)
+		},
+	)
+
+	return promotions, info, r.attachDetails(ctx, promotions, 0)
+}
+
+func reversePromotions(promotions []*model.Promotion) {
+	for i, j := 0, len(promotions)-1; i < j; i, j = i+1, j-1 {
+		promotions[i], promotions[j] = promotions[j], promotions[i]
+	}
+}
+
+func (r *promotionRepository) Count(ctx context.Context, merchantID int64) (int64, error) {
+	var count int64
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		promotion
+	WHERE
+		merchant_id = ?
+	`, merchantID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *promotionRepository) Get(ctx context.Context, id int64) (*model.Promotion, error) {
+	promotion := new(model.Promotion)
+	err := r.mysqlClient.Conn().QueryRowContext(ctx, `
+	SELECT
+		`+promotionColumns+`
+	FROM
+		promotion
+	WHERE
+		id = ?
+	`, id,
+	).Scan(promotionFields(promotion)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotion, r.attachDetails(ctx, []*model.Promotion{promotion}, 0)
+}
+
+// ListByCodes loads the promotions with the given normalized codes, counting the
+// redemptions of customerID too when it is not zero.
+func (r *promotionRepository) ListByCodes(ctx context.Context, codes []string, customerID int64) ([]*model.Promotion, error) {
+	if len(codes) == 0 {
+		return []*model.Promotion{}, nil
+	}
+
+	args := make([]interface{}, len(codes))
+	for i, code := range codes {
+		args[i] = code
+	}
+
+	promotions, err := r.queryPromotions(ctx, `
+	SELECT
+		`+promotionColumns+`
+	FROM
+		promotion
+	WHERE
+		code IN (?`+strings.Repeat(", ?", len(codes)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, r.attachDetails(ctx, promotions, customerID)
+}
+
+func (r *promotionRepository) Delete(ctx context.Context, id int64) error {
+	_, err := r.mysqlClient.Conn().ExecContext(ctx, `
+	DELETE FROM
+		promotion
+	WHERE
+		id = ?
+	`, id)
+	return err
+}
+
+func promotionFields(promotion *model.Promotion) []interface{} {
+	return []interface{}{
+		&promotion.ID, &promotion.MerchantID, &promotion.Code, &promotion.Type, &promotion.Value,
+		&promotion.BuyQuantity, &promotion.GetQuantity, &promotion.Currency, &promotion.MinSpend,
+		&promotion.UsageLimit, &promotion.PerCustomerLimit, &promotion.StartsAt, &promotion.EndsAt, &promotion.CreatedAt,
+	}
+}
+
+func (r *promotionRepository) queryPromotions(ctx context.Context, query string, args ...interface{}) ([]*model.Promotion, error) {
+	promotions := []*model.Promotion{}
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		promotion := new(model.Promotion)
+		err := rows.Scan(promotionFields(promotion)...)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+// attachDetails loads the targeted products and redemption counts of promotions.
+func (r *promotionRepository) attachDetails(ctx context.Context, promotions []*model.Promotion, customerID int64) error {
+	if len(promotions) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*model.Promotion, len(promotions))
+	ids := make([]interface{}, 0, len(promotions))
+	for _, promotion := range promotions {
+		promotion.ProductIDs = []int64{}
+		byID[promotion.ID] = promotion
+		ids = append(ids, promotion.ID)
+	}
+	in := `(?` + strings.Repeat(", ?", len(ids)-1) + `)`
+
+	rows, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		promotion_id, product_id
+	FROM
+		promotion_product
+	WHERE
+		promotion_id IN `+in+`
+	ORDER BY
+		product_id
+	`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var promotionID, productID int64
+		err := rows.Scan(&promotionID, &productID)
+		if err != nil {
+			return err
+		}
+		byID[promotionID].ProductIDs = append(byID[promotionID].ProductIDs, productID)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	counts, err := r.mysqlClient.Conn().QueryContext(ctx, `
+	SELECT
+		promotion_id, COUNT(*), COALESCE(SUM(customer_id = ?), 0)
+	FROM
+		promotion_redemption
+	WHERE
+		promotion_id IN `+in+`
+	GROUP BY
+		promotion_id
+	`, append([]interface{}{customerID}, ids...)...)
+	if err != nil {
+		return err
+	}
+	defer counts.Close()
+
+	for counts.Next() {
+		var promotionID, total, customer int64
+		err := counts.Scan(&promotionID, &total, &customer)
+		if err != nil {
+			return err
+		}
+		byID[promotionID].Redemptions = total
+		byID[promotionID].CustomerRedemptions = customer
+	}
+
+	return counts.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) (bool, error)
+	RevokeFamily(ctx context.Context, family string, ttl time.Duration) error
+	IsFamilyRevoked(ctx context.Context, family string) (bool, error)
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+func NewTokenRepository(redisClient redis.Client) TokenRepository {
+	return &tokenRepository{redisClient}
+}
+
+// tokenRepository talks to redis directly instead of through Cache(), whose local
+// in-process layer would let other replicas keep accepting a revoked token.
+type tokenRepository struct {
+	redisClient redis.Client
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) error {
+	value, err := json.Marshal(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return r.redisClient.Conn().Set(ctx,
+		fmt.Sprintf("refresh_token_%s", refreshToken.Hash), value, time.Until(refreshToken.ExpiresAt),
+	).Err()
+}
+
+func (r *tokenRepository) GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	value, err := r.redisClient.Conn().Get(ctx, fmt.Sprintf("refresh_token_%s", hash)).Bytes()
+	if err == goredis.Nil {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	refreshToken := new(model.RefreshToken)
+	return refreshToken, json.Unmarshal(value, refreshToken)
+}
+
+func (r *tokenRepository) UseRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) (bool, error) {
+	return r.redisClient.Conn().SetNX(ctx,
+		fmt.Sprintf("refresh_token_used_%s", refreshToken.Hash), 1, time.Until(refreshToken.ExpiresAt),
+	).Result()
+}
+
+func (r *tokenRepository) RevokeFamily(ctx context.Context, family string, ttl time.Duration) error {
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("refresh_family_revoked_%s", family), 1, ttl).Err()
+}
+
+func (r *tokenRepository) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, fmt.Sprintf("refresh_family_revoked_%s", family)).Result()
+	return n > 0, err
+}
+
+func (r *tokenRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return r.redisClient.Conn().Set(ctx, fmt.Sprintf("access_token_revoked_%s", jti), 1, ttl).Err()
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := r.redisClient.Conn().Exists(ctx, fmt.Sprintf("access_token_revoked_%s", jti)).Result()
+	return n > 0, err
+}
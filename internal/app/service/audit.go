@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"time"
+)
+
+type AuditService interface {
+	Record(ctx context.Context, entry *model.AuditEntry)
+	List(ctx context.Context, req model.AuditListRequest) ([]*model.AuditEntryResponse, *model.PageInfo, error)
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) AuditService {
+	return &auditService{auditRepository}
+}
+
+// Record completes entry with the actor, request id and address found in ctx and appends
+// it. The operation it describes is already saved, so failures are only logged.
+func (s *auditService) Record(ctx context.Context, entry *model.AuditEntry) {
+	if claimsID, valid := middleware.GetClaimsID(ctx); valid {
+		entry.ActorID = sql.NullInt64{Int64: claimsID, Valid: true}
+		entry.ActorRole, _ = middleware.GetClaimsRole(ctx)
+	}
+	entry.RequestID = middleware.GetRequestID(ctx)
+	entry.IP = middleware.GetClientIP(ctx)
+	entry.CreatedAt = time.Now()
+
+	err := s.auditRepository.Create(ctx, entry)
+	if err != nil {
+		logger.Log().Err(err).
+			Str("action", entry.Action).
+			Str("entity_type", entry.EntityType).
+			Int64("entity_id", entry.EntityID).
+			Msg("failed to record audit entry")
+	}
+}
+
+func (s *auditService) List(ctx context.Context, req model.AuditListRequest) ([]*model.AuditEntryResponse, *model.PageInfo, error) {
+	if !middleware.IsMerchant(ctx, req.Filter.MerchantID) {
+		return nil, nil, constant.ErrUnauthorized
+	}
+
+	entries, info, err := s.auditRepository.List(ctx, req.Page, req.Filter)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to list audit entries")
+		return nil, nil, constant.ErrServer
+	}
+
+	total, err := s.auditRepository.Count(ctx, req.Filter)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count audit entries")
+		return nil, nil, constant.ErrServer
+	}
+	info.SetTotal(total)
+
+	return model.NewAuditEntryListResponse(entries), info, nil
+}
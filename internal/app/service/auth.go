@@ -5,23 +5,32 @@ import (
 	"database/sql"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
 	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
 	"github.com/SemmiDev/go-product/internal/security/token"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService interface {
 	Login(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error)
+	LoginCustomer(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error)
+	Refresh(ctx context.Context, req model.AuthRefreshRequest) (*model.AuthResponse, error)
+	Logout(ctx context.Context, req model.AuthLogoutRequest) error
 }
 
-func NewAuthService(accountRepository repository.MerchantRepository) AuthService {
-	return &authService{accountRepository}
+func NewAuthService(accountRepository repository.MerchantRepository, customerRepository repository.CustomerRepository, tokenRepository repository.TokenRepository, cartRepository repository.CartRepository) AuthService {
+	return &authService{accountRepository, customerRepository, tokenRepository, cartRepository}
 }
 
 type authService struct {
-	accountRepository repository.MerchantRepository
+	accountRepository  repository.MerchantRepository
+	customerRepository repository.CustomerRepository
+	tokenRepository    repository.TokenRepository
+	cartRepository     repository.CartRepository
 }
 
 func (s *authService) Login(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error) {
@@ -41,11 +50,173 @@ func (s *authService) Login(ctx context.Context, req model.AuthRequest) (*model.
 		return nil, constant.ErrWrongPassword
 	}
 
-	accessToken, err := token.GenerateToken(account)
+	return s.issueTokens(ctx, account, account.ID, constant.ROLE_MERCHANT, "")
+}
+
+func (s *authService) LoginCustomer(ctx context.Context, req model.AuthRequest) (*model.AuthResponse, error) {
+	customer, err := s.customerRepository.GetByEmail(ctx, req.Email)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, constant.ErrEmailNotRegistered
+		default:
+			logger.Log().Err(err).Msg("failed to get customer by email")
+			return nil, constant.ErrServer
+		}
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(customer.Password), []byte(req.Password))
+	if err != nil {
+		return nil, constant.ErrWrongPassword
+	}
+
+	// the anonymous cart is a convenience, failing to merge it must not fail the login
+	var left []*model.CartItem
+	if req.CartToken != "" {
+		left, err = s.cartRepository.Merge(ctx, model.CartOwner{Token: req.CartToken}, model.CartOwner{CustomerID: customer.ID})
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to merge anonymous cart")
+		}
+	}
+
+	res, err := s.issueTokens(ctx, customer, customer.ID, constant.ROLE_CUSTOMER, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range left {
+		res.UnmergedCartItems = append(res.UnmergedCartItems, item.ProductID)
+	}
+	return res, nil
+}
+
+func (s *authService) Refresh(ctx context.Context, req model.AuthRefreshRequest) (*model.AuthResponse, error) {
+	refreshToken, err := s.tokenRepository.GetRefreshToken(ctx, token.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		return nil, s.switchErrInvalidRefreshTokenOrErrServer(err)
+	}
+
+	revoked, err := s.tokenRepository.IsFamilyRevoked(ctx, refreshToken.Family)
+	if err != nil {
+		return nil, s.switchErrInvalidRefreshTokenOrErrServer(err)
+	} else if revoked {
+		return nil, constant.ErrInvalidRefreshToken
+	}
+
+	first, err := s.tokenRepository.UseRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, s.switchErrInvalidRefreshTokenOrErrServer(err)
+	} else if !first {
+		logger.Log().Warn().Str("family", refreshToken.Family).Msg("refresh token reused, revoking family")
+		err = s.tokenRepository.RevokeFamily(ctx, refreshToken.Family, config.Cfg().JwtRefreshTTL)
+		if err != nil {
+			return nil, s.switchErrInvalidRefreshTokenOrErrServer(err)
+		}
+		return nil, constant.ErrRefreshTokenReused
+	}
+
+	var subject token.Generator
+	switch refreshToken.Role {
+	case constant.ROLE_CUSTOMER:
+		subject, err = s.customerRepository.Get(ctx, refreshToken.SubjectID)
+	case constant.ROLE_MERCHANT:
+		subject, err = s.accountRepository.Get(ctx, refreshToken.SubjectID)
+	default:
+		return nil, constant.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, s.switchErrInvalidRefreshTokenOrErrServer(err)
+	}
+
+	return s.issueTokens(ctx, subject, refreshToken.SubjectID, refreshToken.Role, refreshToken.Family)
+}
+
+func (s *authService) Logout(ctx context.Context, req model.AuthLogoutRequest) error {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return constant.ErrUnauthorized
+	}
+
+	if req.RefreshToken != "" {
+		refreshToken, err := s.tokenRepository.GetRefreshToken(ctx, token.HashRefreshToken(req.RefreshToken))
+		if err != nil && err != sql.ErrNoRows {
+			logger.Log().Err(err).Msg("failed to get refresh token")
+			return constant.ErrServer
+		} else if err == nil {
+			claimsRole, _ := middleware.GetClaimsRole(ctx)
+			if refreshToken.SubjectID != claimsID || refreshToken.Role != claimsRole {
+				return constant.ErrUnauthorized
+			}
+
+			err = s.tokenRepository.RevokeFamily(ctx, refreshToken.Family, config.Cfg().JwtRefreshTTL)
+			if err != nil {
+				logger.Log().Err(err).Msg("failed to revoke refresh token family")
+				return constant.ErrServer
+			}
+		}
+	}
+
+	jti, valid := middleware.GetClaimsJTI(ctx)
+	if !valid {
+		return nil
+	}
+
+	expiresAt, _ := middleware.GetClaimsExpiresAt(ctx)
+	err := s.tokenRepository.RevokeAccessToken(ctx, jti, time.Until(expiresAt))
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to revoke access token")
+		return constant.ErrServer
+	}
+
+	return nil
+}
+
+func (s *authService) issueTokens(ctx context.Context, subject token.Generator, subjectID int64, role, family string) (*model.AuthResponse, error) {
+	accessToken, expiresAt, err := token.GenerateToken(subject)
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to generate token")
 		return nil, constant.ErrServer
 	}
 
-	return &model.AuthResponse{Token: accessToken}, nil
+	plainRefreshToken, err := token.GenerateRefreshToken()
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate refresh token")
+		return nil, constant.ErrServer
+	}
+
+	if family == "" {
+		family = token.HashRefreshToken(plainRefreshToken)
+	}
+
+	now := time.Now()
+	refreshToken := &model.RefreshToken{
+		Hash:      token.HashRefreshToken(plainRefreshToken),
+		Family:    family,
+		SubjectID: subjectID,
+		Role:      role,
+		ExpiresAt: now.Add(config.Cfg().JwtRefreshTTL),
+		CreatedAt: now,
+	}
+
+	err = s.tokenRepository.CreateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to store refresh token")
+		return nil, constant.ErrServer
+	}
+
+	return &model.AuthResponse{
+		Token:        accessToken,
+		ExpiresAt:    expiresAt,
+		RefreshToken: plainRefreshToken,
+	}, nil
+}
+
+func (s *authService) switchErrInvalidRefreshTokenOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrInvalidRefreshToken
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation token repository")
+		return constant.ErrServer
+	}
 }
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/token"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeTokenRepository keeps tokens in memory the way tokenRepository keeps them in redis.
+type fakeTokenRepository struct {
+	mu       sync.Mutex
+	tokens   map[string]model.RefreshToken
+	used     map[string]bool
+	families map[string]bool
+}
+
+func newFakeTokenRepository() *fakeTokenRepository {
+	return &fakeTokenRepository{
+		tokens:   map[string]model.RefreshToken{},
+		used:     map[string]bool{},
+		families: map[string]bool{},
+	}
+}
+
+func (r *fakeTokenRepository) CreateRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[refreshToken.Hash] = *refreshToken
+	return nil
+}
+
+func (r *fakeTokenRepository) GetRefreshToken(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refreshToken, found := r.tokens[hash]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	return &refreshToken, nil
+}
+
+func (r *fakeTokenRepository) UseRefreshToken(ctx context.Context, refreshToken *model.RefreshToken) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used[refreshToken.Hash] {
+		return false, nil
+	}
+	r.used[refreshToken.Hash] = true
+	return true, nil
+}
+
+func (r *fakeTokenRepository) RevokeFamily(ctx context.Context, family string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families[family] = true
+	return nil
+}
+
+func (r *fakeTokenRepository) IsFamilyRevoked(ctx context.Context, family string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.families[family], nil
+}
+
+func (r *fakeTokenRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return nil
+}
+
+func (r *fakeTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+type fakeMerchantRepository struct {
+	repository.MerchantRepository
+	merchant *model.Merchant
+}
+
+func (r *fakeMerchantRepository) Get(ctx context.Context, id int64) (*model.Merchant, error) {
+	if r.merchant == nil || r.merchant.ID != id {
+		return nil, sql.ErrNoRows
+	}
+	return r.merchant, nil
+}
+
+func newTestAuthService(tokens *fakeTokenRepository) *authService {
+	merchants := &fakeMerchantRepository{merchant: &model.Merchant{ID: 7, Name: "merchant", Email: "merchant@example.com"}}
+	return &authService{accountRepository: merchants, tokenRepository: tokens}
+}
+
+func TestAuthRefreshRotates(t *testing.T) {
+	ctx := context.Background()
+	tokens := newFakeTokenRepository()
+	s := newTestAuthService(tokens)
+
+	login, err := s.issueTokens(ctx, s.accountRepository.(*fakeMerchantRepository).merchant, 7, constant.ROLE_MERCHANT, "")
+	require.NoError(t, err)
+
+	refreshed, err := s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	assert.NotEmpty(t, refreshed.Token)
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken, "every refresh issues a new refresh token")
+
+	first, _ := tokens.GetRefreshToken(ctx, token.HashRefreshToken(login.RefreshToken))
+	next, _ := tokens.GetRefreshToken(ctx, token.HashRefreshToken(refreshed.RefreshToken))
+	assert.Equal(t, first.Family, next.Family, "rotated tokens stay in the family")
+	assert.Equal(t, int64(7), next.SubjectID)
+	assert.Equal(t, constant.ROLE_MERCHANT, next.Role)
+
+	_, err = s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: refreshed.RefreshToken})
+	assert.NoError(t, err)
+}
+
+func TestAuthRefreshReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	tokens := newFakeTokenRepository()
+	s := newTestAuthService(tokens)
+
+	login, err := s.issueTokens(ctx, s.accountRepository.(*fakeMerchantRepository).merchant, 7, constant.ROLE_MERCHANT, "")
+	require.NoError(t, err)
+	refreshed, err := s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+
+	_, err = s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, constant.ErrRefreshTokenReused, err)
+
+	_, err = s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: refreshed.RefreshToken})
+	assert.Equal(t, constant.ErrInvalidRefreshToken, err, "reuse revokes the tokens issued after it too")
+
+	other, err := s.issueTokens(ctx, s.accountRepository.(*fakeMerchantRepository).merchant, 7, constant.ROLE_MERCHANT, "")
+	require.NoError(t, err)
+	_, err = s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: other.RefreshToken})
+	assert.NoError(t, err, "other families stay valid")
+}
+
+func TestAuthRefreshUnknownToken(t *testing.T) {
+	s := newTestAuthService(newFakeTokenRepository())
+
+	_, err := s.Refresh(context.Background(), model.AuthRefreshRequest{RefreshToken: "unknown"})
+	assert.Equal(t, constant.ErrInvalidRefreshToken, err)
+}
+
+type fakeCustomerRepository struct {
+	repository.CustomerRepository
+	customer *model.Customer
+}
+
+func (r *fakeCustomerRepository) Get(ctx context.Context, id int64) (*model.Customer, error) {
+	if r.customer == nil || r.customer.ID != id {
+		return nil, sql.ErrNoRows
+	}
+	return r.customer, nil
+}
+
+func (r *fakeCustomerRepository) GetByEmail(ctx context.Context, email string) (*model.Customer, error) {
+	if r.customer == nil || r.customer.Email != email {
+		return nil, sql.ErrNoRows
+	}
+	return r.customer, nil
+}
+
+func TestAuthLoginCustomer(t *testing.T) {
+	ctx := context.Background()
+	password, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	tokens := newFakeTokenRepository()
+	s := newTestAuthService(tokens)
+	s.customerRepository = &fakeCustomerRepository{customer: &model.Customer{ID: 7, Email: "customer@example.com", Password: string(password)}}
+
+	_, err = s.LoginCustomer(ctx, model.AuthRequest{Email: "customer@example.com", Password: "wrong"})
+	assert.Equal(t, constant.ErrWrongPassword, err)
+	_, err = s.LoginCustomer(ctx, model.AuthRequest{Email: "merchant@example.com", Password: "secret123"})
+	assert.Equal(t, constant.ErrEmailNotRegistered, err, "merchants are not customers")
+
+	login, err := s.LoginCustomer(ctx, model.AuthRequest{Email: "customer@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	parsed, err := token.ParseToken(login.Token)
+	require.NoError(t, err)
+	assert.Equal(t, constant.ROLE_CUSTOMER, parsed.Claims.(jwt.MapClaims)["role"])
+
+	refreshed, err := s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	parsed, err = token.ParseToken(refreshed.Token)
+	require.NoError(t, err)
+	assert.Equal(t, constant.ROLE_CUSTOMER, parsed.Claims.(jwt.MapClaims)["role"], "refresh keeps the role")
+	next, _ := tokens.GetRefreshToken(ctx, token.HashRefreshToken(refreshed.RefreshToken))
+	assert.Equal(t, constant.ROLE_CUSTOMER, next.Role)
+}
+
+func TestAuthRefreshUnknownRole(t *testing.T) {
+	ctx := context.Background()
+	tokens := newFakeTokenRepository()
+	s := newTestAuthService(tokens)
+
+	login, err := s.issueTokens(ctx, s.accountRepository.(*fakeMerchantRepository).merchant, 7, "", "")
+	require.NoError(t, err)
+
+	_, err = s.Refresh(ctx, model.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, constant.ErrInvalidRefreshToken, err, "a token without a role refreshes as nobody")
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/security/token"
+	"time"
+)
+
+type CartService interface {
+	Get(ctx context.Context, req model.CartGetRequest) (*model.CartResponse, error)
+	AddItem(ctx context.Context, req model.CartItemAddRequest) (*model.CartResponse, error)
+	UpdateItem(ctx context.Context, req model.CartItemUpdateRequest) (*model.CartResponse, error)
+	RemoveItem(ctx context.Context, req model.CartItemRemoveRequest) (*model.CartResponse, error)
+	Clear(ctx context.Context, req model.CartClearRequest) error
+}
+
+type cartService struct {
+	cartRepository    repository.CartRepository
+	productRepository repository.ProductRepository
+}
+
+func NewCartService(cartRepository repository.CartRepository, productRepository repository.ProductRepository) CartService {
+	return &cartService{cartRepository, productRepository}
+}
+
+func (s *cartService) Get(ctx context.Context, req model.CartGetRequest) (*model.CartResponse, error) {
+	owner, err := s.owner(ctx, req.Owner, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(ctx, owner)
+}
+
+// AddItem adds quantity to the product's line, creating the anonymous cart when the caller
+// has none yet. The line takes the current price.
+func (s *cartService) AddItem(ctx context.Context, req model.CartItemAddRequest) (*model.CartResponse, error) {
+	owner, err := s.owner(ctx, req.Owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.getProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &model.CartItem{ProductID: product.ID, Quantity: req.Quantity, Price: product.EffectivePrice(), AddedAt: time.Now()}
+	err = s.cartRepository.Add(ctx, owner, item)
+	if err == constant.ErrCartFull {
+		return nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to add cart item")
+		return nil, constant.ErrServer
+	}
+
+	return s.get(ctx, owner)
+}
+
+// UpdateItem sets the quantity of a line already in the cart, which takes the current price.
+func (s *cartService) UpdateItem(ctx context.Context, req model.CartItemUpdateRequest) (*model.CartResponse, error) {
+	owner, err := s.owner(ctx, req.Owner, false)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.cartRepository.Get(ctx, owner, req.ProductID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get cart item")
+		return nil, constant.ErrServer
+	} else if item == nil {
+		return nil, constant.ErrCartItemNotFound
+	}
+
+	product, err := s.getProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Quantity = req.Quantity
+	item.Price = product.EffectivePrice()
+
+	return s.save(ctx, owner, item)
+}
+
+func (s *cartService) RemoveItem(ctx context.Context, req model.CartItemRemoveRequest) (*model.CartResponse, error) {
+	owner, err := s.owner(ctx, req.Owner, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if owner.CustomerID != 0 || owner.Token != "" {
+		err = s.cartRepository.Remove(ctx, owner, req.ProductID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to remove cart item")
+			return nil, constant.ErrServer
+		}
+	}
+
+	return s.get(ctx, owner)
+}
+
+func (s *cartService) Clear(ctx context.Context, req model.CartClearRequest) error {
+	owner, err := s.owner(ctx, req.Owner, false)
+	if err != nil {
+		return err
+	}
+
+	if owner.CustomerID != 0 || owner.Token != "" {
+		err = s.cartRepository.Clear(ctx, owner)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to clear cart")
+			return constant.ErrServer
+		}
+	}
+
+	return nil
+}
+
+// owner picks the signed-in customer's cart over the anonymous one, which gets a new token
+// when create is set and the caller has none. Merchants have no cart.
+func (s *cartService) owner(ctx context.Context, owner model.CartOwner, create bool) (model.CartOwner, error) {
+	if claimsID, valid := middleware.GetClaimsID(ctx); valid {
+		if !middleware.HasRole(ctx, constant.ROLE_CUSTOMER) {
+			return model.CartOwner{}, constant.ErrForbiddenRole
+		}
+		return model.CartOwner{CustomerID: claimsID}, nil
+	}
+
+	if owner.Token == "" && create {
+		var err error
+		owner.Token, err = token.GenerateCartToken()
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to generate cart token")
+			return model.CartOwner{}, constant.ErrServer
+		}
+	}
+
+	return model.CartOwner{Token: owner.Token}, nil
+}
+
+func (s *cartService) getProduct(ctx context.Context, id int64) (*model.Product, error) {
+	product, err := s.productRepository.Get(ctx, id)
+	switch err {
+	case nil:
+		return product, nil
+	case sql.ErrNoRows:
+		return nil, constant.ErrProductNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to get cart product")
+		return nil, constant.ErrServer
+	}
+}
+
+func (s *cartService) save(ctx context.Context, owner model.CartOwner, item *model.CartItem) (*model.CartResponse, error) {
+	err := s.cartRepository.Save(ctx, owner, item)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to save cart item")
+		return nil, constant.ErrServer
+	}
+
+	return s.get(ctx, owner)
+}
+
+// get reprices the cart against the products as they are now.
+func (s *cartService) get(ctx context.Context, owner model.CartOwner) (*model.CartResponse, error) {
+	if owner.CustomerID == 0 && owner.Token == "" {
+		return model.NewCartResponse("", []*model.CartItem{}, nil), nil
+	}
+
+	items, err := s.cartRepository.List(ctx, owner)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list cart items")
+		return nil, constant.ErrServer
+	}
+
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+
+	products, err := s.productRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list cart products")
+		return nil, constant.ErrServer
+	}
+
+	byID := make(map[int64]*model.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	return model.NewCartResponse(owner.Token, items, byID), nil
+}
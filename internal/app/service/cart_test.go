@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeCartRepository keeps carts in memory the way cartRepository keeps them in redis.
+type fakeCartRepository struct {
+	repository.CartRepository
+	carts map[string]map[int64]*model.CartItem
+}
+
+func newFakeCartRepository() *fakeCartRepository {
+	return &fakeCartRepository{carts: map[string]map[int64]*model.CartItem{}}
+}
+
+func (r *fakeCartRepository) fill(owner model.CartOwner, ids ...int64) {
+	if r.carts[owner.Key()] == nil {
+		r.carts[owner.Key()] = map[int64]*model.CartItem{}
+	}
+	for _, id := range ids {
+		r.carts[owner.Key()][id] = &model.CartItem{ProductID: id, Quantity: 1}
+	}
+}
+
+func (r *fakeCartRepository) List(ctx context.Context, owner model.CartOwner) ([]*model.CartItem, error) {
+	items := []*model.CartItem{}
+	for _, item := range r.carts[owner.Key()] {
+		items = append(items, item)
+	}
+	model.SortCartItems(items)
+	return items, nil
+}
+
+func (r *fakeCartRepository) Add(ctx context.Context, owner model.CartOwner, item *model.CartItem) error {
+	cart := r.carts[owner.Key()]
+	if existing, found := cart[item.ProductID]; found {
+		item.Quantity += existing.Quantity
+		item.AddedAt = existing.AddedAt
+	} else if len(cart) >= model.MaxCartItems {
+		return constant.ErrCartFull
+	}
+	r.fill(owner)
+	line := *item
+	r.carts[owner.Key()][item.ProductID] = &line
+	return nil
+}
+
+func (r *fakeCartRepository) Merge(ctx context.Context, from, to model.CartOwner) ([]*model.CartItem, error) {
+	anonymous, _ := r.List(ctx, from)
+	account, _ := r.List(ctx, to)
+	merged, left := model.MergeCartItems(account, anonymous)
+
+	r.fill(to)
+	for _, item := range merged {
+		r.carts[to.Key()][item.ProductID] = item
+	}
+	r.carts[from.Key()] = map[int64]*model.CartItem{}
+	for _, item := range left {
+		r.carts[from.Key()][item.ProductID] = item
+	}
+	return left, nil
+}
+
+type fakeCartProductRepository struct {
+	repository.ProductRepository
+}
+
+func (r *fakeCartProductRepository) Get(ctx context.Context, id int64) (*model.Product, error) {
+	if id <= 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &model.Product{ID: id, Name: "product", Price: model.Money{Amount: 1000, Currency: "IDR"}}, nil
+}
+
+func (r *fakeCartProductRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.Product, error) {
+	products := make([]*model.Product, len(ids))
+	for i, id := range ids {
+		products[i], _ = r.Get(ctx, id)
+	}
+	return products, nil
+}
+
+func TestCartAddItemFull(t *testing.T) {
+	ctx := middleware.WithClaims(context.Background(), 3, constant.ROLE_CUSTOMER)
+	owner := model.CartOwner{CustomerID: 3}
+	carts := newFakeCartRepository()
+	for id := int64(1); id <= model.MaxCartItems; id++ {
+		carts.fill(owner, id)
+	}
+	s := NewCartService(carts, &fakeCartProductRepository{})
+
+	_, err := s.AddItem(ctx, model.CartItemAddRequest{ProductID: 1001, Quantity: 1})
+	assert.Equal(t, constant.ErrCartFull, err)
+	assert.Len(t, carts.carts[owner.Key()], model.MaxCartItems)
+
+	res, err := s.AddItem(ctx, model.CartItemAddRequest{ProductID: 1, Quantity: 2})
+	require.NoError(t, err, "a product already in a full cart can still be added")
+	assert.Equal(t, 3, res.Items[0].Quantity)
+}
+
+func TestLoginCustomerCartOverflow(t *testing.T) {
+	password, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	account := model.CartOwner{CustomerID: 7}
+	anonymous := model.CartOwner{Token: "abc"}
+	carts := newFakeCartRepository()
+	for id := int64(1); id < model.MaxCartItems; id++ {
+		carts.fill(account, id)
+	}
+	carts.fill(anonymous, 1, 1001, 1002, 1003)
+
+	s := newTestAuthService(newFakeTokenRepository())
+	s.customerRepository = &fakeCustomerRepository{customer: &model.Customer{ID: 7, Email: "customer@example.com", Password: string(password)}}
+	s.cartRepository = carts
+
+	res, err := s.LoginCustomer(context.Background(), model.AuthRequest{Email: "customer@example.com", Password: "secret123", CartToken: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1002, 1003}, res.UnmergedCartItems)
+	assert.Len(t, carts.carts[account.Key()], model.MaxCartItems)
+	assert.Equal(t, 2, carts.carts[account.Key()][1].Quantity)
+	assert.Len(t, carts.carts[anonymous.Key()], 2, "what did not fit stays in the anonymous cart")
+}
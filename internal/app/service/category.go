@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"time"
+)
+
+type CategoryService interface {
+	Create(ctx context.Context, req model.CategoryCreateRequest) (*model.CategoryResponse, error)
+	List(ctx context.Context) ([]*model.CategoryResponse, error)
+	Get(ctx context.Context, req model.CategoryGetRequest) (*model.CategoryResponse, error)
+	Update(ctx context.Context, req model.CategoryUpdateRequest) (*model.CategoryResponse, error)
+	Delete(ctx context.Context, req model.CategoryDeleteRequest) error
+
+	ListProductCategories(ctx context.Context, req model.ProductCategoriesGetRequest) ([]*model.CategoryResponse, error)
+	SetProductCategories(ctx context.Context, req model.ProductCategoriesUpdateRequest) ([]*model.CategoryResponse, error)
+}
+
+type categoryService struct {
+	categoryRepository repository.CategoryRepository
+	productRepository  repository.ProductRepository
+}
+
+func NewCategoryService(categoryRepository repository.CategoryRepository, productRepository repository.ProductRepository) CategoryService {
+	return &categoryService{categoryRepository, productRepository}
+}
+
+func (s *categoryService) Create(ctx context.Context, req model.CategoryCreateRequest) (*model.CategoryResponse, error) {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	if req.Slug == "" {
+		req.Slug = model.Slugify(req.Name)
+	}
+
+	category := &model.Category{
+		MerchantID: sql.NullInt64{Int64: claimsID, Valid: true},
+		Name:       req.Name,
+		Slug:       req.Slug,
+		Position:   req.Position,
+		CreatedAt:  time.Now(),
+	}
+	if req.ParentID != nil {
+		category.ParentID = sql.NullInt64{Int64: *req.ParentID, Valid: true}
+	}
+
+	err := s.validate(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.categoryRepository.Create(ctx, category)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create category")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewCategoryResponse(category), nil
+}
+
+func (s *categoryService) List(ctx context.Context) ([]*model.CategoryResponse, error) {
+	categories, err := s.categoryRepository.List(ctx)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list categories")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewCategoryTreeResponse(categories), nil
+}
+
+func (s *categoryService) Get(ctx context.Context, req model.CategoryGetRequest) (*model.CategoryResponse, error) {
+	category, err := s.categoryRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrCategoryNotFoundOrErrServer(err)
+	}
+
+	return model.NewCategoryResponse(category), nil
+}
+
+func (s *categoryService) Update(ctx context.Context, req model.CategoryUpdateRequest) (*model.CategoryResponse, error) {
+	category, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	category.Name = req.Name
+	category.Slug = req.Slug
+	category.Position = req.Position
+	category.ParentID = sql.NullInt64{}
+	if req.ParentID != nil {
+		category.ParentID = sql.NullInt64{Int64: *req.ParentID, Valid: true}
+	}
+	category.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	err = s.validate(ctx, category)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.categoryRepository.Update(ctx, category)
+	if err != nil {
+		return nil, s.switchErrCategoryNotFoundOrErrServer(err)
+	}
+
+	return model.NewCategoryResponse(category), nil
+}
+
+func (s *categoryService) Delete(ctx context.Context, req model.CategoryDeleteRequest) error {
+	category, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	ids, err := s.categoryRepository.ListDescendantIDs(ctx, req.ID)
+	if err != nil {
+		return s.switchErrCategoryNotFoundOrErrServer(err)
+	}
+
+	switch {
+	case len(ids) == 0:
+		return constant.ErrCategoryNotFound
+	case len(ids) > 1:
+		return constant.ErrCategoryHasChildren
+	}
+
+	err = s.categoryRepository.Delete(ctx, req.ID, category.MerchantID.Int64)
+	if err != nil {
+		return s.switchErrCategoryNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+func (s *categoryService) ListProductCategories(ctx context.Context, req model.ProductCategoriesGetRequest) ([]*model.CategoryResponse, error) {
+	_, err := s.productRepository.Get(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	categories, err := s.categoryRepository.ListByProduct(ctx, req.ProductID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list product categories")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewCategoryListResponse(categories), nil
+}
+
+func (s *categoryService) SetProductCategories(ctx context.Context, req model.ProductCategoriesUpdateRequest) ([]*model.CategoryResponse, error) {
+	product, err := s.productRepository.Get(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	categories, err := s.categoryRepository.ListByIDs(ctx, req.CategoryIDs)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list categories by id")
+		return nil, constant.ErrServer
+	}
+
+	found := make(map[int64]bool, len(categories))
+	for _, category := range categories {
+		found[category.ID] = true
+	}
+	for _, id := range req.CategoryIDs {
+		if !found[id] {
+			return nil, constant.ErrCategoryNotFound
+		}
+	}
+
+	err = s.categoryRepository.SetProductCategories(ctx, req.ProductID, req.CategoryIDs)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to set product categories")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewCategoryListResponse(categories), nil
+}
+
+// authorize gets the category for a change by the merchant who owns it, categories without
+// an owner cannot be changed.
+func (s *categoryService) authorize(ctx context.Context, id int64) (*model.Category, error) {
+	category, err := s.categoryRepository.Get(ctx, id)
+	if err != nil {
+		return nil, s.switchErrCategoryNotFoundOrErrServer(err)
+	}
+
+	if !category.MerchantID.Valid || !middleware.IsMerchant(ctx, category.MerchantID.Int64) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) validate(ctx context.Context, category *model.Category) error {
+	if !model.IsSlug(category.Slug) {
+		return constant.ErrCategoryInvalidSlug
+	}
+
+	existing, err := s.categoryRepository.GetBySlug(ctx, category.Slug)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get category by slug")
+		return constant.ErrServer
+	} else if err == nil && existing.ID != category.ID {
+		return constant.ErrCategorySlugTaken
+	}
+
+	if !category.ParentID.Valid {
+		return nil
+	}
+
+	_, err = s.categoryRepository.Get(ctx, category.ParentID.Int64)
+	if err == sql.ErrNoRows {
+		return constant.ErrCategoryParentNotFound
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to get parent category")
+		return constant.ErrServer
+	}
+
+	if category.ID == 0 {
+		return nil
+	}
+
+	descendantIDs, err := s.categoryRepository.ListDescendantIDs(ctx, category.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list category descendants")
+		return constant.ErrServer
+	}
+	for _, id := range descendantIDs {
+		if id == category.ParentID.Int64 {
+			return constant.ErrCategoryCycle
+		}
+	}
+
+	return nil
+}
+
+func (s *categoryService) switchErrCategoryNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrCategoryNotFound
+	case constant.ErrCategoryInUse:
+		return err
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation category repository")
+		return constant.ErrServer
+	}
+}
+
+func (s *categoryService) switchErrProductNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrProductNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation product repository")
+		return constant.ErrServer
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCategoryRepository struct {
+	repository.CategoryRepository
+	categories map[int64]*model.Category
+	// usedByOthers lists the categories products of another merchant are filed under.
+	usedByOthers map[int64]bool
+	deleted      []int64
+}
+
+func (r *fakeCategoryRepository) Create(ctx context.Context, category *model.Category) error {
+	category.ID = int64(len(r.categories) + 1)
+	r.categories[category.ID] = category
+	return nil
+}
+
+func (r *fakeCategoryRepository) Get(ctx context.Context, id int64) (*model.Category, error) {
+	category, found := r.categories[id]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	copied := *category
+	return &copied, nil
+}
+
+func (r *fakeCategoryRepository) GetBySlug(ctx context.Context, slug string) (*model.Category, error) {
+	for _, category := range r.categories {
+		if category.Slug == slug {
+			return category, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakeCategoryRepository) ListDescendantIDs(ctx context.Context, id int64) ([]int64, error) {
+	ids := []int64{id}
+	for _, category := range r.categories {
+		if category.ParentID.Valid && category.ParentID.Int64 == id {
+			ids = append(ids, category.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeCategoryRepository) Update(ctx context.Context, category *model.Category) error {
+	r.categories[category.ID] = category
+	return nil
+}
+
+func (r *fakeCategoryRepository) Delete(ctx context.Context, id, merchantID int64) error {
+	if r.usedByOthers[id] {
+		return constant.ErrCategoryInUse
+	}
+	delete(r.categories, id)
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func merchantContext(id int64) context.Context {
+	return middleware.WithClaims(context.Background(), id, constant.ROLE_MERCHANT)
+}
+
+func TestCategoryOwnership(t *testing.T) {
+	categories := &fakeCategoryRepository{
+		categories: map[int64]*model.Category{
+			1: {ID: 1, Name: "Legacy", Slug: "legacy"},
+		},
+		usedByOthers: map[int64]bool{},
+	}
+	s := NewCategoryService(categories, nil)
+
+	created, err := s.Create(merchantContext(7), model.CategoryCreateRequest{Name: "Shoes"})
+	require.NoError(t, err)
+	require.NotNil(t, created.MerchantID)
+	assert.Equal(t, int64(7), *created.MerchantID)
+
+	update := model.CategoryUpdateRequest{ID: created.ID, Name: "Sneakers", Slug: "sneakers"}
+	_, err = s.Update(merchantContext(8), update)
+	assert.Equal(t, constant.ErrUnauthorized, err, "only the owner renames a category")
+	_, err = s.Update(middleware.WithClaims(context.Background(), 7, constant.ROLE_CUSTOMER), update)
+	assert.Equal(t, constant.ErrUnauthorized, err)
+
+	updated, err := s.Update(merchantContext(7), update)
+	require.NoError(t, err)
+	assert.Equal(t, "Sneakers", updated.Name)
+	assert.Equal(t, int64(7), *updated.MerchantID)
+
+	_, err = s.Update(merchantContext(7), model.CategoryUpdateRequest{ID: 1, Name: "Mine", Slug: "mine"})
+	assert.Equal(t, constant.ErrUnauthorized, err, "categories without an owner stay as they are")
+
+	assert.Equal(t, constant.ErrUnauthorized, s.Delete(merchantContext(8), model.CategoryDeleteRequest{ID: created.ID}))
+	assert.Equal(t, constant.ErrUnauthorized, s.Delete(merchantContext(7), model.CategoryDeleteRequest{ID: 1}))
+	assert.Empty(t, categories.deleted)
+}
+
+func TestCategoryDeleteInUse(t *testing.T) {
+	categories := &fakeCategoryRepository{
+		categories: map[int64]*model.Category{
+			1: {ID: 1, MerchantID: sql.NullInt64{Int64: 7, Valid: true}, Name: "Shoes", Slug: "shoes"},
+			2: {ID: 2, MerchantID: sql.NullInt64{Int64: 7, Valid: true}, Name: "Hats", Slug: "hats"},
+		},
+		usedByOthers: map[int64]bool{1: true},
+	}
+	s := NewCategoryService(categories, nil)
+
+	assert.Equal(t, constant.ErrCategoryInUse, s.Delete(merchantContext(7), model.CategoryDeleteRequest{ID: 1}))
+	assert.NoError(t, s.Delete(merchantContext(7), model.CategoryDeleteRequest{ID: 2}))
+	assert.Equal(t, []int64{2}, categories.deleted)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"golang.org/x/crypto/bcrypt"
+	"time"
+)
+
+type CustomerService interface {
+	Create(ctx context.Context, req model.CustomerCreateRequest) (*model.CustomerResponse, error)
+	Get(ctx context.Context, req model.CustomerGetRequest) (*model.CustomerResponse, error)
+	Update(ctx context.Context, req model.CustomerUpdateRequest) (*model.CustomerResponse, error)
+}
+
+func NewCustomerService(customerRepository repository.CustomerRepository) CustomerService {
+	return &customerService{customerRepository}
+}
+
+type customerService struct {
+	customerRepository repository.CustomerRepository
+}
+
+func (s *customerService) Create(ctx context.Context, req model.CustomerCreateRequest) (*model.CustomerResponse, error) {
+	_, err := s.customerRepository.GetByEmail(ctx, req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get customer by email")
+		return nil, constant.ErrServer
+	} else if err == nil {
+		return nil, constant.ErrEmailRegistered
+	}
+
+	password, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate from password")
+		return nil, constant.ErrServer
+	}
+
+	customer := &model.Customer{
+		Name:      req.Name,
+		Email:     req.Email,
+		Password:  string(password),
+		CreatedAt: time.Now(),
+	}
+
+	err = s.customerRepository.Create(ctx, customer)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create customer")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewCustomerResponse(customer), nil
+}
+
+func (s *customerService) Get(ctx context.Context, req model.CustomerGetRequest) (*model.CustomerResponse, error) {
+	if !middleware.IsCustomer(ctx, req.ID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	customer, err := s.customerRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrCustomerNotFoundOrErrServer(err)
+	}
+
+	return model.NewCustomerResponse(customer), nil
+}
+
+func (s *customerService) Update(ctx context.Context, req model.CustomerUpdateRequest) (*model.CustomerResponse, error) {
+	if !middleware.IsCustomer(ctx, req.ID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	customer, err := s.customerRepository.GetByEmail(ctx, req.Email)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get customer by email")
+		return nil, constant.ErrServer
+	} else if err == nil && customer.ID != req.ID {
+		return nil, constant.ErrEmailRegistered
+	}
+
+	customer, err = s.customerRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrCustomerNotFoundOrErrServer(err)
+	}
+
+	customer.Name = req.Name
+	customer.Email = req.Email
+	customer.UpdatedAt.Time = time.Now()
+
+	err = s.customerRepository.Update(ctx, customer)
+	if err != nil {
+		return nil, s.switchErrCustomerNotFoundOrErrServer(err)
+	}
+
+	return model.NewCustomerResponse(customer), nil
+}
+
+func (s *customerService) switchErrCustomerNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrCustomerNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation customer repository")
+		return constant.ErrServer
+	}
+}
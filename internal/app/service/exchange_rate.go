@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/exchange"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"time"
+)
+
+type ExchangeRateService interface {
+	Rates(ctx context.Context) (*exchange.Rates, error)
+}
+
+func NewExchangeRateService(exchangeRateRepository repository.ExchangeRateRepository, provider exchange.Provider) ExchangeRateService {
+	return &exchangeRateService{exchangeRateRepository, provider}
+}
+
+type exchangeRateService struct {
+	exchangeRateRepository repository.ExchangeRateRepository
+	provider               exchange.Provider
+}
+
+// Rates serves the cached rates until they were fetched ExchangeRateRefresh ago and then
+// asks the provider. If the provider fails the cached rates keep being served, but never
+// rates whose own timestamp is more than ExchangeRateMaxAge old.
+func (s *exchangeRateService) Rates(ctx context.Context) (*exchange.Rates, error) {
+	now := time.Now()
+
+	cached, err := s.exchangeRateRepository.Get(ctx)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get cached exchange rates")
+	}
+	if cached != nil && now.Sub(cached.FetchedAt) < config.Cfg().ExchangeRateRefresh && !isStale(&cached.Rates, now) {
+		return &cached.Rates, nil
+	}
+
+	rates, err := s.provider.Rates(ctx)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to fetch exchange rates")
+		if cached != nil && !isStale(&cached.Rates, now) {
+			return &cached.Rates, nil
+		}
+		return nil, constant.ErrExchangeRateUnavailable
+	}
+
+	if isStale(rates, now) {
+		logger.Log().Warn().Time("timestamp", rates.Timestamp).Msg("exchange rates are too stale to serve")
+		return nil, constant.ErrExchangeRateUnavailable
+	}
+
+	err = s.exchangeRateRepository.Set(ctx, &model.ExchangeRates{Rates: *rates, FetchedAt: now})
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to cache exchange rates")
+	}
+
+	return rates, nil
+}
+
+func isStale(rates *exchange.Rates, now time.Time) bool {
+	return now.Sub(rates.Timestamp) > config.Cfg().ExchangeRateMaxAge
+}
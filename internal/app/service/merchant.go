@@ -3,38 +3,46 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/repository"
 	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/logger"
 	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/validation"
 	"golang.org/x/crypto/bcrypt"
 	"time"
 )
 
 type MerchantService interface {
 	Create(ctx context.Context, req model.MerchantCreateRequest) (*model.MerchantResponse, error)
-	List(ctx context.Context, req model.MerchantListRequest) ([]*model.MerchantResponse, error)
+	List(ctx context.Context, req model.MerchantListRequest) ([]*model.MerchantResponse, *model.PageInfo, error)
 	Get(ctx context.Context, req model.MerchantGetRequest) (*model.MerchantResponse, error)
 	Update(ctx context.Context, req model.MerchantUpdateRequest) (*model.MerchantResponse, error)
+	Patch(ctx context.Context, req model.MerchantPatchRequest) (*model.MerchantResponse, error)
 	UpdatePassword(ctx context.Context, req model.MerchantPasswordUpdateRequest) (*model.MerchantResponse, error)
 	Delete(ctx context.Context, req model.MerchantDeleteRequest) error
+	Restore(ctx context.Context, req model.MerchantRestoreRequest) (*model.MerchantResponse, error)
+	Trash(ctx context.Context, req model.MerchantTrashRequest) ([]*model.ProductResponse, *model.PageInfo, error)
 }
 
-func NewMerchantService(accountRepository repository.MerchantRepository) MerchantService {
-	return &accountService{accountRepository}
+func NewMerchantService(accountRepository repository.MerchantRepository, productRepository repository.ProductRepository, productSearcher repository.ProductSearcher, auditService AuditService) MerchantService {
+	return &accountService{accountRepository, productRepository, productSearcher, auditService}
 }
 
 type accountService struct {
 	accountRepository repository.MerchantRepository
+	productRepository repository.ProductRepository
+	productSearcher   repository.ProductSearcher
+	auditService      AuditService
 }
 
 func (s *accountService) Create(ctx context.Context, req model.MerchantCreateRequest) (*model.MerchantResponse, error) {
-	_, err := s.accountRepository.GetByEmail(ctx, req.Email)
-	if err != nil && err != sql.ErrNoRows {
-		logger.Log().Err(err).Msg("failed to get account by email")
+	taken, err := s.accountRepository.EmailTaken(ctx, req.Email, 0)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to check account email")
 		return nil, constant.ErrServer
-	} else if err == nil {
+	} else if taken {
 		return nil, constant.ErrEmailRegistered
 	}
 
@@ -56,22 +64,32 @@ func (s *accountService) Create(ctx context.Context, req model.MerchantCreateReq
 		logger.Log().Err(err).Msg("failed to create account")
 		return nil, constant.ErrServer
 	}
+	s.audit(ctx, model.AuditActionCreate, account, model.AuditDiff(nil, account.AuditFields()))
 
 	return model.NewMerchantResponse(account), nil
 }
 
-func (s *accountService) List(ctx context.Context, req model.MerchantListRequest) ([]*model.MerchantResponse, error) {
-	accounts, err := s.accountRepository.List(ctx, req.Limit, req.Offset, req.Name)
-	if err != nil {
+func (s *accountService) List(ctx context.Context, req model.MerchantListRequest) ([]*model.MerchantResponse, *model.PageInfo, error) {
+	accounts, info, err := s.accountRepository.List(ctx, req.Page, req.Name)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
 		logger.Log().Err(err).Msg("failed to list accounts")
-		return nil, constant.ErrServer
+		return nil, nil, constant.ErrServer
+	}
+
+	total, err := s.accountRepository.Count(ctx, req.Name)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count accounts")
+		return nil, nil, constant.ErrServer
 	}
+	info.SetTotal(total)
 
-	return model.NewMerchantListResponse(accounts), nil
+	return model.NewMerchantListResponse(accounts), info, nil
 }
 
 func (s *accountService) Get(ctx context.Context, req model.MerchantGetRequest) (*model.MerchantResponse, error) {
-	account, err := s.accountRepository.Get(ctx, req.ID)
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
 	}
@@ -80,41 +98,110 @@ func (s *accountService) Get(ctx context.Context, req model.MerchantGetRequest)
 }
 
 func (s *accountService) Update(ctx context.Context, req model.MerchantUpdateRequest) (*model.MerchantResponse, error) {
-	if !middleware.IsMe(ctx, req.ID) {
+	if !middleware.IsMerchant(ctx, req.ID) {
 		return nil, constant.ErrUnauthorized
 	}
 
-	account, err := s.accountRepository.GetByEmail(ctx, req.Email)
-	if err != nil && err != sql.ErrNoRows {
-		logger.Log().Err(err).Msg("failed to get account by email")
+	taken, err := s.accountRepository.EmailTaken(ctx, req.Email, req.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to check account email")
 		return nil, constant.ErrServer
-	} else if err == nil && account.ID != req.ID {
+	} else if taken {
 		return nil, constant.ErrEmailRegistered
 	}
 
-	account, err = s.accountRepository.Get(ctx, req.ID)
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
 	}
 
+	before := account.AuditFields()
 	account.Name = req.Name
 	account.Email = req.Email
 	account.UpdatedAt.Time = time.Now()
 
-	err = s.accountRepository.Update(ctx, account)
+	err = s.accountRepository.Update(ctx, account, req.IfMatch)
 	if err != nil {
 		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
 	}
+	s.audit(ctx, model.AuditActionUpdate, account, model.AuditDiff(before, account.AuditFields()))
+
+	return model.NewMerchantResponse(account), nil
+}
+
+// Patch merges req.Patch into the latest merchant as an update request and validates the
+// result like one, then writes only the fields that changed. A patch changing nothing
+// writes nothing.
+func (s *accountService) Patch(ctx context.Context, req model.MerchantPatchRequest) (*model.MerchantResponse, error) {
+	if !middleware.IsMerchant(ctx, req.ID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
+	}
+
+	current, err := json.Marshal(model.NewMerchantUpdateRequest(account))
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to marshal account update request")
+		return nil, constant.ErrServer
+	}
+
+	merged, err := model.MergePatch(current, req.Patch)
+	if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+
+	update := model.MerchantUpdateRequest{ID: req.ID, IfMatch: req.IfMatch}
+	err = json.Unmarshal(merged, &update)
+	if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+
+	err = validation.Struct(update)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Email != account.Email {
+		taken, err := s.accountRepository.EmailTaken(ctx, update.Email, req.ID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to check account email")
+			return nil, constant.ErrServer
+		} else if taken {
+			return nil, constant.ErrEmailRegistered
+		}
+	}
+
+	before := account.AuditFields()
+	account.Name = update.Name
+	account.Email = update.Email
+
+	changes := model.AuditDiff(before, account.AuditFields())
+	if len(changes) == 0 {
+		if !req.IfMatch.Holds(account.Version) {
+			return nil, constant.ErrPreconditionFailed
+		}
+		return model.NewMerchantResponse(account), nil
+	}
+	account.UpdatedAt.Time = time.Now()
+
+	err = s.accountRepository.Patch(ctx, account, req.IfMatch, model.ChangedFields(changes)...)
+	if err != nil {
+		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
+	}
+	s.audit(ctx, model.AuditActionUpdate, account, changes)
 
 	return model.NewMerchantResponse(account), nil
 }
 
 func (s *accountService) UpdatePassword(ctx context.Context, req model.MerchantPasswordUpdateRequest) (*model.MerchantResponse, error) {
-	if !middleware.IsMe(ctx, req.ID) {
+	if !middleware.IsMerchant(ctx, req.ID) {
 		return nil, constant.ErrUnauthorized
 	}
 
-	account, err := s.accountRepository.Get(ctx, req.ID)
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
 	}
@@ -130,34 +217,137 @@ func (s *accountService) UpdatePassword(ctx context.Context, req model.MerchantP
 		return nil, constant.ErrServer
 	}
 
+	before := account.AuditFields()
 	account.Password = string(password)
 	account.UpdatedAt.Time = time.Now()
 
-	err = s.accountRepository.Update(ctx, account)
+	err = s.accountRepository.Update(ctx, account, model.Precondition{})
 	if err != nil {
 		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
 	}
+	s.audit(ctx, model.AuditActionUpdate, account, model.AuditDiff(before, account.AuditFields()))
 
 	return model.NewMerchantResponse(account), nil
 }
 
 func (s *accountService) Delete(ctx context.Context, req model.MerchantDeleteRequest) error {
-	if !middleware.IsMe(ctx, req.ID) {
+	if !middleware.IsMerchant(ctx, req.ID) {
 		return constant.ErrUnauthorized
 	}
 
-	err := s.accountRepository.Delete(ctx, req.ID)
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
 	if err != nil {
 		return s.switchErrMerchantNotFoundOrErrServer(err)
 	}
 
+	before := account.AuditFields()
+	account.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	productIDs, err := s.accountRepository.Delete(ctx, req.ID, req.IfMatch, account.DeletedAt.Time)
+	if err != nil {
+		return s.switchErrMerchantNotFoundOrErrServer(err)
+	}
+	s.audit(ctx, model.AuditActionDelete, account, model.AuditDiff(before, account.AuditFields()))
+
+	for _, productID := range productIDs {
+		err = s.productSearcher.Remove(ctx, productID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to remove product from search index")
+		}
+		s.auditProduct(ctx, model.AuditActionDelete, productID, req.ID, sql.NullTime{}, account.DeletedAt)
+	}
+
 	return nil
 }
 
+// Restore brings back a deleted merchant with the products deleted along with it. Only the
+// merchant can restore itself, so it has to happen while its access token is still valid.
+func (s *accountService) Restore(ctx context.Context, req model.MerchantRestoreRequest) (*model.MerchantResponse, error) {
+	if !middleware.IsMerchant(ctx, req.ID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	deletedAt, productIDs, err := s.accountRepository.Restore(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
+	}
+
+	deleted := sql.NullTime{Time: deletedAt, Valid: true}
+	for _, productID := range productIDs {
+		s.auditProduct(ctx, model.AuditActionRestore, productID, req.ID, deleted, sql.NullTime{})
+	}
+
+	products, err := s.productRepository.ListByIDs(ctx, productIDs)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list restored products")
+	}
+	for _, product := range products {
+		err = s.productSearcher.Index(ctx, product)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to index product")
+		}
+	}
+
+	account, err := s.accountRepository.GetLatest(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrMerchantNotFoundOrErrServer(err)
+	}
+
+	s.audit(ctx, model.AuditActionRestore, account, model.AuditDeletedAt(deleted, sql.NullTime{}))
+
+	return model.NewMerchantResponse(account), nil
+}
+
+func (s *accountService) Trash(ctx context.Context, req model.MerchantTrashRequest) ([]*model.ProductResponse, *model.PageInfo, error) {
+	if !middleware.IsMerchant(ctx, req.ID) {
+		return nil, nil, constant.ErrUnauthorized
+	}
+
+	products, info, err := s.productRepository.ListDeleted(ctx, req.Page, req.ID)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to list deleted products")
+		return nil, nil, constant.ErrServer
+	}
+
+	total, err := s.productRepository.CountDeleted(ctx, req.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count deleted products")
+		return nil, nil, constant.ErrServer
+	}
+	info.SetTotal(total)
+
+	return model.NewProductListResponse(products), info, nil
+}
+
+func (s *accountService) audit(ctx context.Context, action string, account *model.Merchant, changes map[string]model.AuditChange) {
+	s.auditService.Record(ctx, &model.AuditEntry{
+		Action:     action,
+		EntityType: model.AuditEntityMerchant,
+		EntityID:   account.ID,
+		MerchantID: account.ID,
+		Changes:    changes,
+	})
+}
+
+// auditProduct records a product deleted or restored together with its merchant.
+func (s *accountService) auditProduct(ctx context.Context, action string, productID, merchantID int64, before, after sql.NullTime) {
+	s.auditService.Record(ctx, &model.AuditEntry{
+		Action:     action,
+		EntityType: model.AuditEntityProduct,
+		EntityID:   productID,
+		MerchantID: merchantID,
+		Changes:    model.AuditDeletedAt(before, after),
+	})
+}
+
 func (s *accountService) switchErrMerchantNotFoundOrErrServer(err error) error {
 	switch err {
 	case sql.ErrNoRows:
 		return constant.ErrMerchantNotFound
+	case constant.ErrPreconditionFailed:
+		return err
 	default:
 		logger.Log().Err(err).Msg("failed to execute operation account repository")
 		return constant.ErrServer
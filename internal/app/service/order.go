@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"time"
+)
+
+type OrderService interface {
+	Create(ctx context.Context, req model.OrderCreateRequest) (*model.OrderResponse, error)
+	List(ctx context.Context, req model.OrderListRequest) ([]*model.OrderResponse, error)
+	Get(ctx context.Context, req model.OrderGetRequest) (*model.OrderResponse, error)
+	UpdateStatus(ctx context.Context, req model.OrderStatusUpdateRequest) (*model.OrderResponse, error)
+}
+
+type orderService struct {
+	orderRepository     repository.OrderRepository
+	productRepository   repository.ProductRepository
+	promotionRepository repository.PromotionRepository
+}
+
+func NewOrderService(orderRepository repository.OrderRepository, productRepository repository.ProductRepository, promotionRepository repository.PromotionRepository) OrderService {
+	return &orderService{orderRepository, productRepository, promotionRepository}
+}
+
+func (s *orderService) Create(ctx context.Context, req model.OrderCreateRequest) (*model.OrderResponse, error) {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	order := &model.Order{
+		BuyerID:   claimsID,
+		Status:    model.OrderPending,
+		CreatedAt: time.Now(),
+	}
+
+	err := addOrderItems(ctx, s.productRepository, order, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	// every code must apply, the buyer would otherwise pay more than expected
+	if len(req.Codes) > 0 {
+		evaluation, err := evaluatePromotions(ctx, s.promotionRepository, order.Items, req.Codes, claimsID, order.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if len(evaluation.Rejected) > 0 {
+			return nil, evaluation.Rejected[0].Err
+		}
+		order.ApplyPromotions(evaluation)
+	}
+
+	err = s.orderRepository.Create(ctx, order)
+	switch err {
+	case nil:
+		return model.NewOrderResponse(order), nil
+	case constant.ErrPromotionNotFound, constant.ErrPromotionUsedUp, constant.ErrPromotionCustomerLimit:
+		return nil, err
+	default:
+		logger.Log().Err(err).Msg("failed to create order")
+		return nil, constant.ErrServer
+	}
+}
+
+// addOrderItems adds the requested products to order at their current effective price.
+func addOrderItems(ctx context.Context, productRepository repository.ProductRepository, order *model.Order, items []model.OrderItemRequest) error {
+	for _, item := range items {
+		product, err := productRepository.Get(ctx, item.ProductID)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return constant.ErrOrderProductNotFound
+			default:
+				logger.Log().Err(err).Msg("failed to get ordered product")
+				return constant.ErrServer
+			}
+		}
+		err = order.AddItem(product, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *orderService) List(ctx context.Context, req model.OrderListRequest) ([]*model.OrderResponse, error) {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	var buyerID, merchantID int64
+	if middleware.HasRole(ctx, constant.ROLE_CUSTOMER) {
+		buyerID = claimsID
+	} else {
+		merchantID = claimsID
+	}
+
+	orders, err := s.orderRepository.List(ctx, req.Limit, req.Offset, buyerID, merchantID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list orders")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewOrderListResponse(orders), nil
+}
+
+func (s *orderService) Get(ctx context.Context, req model.OrderGetRequest) (*model.OrderResponse, error) {
+	order, err := s.getVisibleOrder(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewOrderResponse(order), nil
+}
+
+// UpdateStatus lets a merchant move its own lines of the order, the order follows once
+// every merchant on it has, and lets the buyer cancel the order while it is pending.
+func (s *orderService) UpdateStatus(ctx context.Context, req model.OrderStatusUpdateRequest) (*model.OrderResponse, error) {
+	order, err := s.getVisibleOrder(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claimsID, _ := middleware.GetClaimsID(ctx)
+	isMerchant := s.isOrderMerchant(ctx, order)
+	isBuyerCancelling := middleware.IsCustomer(ctx, order.BuyerID) && order.Status == model.OrderPending && req.Status == model.OrderCancelled
+	if !isMerchant && !isBuyerCancelling {
+		return nil, constant.ErrUnauthorized
+	}
+
+	order, err = s.orderRepository.UpdateStatus(ctx, order.ID, func(order *model.Order) error {
+		if isMerchant {
+			return order.Advance(claimsID, req.Status, time.Now())
+		}
+		if order.Status != model.OrderPending {
+			return constant.ErrInvalidOrderTransition
+		}
+		return order.SetStatus(req.Status, time.Now())
+	})
+	if err != nil {
+		return nil, s.switchErrOrderNotFoundOrErrServer(err)
+	}
+
+	return model.NewOrderResponse(order), nil
+}
+
+func (s *orderService) getVisibleOrder(ctx context.Context, id int64) (*model.Order, error) {
+	_, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	order, err := s.orderRepository.Get(ctx, id)
+	if err != nil {
+		return nil, s.switchErrOrderNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsCustomer(ctx, order.BuyerID) && !s.isOrderMerchant(ctx, order) {
+		return nil, constant.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+func (s *orderService) isOrderMerchant(ctx context.Context, order *model.Order) bool {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	return valid && middleware.HasRole(ctx, constant.ROLE_MERCHANT) && order.HasMerchant(claimsID)
+}
+
+func (s *orderService) switchErrOrderNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrOrderNotFound
+	case constant.ErrInvalidOrderTransition, constant.ErrOrderPaymentPending:
+		return err
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation order repository")
+		return constant.ErrServer
+	}
+}
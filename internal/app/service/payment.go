@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/payment"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"strconv"
+	"time"
+)
+
+type PaymentService interface {
+	Checkout(ctx context.Context, req model.CheckoutRequest) (*model.PaymentResponse, error)
+	HandleWebhook(ctx context.Context, req model.PaymentWebhookRequest) error
+}
+
+func NewPaymentService(paymentRepository repository.PaymentRepository, orderRepository repository.OrderRepository, provider payment.Provider) PaymentService {
+	return &paymentService{paymentRepository, orderRepository, provider}
+}
+
+type paymentService struct {
+	paymentRepository repository.PaymentRepository
+	orderRepository   repository.OrderRepository
+	provider          payment.Provider
+}
+
+// Checkout starts paying for a pending order of the buyer. While a payment is pending it is
+// returned again, so a retried checkout never creates a second intent.
+func (s *paymentService) Checkout(ctx context.Context, req model.CheckoutRequest) (*model.PaymentResponse, error) {
+	order, err := s.orderRepository.Get(ctx, req.OrderID)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, constant.ErrOrderNotFound
+	case err != nil:
+		logger.Log().Err(err).Msg("failed to get order to check out")
+		return nil, constant.ErrServer
+	case !middleware.IsCustomer(ctx, order.BuyerID):
+		return nil, constant.ErrOrderNotFound
+	case order.Status != model.OrderPending:
+		return nil, constant.ErrOrderNotPayable
+	}
+
+	attempts, err := s.paymentRepository.ListByOrder(ctx, order.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list order payments")
+		return nil, constant.ErrServer
+	}
+	if n := len(attempts); n > 0 && attempts[n-1].Status == model.PaymentPending {
+		return model.NewPaymentResponse(attempts[n-1]), nil
+	}
+
+	// lines a merchant cancelled are not charged, whatever the stored total says
+	amount := order.Payable()
+	key := model.PaymentIdempotencyKey(order.ID, len(attempts))
+	intent, err := s.provider.CreateIntent(ctx, payment.IntentRequest{
+		Amount:         amount.Amount,
+		Currency:       amount.Currency,
+		IdempotencyKey: key,
+		Metadata:       map[string]string{"order_id": strconv.FormatInt(order.ID, 10)},
+	})
+	if err != nil {
+		logger.Log().Err(err).Int64("order_id", order.ID).Msg("failed to create payment intent")
+		return nil, constant.ErrPaymentProvider
+	}
+
+	attempt := &model.Payment{
+		OrderID:        order.ID,
+		Provider:       s.provider.Name(),
+		IntentID:       intent.ID,
+		IdempotencyKey: key,
+		Amount:         amount,
+		Status:         model.PaymentPending,
+		ClientSecret:   intent.ClientSecret,
+		CreatedAt:      time.Now(),
+	}
+
+	err = s.paymentRepository.Create(ctx, attempt)
+	if err == constant.ErrOrderNotPayable {
+		return nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to create payment")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewPaymentResponse(attempt), nil
+}
+
+// HandleWebhook settles the payment of a signed intent event. Events it has no use for,
+// and events of intents it does not know, are acknowledged so the provider stops sending them.
+// Money collected for an order cancelled in the meantime, or differing from the payment's
+// amount, leaves the order unpaid and the payment refund_due.
+func (s *paymentService) HandleWebhook(ctx context.Context, req model.PaymentWebhookRequest) error {
+	event, err := s.provider.ParseEvent(req.Payload, req.Signature)
+	if err == payment.ErrInvalidSignature {
+		return constant.ErrPaymentSignature
+	} else if err != nil {
+		return constant.ErrRequestBody
+	}
+
+	var status model.PaymentStatus
+	switch event.Type {
+	case payment.EventIntentSucceeded:
+		status = model.PaymentSucceeded
+	case payment.EventIntentFailed:
+		status = model.PaymentFailed
+	default:
+		return nil
+	}
+
+	attempt, err := s.paymentRepository.GetByIntent(ctx, s.provider.Name(), event.Intent.ID)
+	if err == sql.ErrNoRows {
+		logger.Log().Warn().Str("intent_id", event.Intent.ID).Msg("payment event for an unknown intent")
+		return nil
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to get payment by intent")
+		return constant.ErrServer
+	}
+
+	if attempt.Status != model.PaymentPending {
+		return nil
+	}
+
+	collected := model.Money{Amount: event.Intent.Amount, Currency: event.Intent.Currency}
+	var refundDue bool
+	attempt, err = s.paymentRepository.UpdateStatus(ctx, attempt.ID, func(attempt *model.Payment, order model.OrderStatus) {
+		attempt.Settle(status, collected, order, time.Now())
+		refundDue = attempt.Status == model.PaymentRefundDue
+	})
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to update payment status")
+		return constant.ErrServer
+	}
+
+	if refundDue {
+		logger.Log().Error().Str("intent_id", event.Intent.ID).Int64("payment_id", attempt.ID).Int64("order_id", attempt.OrderID).
+			Msg("payment collected money the order cannot take, it has to be refunded")
+	}
+
+	return nil
+}
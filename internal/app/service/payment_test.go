@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/payment"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCheckoutOrderRepository struct {
+	repository.OrderRepository
+	order *model.Order
+}
+
+func (r *fakeCheckoutOrderRepository) Get(ctx context.Context, id int64) (*model.Order, error) {
+	if id != r.order.ID {
+		return nil, sql.ErrNoRows
+	}
+	return r.order, nil
+}
+
+type fakeCheckoutPaymentRepository struct {
+	repository.PaymentRepository
+	created []*model.Payment
+}
+
+func (r *fakeCheckoutPaymentRepository) ListByOrder(ctx context.Context, orderID int64) ([]*model.Payment, error) {
+	return r.created, nil
+}
+
+func (r *fakeCheckoutPaymentRepository) Create(ctx context.Context, attempt *model.Payment) error {
+	r.created = append(r.created, attempt)
+	return nil
+}
+
+type fakeCheckoutProvider struct {
+	payment.Provider
+	requests []payment.IntentRequest
+}
+
+func (p *fakeCheckoutProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeCheckoutProvider) CreateIntent(ctx context.Context, req payment.IntentRequest) (*payment.Intent, error) {
+	p.requests = append(p.requests, req)
+	return &payment.Intent{ID: "pi_1", Amount: req.Amount, Currency: req.Currency}, nil
+}
+
+func TestCheckoutPartlyCancelledOrder(t *testing.T) {
+	order := &model.Order{ID: 5, BuyerID: 3, Status: model.OrderPending}
+	require.NoError(t, order.AddItem(&model.Product{ID: 1, Price: model.Money{Amount: 1500, Currency: "IDR"}, MerchantID: 7}, 2))
+	require.NoError(t, order.AddItem(&model.Product{ID: 2, Price: model.Money{Amount: 250, Currency: "IDR"}, MerchantID: 8}, 1))
+	order.ApplyPromotions(&model.PromotionEvaluation{Discount: model.Money{Amount: 100, Currency: "IDR"}})
+	// the merchant 7 cancelled its lines, the stored total predates it
+	order.Items[0].Status = model.OrderCancelled
+
+	payments := &fakeCheckoutPaymentRepository{}
+	provider := &fakeCheckoutProvider{}
+	s := NewPaymentService(payments, &fakeCheckoutOrderRepository{order: order}, provider)
+
+	res, err := s.Checkout(middleware.WithClaims(context.Background(), 3, constant.ROLE_CUSTOMER), model.CheckoutRequest{OrderID: 5})
+	require.NoError(t, err)
+	require.Len(t, provider.requests, 1)
+	assert.Equal(t, int64(150), provider.requests[0].Amount, "only the lines still coming are charged")
+	assert.Equal(t, "IDR", provider.requests[0].Currency)
+	require.Len(t, payments.created, 1)
+	assert.Equal(t, model.Money{Amount: 150, Currency: "IDR"}, payments.created[0].Amount)
+	assert.Equal(t, model.Money{Amount: 150, Currency: "IDR"}, res.Amount)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"time"
+)
+
+type PriceRuleService interface {
+	List(ctx context.Context, req model.PriceRuleListRequest) ([]*model.PriceRuleResponse, error)
+	Create(ctx context.Context, req model.PriceRuleCreateRequest) (*model.PriceRuleResponse, error)
+	Delete(ctx context.Context, req model.PriceRuleDeleteRequest) error
+	Sync(ctx context.Context) error
+}
+
+type priceRuleService struct {
+	productRepository repository.ProductRepository
+}
+
+func NewPriceRuleService(productRepository repository.ProductRepository) PriceRuleService {
+	return &priceRuleService{productRepository}
+}
+
+// List shows the merchant every rule of the product, scheduled, active and ended.
+func (s *priceRuleService) List(ctx context.Context, req model.PriceRuleListRequest) ([]*model.PriceRuleResponse, error) {
+	product, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.productRepository.ListPriceRules(ctx, product.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list price rules")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewPriceRuleListResponse(rules, product.Price.Currency), nil
+}
+
+// Create schedules a sale. A rule whose period has already started is active right away
+// rather than waiting for the next scheduler run.
+func (s *priceRuleService) Create(ctx context.Context, req model.PriceRuleCreateRequest) (*model.PriceRuleResponse, error) {
+	product, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rule := &model.PriceRule{
+		ProductID: product.ID,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		CreatedAt: now,
+	}
+	if (req.SalePrice == nil) == (req.Percentage == nil) || rule.Ended(now) {
+		return nil, constant.ErrPriceRuleInvalid
+	}
+
+	if req.SalePrice != nil {
+		rule.SalePrice, err = parsePriceOverride(req.SalePrice, product.Price.Currency)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rule.Percentage = sql.NullInt64{Int64: *req.Percentage, Valid: true}
+	}
+	rule.Active = !now.Before(rule.StartsAt)
+
+	err = s.productRepository.CreatePriceRule(ctx, rule)
+	if err == constant.ErrPriceRuleOverlap {
+		return nil, err
+	} else if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewPriceRuleResponse(rule, product.Price.Currency), nil
+}
+
+func (s *priceRuleService) Delete(ctx context.Context, req model.PriceRuleDeleteRequest) error {
+	_, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return err
+	}
+
+	rule, err := s.productRepository.GetPriceRule(ctx, req.RuleID)
+	if err == sql.ErrNoRows || err == nil && rule.ProductID != req.ProductID {
+		return constant.ErrPriceRuleNotFound
+	} else if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	err = s.productRepository.DeletePriceRule(ctx, rule)
+	if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+// Sync is run by the scheduler to start and end sales whose time has come.
+func (s *priceRuleService) Sync(ctx context.Context) error {
+	productIDs, err := s.productRepository.SyncPriceRules(ctx, time.Now())
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to sync price rules")
+		return err
+	}
+
+	if len(productIDs) > 0 {
+		logger.Log().Info().Msgf("updated the effective price of %d products", len(productIDs))
+	}
+
+	return nil
+}
+
+func (s *priceRuleService) authorize(ctx context.Context, productID int64) (*model.Product, error) {
+	product, err := s.productRepository.Get(ctx, productID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	return product, nil
+}
+
+func (s *priceRuleService) switchErrProductNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrProductNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation product repository")
+		return constant.ErrServer
+	}
+}
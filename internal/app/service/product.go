@@ -3,28 +3,40 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/app/repository"
 	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/exchange"
 	"github.com/SemmiDev/go-product/internal/logger"
 	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/validation"
 	"time"
 )
 
 type ProductService interface {
 	Create(ctx context.Context, req model.ProductCreateRequest) (*model.ProductResponse, error)
-	List(ctx context.Context, req model.ProductListRequest) ([]*model.ProductResponse, error)
+	List(ctx context.Context, req model.ProductListRequest) ([]*model.ProductResponse, *model.PageInfo, error)
+	Search(ctx context.Context, req model.ProductSearchRequest) ([]*model.ProductSearchResponse, error)
 	Get(ctx context.Context, req model.ProductGetRequest) (*model.ProductResponse, error)
 	Update(ctx context.Context, req model.ProductUpdateRequest) (*model.ProductResponse, error)
+	Patch(ctx context.Context, req model.ProductPatchRequest) (*model.ProductResponse, error)
 	Delete(ctx context.Context, req model.ProductDeleteRequest) error
+	Restore(ctx context.Context, req model.ProductRestoreRequest) (*model.ProductResponse, error)
+
+	PriceHistory(ctx context.Context, req model.ProductPriceHistoryRequest) ([]*model.ProductPriceResponse, *model.PageInfo, error)
+	PriceAt(ctx context.Context, req model.ProductPriceAtRequest) (*model.ProductPriceResponse, error)
 }
 
 type productService struct {
-	productRepository repository.ProductRepository
+	productRepository   repository.ProductRepository
+	productSearcher     repository.ProductSearcher
+	exchangeRateService ExchangeRateService
+	auditService        AuditService
 }
 
-func NewProductService(productRepository repository.ProductRepository) ProductService {
-	return &productService{productRepository}
+func NewProductService(productRepository repository.ProductRepository, productSearcher repository.ProductSearcher, exchangeRateService ExchangeRateService, auditService AuditService) ProductService {
+	return &productService{productRepository, productSearcher, exchangeRateService, auditService}
 }
 
 func (s *productService) Create(ctx context.Context, req model.ProductCreateRequest) (*model.ProductResponse, error) {
@@ -33,73 +45,328 @@ func (s *productService) Create(ctx context.Context, req model.ProductCreateRequ
 		return nil, constant.ErrUnauthorized
 	}
 
+	currency := req.Currency
+	if currency == "" {
+		currency = model.DefaultCurrency
+	}
+
+	price, err := model.ParseMoney(req.Price.String(), currency)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &model.Product{
 		Name:       req.Name,
-		Price:      req.Price,
+		Price:      price,
 		CreatedAt:  time.Now(),
 		MerchantID: claimsID,
 	}
 
-	err := s.productRepository.Create(ctx, product)
+	err = s.productRepository.Create(ctx, product)
 	if err != nil {
 		logger.Log().Err(err).Msg("failed to create product")
 		return nil, constant.ErrServer
 	}
+	s.index(ctx, product)
+	s.audit(ctx, model.AuditActionCreate, product, model.AuditDiff(nil, product.AuditFields()))
 
 	return model.NewProductResponse(product), nil
 }
 
-func (s *productService) List(ctx context.Context, req model.ProductListRequest) ([]*model.ProductResponse, error) {
-	products, err := s.productRepository.List(ctx, req.Limit, req.Offset, req.Title)
-	if err != nil {
+func (s *productService) List(ctx context.Context, req model.ProductListRequest) ([]*model.ProductResponse, *model.PageInfo, error) {
+	products, info, err := s.productRepository.List(ctx, req.Page, req.Filter)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
 		logger.Log().Err(err).Msg("failed to list products")
+		return nil, nil, constant.ErrServer
+	}
+
+	total, err := s.productRepository.Count(ctx, req.Filter)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count products")
+		return nil, nil, constant.ErrServer
+	}
+	info.SetTotal(total)
+
+	res := model.NewProductListResponse(products)
+	err = s.convertPrices(ctx, req.Currency, res...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return res, info, nil
+}
+
+func (s *productService) Search(ctx context.Context, req model.ProductSearchRequest) ([]*model.ProductSearchResponse, error) {
+	hits, err := s.productSearcher.Search(ctx, req.Query, req.Limit, req.Offset)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to search products")
 		return nil, constant.ErrServer
 	}
 
-	return model.NewProductListResponse(products), nil
+	return model.NewProductSearchListResponse(hits, req.Query), nil
 }
 
 func (s *productService) Get(ctx context.Context, req model.ProductGetRequest) (*model.ProductResponse, error) {
-	product, err := s.productRepository.Get(ctx, req.ID)
+	product, err := s.productRepository.GetLatest(ctx, req.ID)
 	if err != nil {
 		return nil, s.switchErrProductNotFoundOrErrServer(err)
 	}
-	return model.NewProductResponse(product), nil
+
+	res := model.NewProductResponse(product)
+	err = s.convertPrices(ctx, req.Currency, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 func (s *productService) Update(ctx context.Context, req model.ProductUpdateRequest) (*model.ProductResponse, error) {
-	product, err := s.productRepository.Get(ctx, req.ID)
+	product, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := product.AuditFields()
+	err = s.apply(ctx, product, req)
+	if err != nil {
+		return nil, err
+	}
+	product.UpdatedAt.Time = time.Now()
+
+	err = s.productRepository.Update(ctx, product, req.IfMatch)
 	if err != nil {
 		return nil, s.switchErrProductNotFoundOrErrServer(err)
 	}
+	s.index(ctx, product)
+	s.audit(ctx, model.AuditActionUpdate, product, model.AuditDiff(before, product.AuditFields()))
 
-	if !middleware.IsMe(ctx, product.MerchantID) {
-		return nil, constant.ErrUnauthorized
+	return model.NewProductResponse(product), nil
+}
+
+// Patch merges req.Patch into the latest product as an update request and validates the
+// result like one, then writes only the fields that changed. A patch changing nothing
+// writes nothing.
+func (s *productService) Patch(ctx context.Context, req model.ProductPatchRequest) (*model.ProductResponse, error) {
+	product, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	product.Name = req.Name
-	product.Price = req.Price
+	current, err := json.Marshal(model.NewProductUpdateRequest(product))
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to marshal product update request")
+		return nil, constant.ErrServer
+	}
+
+	merged, err := model.MergePatch(current, req.Patch)
+	if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+
+	update := model.ProductUpdateRequest{ID: req.ID, IfMatch: req.IfMatch}
+	err = json.Unmarshal(merged, &update)
+	if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+
+	err = validation.Struct(update)
+	if err != nil {
+		return nil, err
+	}
+
+	before := product.AuditFields()
+	err = s.apply(ctx, product, update)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := model.AuditDiff(before, product.AuditFields())
+	if len(changes) == 0 {
+		if !req.IfMatch.Holds(product.Version) {
+			return nil, constant.ErrPreconditionFailed
+		}
+		return model.NewProductResponse(product), nil
+	}
 	product.UpdatedAt.Time = time.Now()
 
-	err = s.productRepository.Update(ctx, product)
+	err = s.productRepository.Patch(ctx, product, req.IfMatch, model.ChangedFields(changes)...)
 	if err != nil {
 		return nil, s.switchErrProductNotFoundOrErrServer(err)
 	}
+	s.index(ctx, product)
+	s.audit(ctx, model.AuditActionUpdate, product, changes)
 
 	return model.NewProductResponse(product), nil
 }
 
+// authorize reads the latest product for a change by its merchant.
+func (s *productService) authorize(ctx context.Context, id int64) (*model.Product, error) {
+	product, err := s.productRepository.GetLatest(ctx, id)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	return product, nil
+}
+
+// apply sets the name and price of req on product.
+func (s *productService) apply(ctx context.Context, product *model.Product, req model.ProductUpdateRequest) error {
+	currency := req.Currency
+	if currency == "" {
+		currency = product.Price.Currency
+	}
+
+	price, err := model.ParseMoney(req.Price.String(), currency)
+	if err != nil {
+		return err
+	}
+
+	// variant price overrides are amounts in the product currency
+	if currency != product.Price.Currency {
+		for _, variant := range product.Variants {
+			if variant.PriceOverride.Valid {
+				return constant.ErrVariantPriceCurrency
+			}
+		}
+
+		// so are sale prices of price rules
+		rules, err := s.productRepository.ListPriceRules(ctx, product.ID)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to list price rules")
+			return constant.ErrServer
+		}
+		for _, rule := range rules {
+			if rule.SalePrice.Valid && !rule.Ended(time.Now()) {
+				return constant.ErrPriceRuleCurrency
+			}
+		}
+	}
+
+	product.Name = req.Name
+	product.Price = price
+	return nil
+}
+
 func (s *productService) Delete(ctx context.Context, req model.ProductDeleteRequest) error {
-	product, err := s.productRepository.Get(ctx, req.ID)
+	product, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	before := product.AuditFields()
+	product.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	err = s.productRepository.Delete(ctx, req.ID, req.IfMatch, product.DeletedAt.Time)
 	if err != nil {
 		return s.switchErrProductNotFoundOrErrServer(err)
 	}
+	s.audit(ctx, model.AuditActionDelete, product, model.AuditDiff(before, product.AuditFields()))
+
+	err = s.productSearcher.Remove(ctx, req.ID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to remove product from search index")
+	}
+
+	return nil
+}
+
+// Restore takes a product out of the trash, which requires its merchant to be active.
+func (s *productService) Restore(ctx context.Context, req model.ProductRestoreRequest) (*model.ProductResponse, error) {
+	product, err := s.productRepository.GetDeleted(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	if product.Merchant.DeletedAt.Valid {
+		return nil, constant.ErrProductMerchantDeleted
+	}
+
+	err = s.productRepository.Restore(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+	s.audit(ctx, model.AuditActionRestore, product, model.AuditDeletedAt(product.DeletedAt, sql.NullTime{}))
+
+	product, err = s.productRepository.Get(ctx, req.ID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+	s.index(ctx, product)
+
+	return model.NewProductResponse(product), nil
+}
+
+func (s *productService) PriceHistory(ctx context.Context, req model.ProductPriceHistoryRequest) ([]*model.ProductPriceResponse, *model.PageInfo, error) {
+	err := s.checkPriceHistory(ctx, req.ProductID)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if !middleware.IsMe(ctx, product.MerchantID) {
-		return constant.ErrUnauthorized
+	prices, info, err := s.productRepository.ListPriceHistory(ctx, req.Page, req.ProductID)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to list product price history")
+		return nil, nil, constant.ErrServer
 	}
 
-	err = s.productRepository.Delete(ctx, req.ID)
+	total, err := s.productRepository.CountPriceHistory(ctx, req.ProductID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count product price history")
+		return nil, nil, constant.ErrServer
+	}
+	info.SetTotal(total)
+
+	return model.NewProductPriceListResponse(prices), info, nil
+}
+
+func (s *productService) PriceAt(ctx context.Context, req model.ProductPriceAtRequest) (*model.ProductPriceResponse, error) {
+	err := s.checkPriceHistory(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	price, err := s.productRepository.GetPriceAt(ctx, req.ProductID, at)
+	if err == sql.ErrNoRows {
+		return nil, constant.ErrPriceNotFound
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to get product price")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewProductPriceResponse(price), nil
+}
+
+// checkPriceHistory makes sure there is a product to read the prices of. Deleted products
+// keep their history, and purged ones as long as any of it is left.
+func (s *productService) checkPriceHistory(ctx context.Context, productID int64) error {
+	_, err := s.productRepository.Get(ctx, productID)
+	if err == sql.ErrNoRows {
+		_, err = s.productRepository.GetDeleted(ctx, productID)
+	}
+	if err == sql.ErrNoRows {
+		var count int64
+		count, err = s.productRepository.CountPriceHistory(ctx, productID)
+		if err == nil && count == 0 {
+			err = sql.ErrNoRows
+		}
+	}
 	if err != nil {
 		return s.switchErrProductNotFoundOrErrServer(err)
 	}
@@ -107,10 +374,67 @@ func (s *productService) Delete(ctx context.Context, req model.ProductDeleteRequ
 	return nil
 }
 
+// convertPrices converts every response to currency, fetching rates only when one of
+// them is priced in another currency.
+func (s *productService) convertPrices(ctx context.Context, currency string, res ...*model.ProductResponse) error {
+	if currency == "" {
+		return nil
+	}
+
+	var rates *exchange.Rates
+	for _, product := range res {
+		if product.Price.Currency == currency {
+			continue
+		}
+
+		if rates == nil {
+			var err error
+			rates, err = s.exchangeRateService.Rates(ctx)
+			if err != nil {
+				return err
+			}
+
+			// a currency the provider does not quote is the caller's to change
+			_, err = rates.Rate(rates.Base, currency)
+			if err != nil {
+				return constant.ErrExchangeRateCurrency
+			}
+		}
+
+		err := product.ConvertPrice(currency, rates)
+		if err != nil {
+			logger.Log().Err(err).Msg("failed to convert product price")
+			return constant.ErrExchangeRateUnavailable
+		}
+	}
+
+	return nil
+}
+
+// index failures are only logged, the product itself is already saved.
+func (s *productService) index(ctx context.Context, product *model.Product) {
+	err := s.productSearcher.Index(ctx, product)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to index product")
+	}
+}
+
+func (s *productService) audit(ctx context.Context, action string, product *model.Product, changes map[string]model.AuditChange) {
+	s.auditService.Record(ctx, &model.AuditEntry{
+		Action:     action,
+		EntityType: model.AuditEntityProduct,
+		EntityID:   product.ID,
+		MerchantID: product.MerchantID,
+		Changes:    changes,
+	})
+}
+
 func (s *productService) switchErrProductNotFoundOrErrServer(err error) error {
 	switch err {
 	case sql.ErrNoRows:
 		return constant.ErrProductNotFound
+	case constant.ErrPreconditionFailed:
+		return err
 	default:
 		logger.Log().Err(err).Msg("failed to execute operation product repository")
 		return constant.ErrServer
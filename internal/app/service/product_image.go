@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/storage"
+	"time"
+)
+
+type ProductImageService interface {
+	Upload(ctx context.Context, req model.ProductImageUploadRequest) (*model.ProductImageResponse, error)
+	Reorder(ctx context.Context, req model.ProductImageReorderRequest) ([]*model.ProductImageResponse, error)
+	Delete(ctx context.Context, req model.ProductImageDeleteRequest) error
+}
+
+type productImageService struct {
+	productRepository repository.ProductRepository
+	storage           storage.Storage
+}
+
+func NewProductImageService(productRepository repository.ProductRepository, storage storage.Storage) ProductImageService {
+	return &productImageService{productRepository, storage}
+}
+
+func (s *productImageService) Upload(ctx context.Context, req model.ProductImageUploadRequest) (*model.ProductImageResponse, error) {
+	err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(req.Data)) > config.Cfg().ImageMaxBytes {
+		return nil, constant.ErrImageTooLarge
+	}
+
+	info, err := storage.InspectImage(req.Data)
+	if err != nil {
+		return nil, constant.ErrImageUnsupported
+	}
+
+	minDimension, maxDimension := config.Cfg().ImageMinDimension, config.Cfg().ImageMaxDimension
+	if info.Width < minDimension || info.Height < minDimension || info.Width > maxDimension || info.Height > maxDimension {
+		return nil, constant.ErrImageDimensions
+	}
+
+	name := make([]byte, 16)
+	_, err = rand.Read(name)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to generate image name")
+		return nil, constant.ErrServer
+	}
+
+	key := fmt.Sprintf("products/%d/%s%s", req.ProductID, hex.EncodeToString(name), info.Extension)
+	err = s.storage.Put(ctx, key, req.Data, info.ContentType)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to store image")
+		return nil, constant.ErrServer
+	}
+
+	image := &model.ProductImage{
+		ProductID:   req.ProductID,
+		Key:         key,
+		URL:         s.storage.URL(key),
+		ContentType: info.ContentType,
+		Size:        int64(len(req.Data)),
+		Width:       info.Width,
+		Height:      info.Height,
+		CreatedAt:   time.Now(),
+	}
+
+	err = s.productRepository.CreateImage(ctx, image)
+	if err != nil {
+		s.deleteObject(ctx, key)
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductImageResponse(image), nil
+}
+
+func (s *productImageService) Reorder(ctx context.Context, req model.ProductImageReorderRequest) ([]*model.ProductImageResponse, error) {
+	err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.productRepository.ListImages(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	byID := make(map[int64]*model.ProductImage, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+	if len(req.ImageIDs) != len(images) {
+		return nil, constant.ErrImageOrderInvalid
+	}
+
+	ordered := make([]*model.ProductImage, len(req.ImageIDs))
+	for position, id := range req.ImageIDs {
+		image, ok := byID[id]
+		if !ok {
+			return nil, constant.ErrImageOrderInvalid
+		}
+		delete(byID, id)
+
+		image.Position = position
+		ordered[position] = image
+	}
+
+	err = s.productRepository.ReorderImages(ctx, req.ProductID, req.ImageIDs)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductImageListResponse(ordered), nil
+}
+
+func (s *productImageService) Delete(ctx context.Context, req model.ProductImageDeleteRequest) error {
+	err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return err
+	}
+
+	image, err := s.productRepository.GetImage(ctx, req.ImageID)
+	if err == sql.ErrNoRows || err == nil && image.ProductID != req.ProductID {
+		return constant.ErrImageNotFound
+	} else if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	err = s.productRepository.DeleteImage(ctx, image)
+	if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	s.deleteObject(ctx, image.Key)
+	return nil
+}
+
+func (s *productImageService) authorize(ctx context.Context, productID int64) error {
+	product, err := s.productRepository.Get(ctx, productID)
+	if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return constant.ErrUnauthorized
+	}
+
+	return nil
+}
+
+// deleteObject only logs failures; a leftover object is harmless once no row points to it.
+func (s *productImageService) deleteObject(ctx context.Context, key string) {
+	err := s.storage.Delete(ctx, key)
+	if err != nil {
+		logger.Log().Err(err).Str("key", key).Msg("failed to delete stored image")
+	}
+}
+
+func (s *productImageService) switchErrProductNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrProductNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation product repository")
+		return constant.ErrServer
+	}
+}
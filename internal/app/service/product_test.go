@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/exchange"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePriceHistoryRepository struct {
+	repository.ProductRepository
+	active  map[int64]*model.Product
+	deleted map[int64]*model.Product
+	prices  map[int64][]*model.ProductPrice
+}
+
+func (r *fakePriceHistoryRepository) Get(ctx context.Context, id int64) (*model.Product, error) {
+	if product, found := r.active[id]; found {
+		return product, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePriceHistoryRepository) GetDeleted(ctx context.Context, id int64) (*model.Product, error) {
+	if product, found := r.deleted[id]; found {
+		return product, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *fakePriceHistoryRepository) ListPriceHistory(ctx context.Context, page model.Page, productID int64) ([]*model.ProductPrice, *model.PageInfo, error) {
+	return r.prices[productID], &model.PageInfo{}, nil
+}
+
+func (r *fakePriceHistoryRepository) CountPriceHistory(ctx context.Context, productID int64) (int64, error) {
+	return int64(len(r.prices[productID])), nil
+}
+
+func (r *fakePriceHistoryRepository) GetPriceAt(ctx context.Context, productID int64, t time.Time) (*model.ProductPrice, error) {
+	for _, price := range r.prices[productID] {
+		if !price.EffectiveFrom.After(t) {
+			return price, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestPriceHistoryOfDeletedProducts(t *testing.T) {
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	price := func(productID int64) []*model.ProductPrice {
+		return []*model.ProductPrice{{ID: productID, ProductID: productID, Price: model.Money{Amount: 1999, Currency: "USD"}, EffectiveFrom: from}}
+	}
+	products := &fakePriceHistoryRepository{
+		active:  map[int64]*model.Product{1: {ID: 1}},
+		deleted: map[int64]*model.Product{2: {ID: 2}},
+		prices:  map[int64][]*model.ProductPrice{1: price(1), 2: price(2), 3: price(3)},
+	}
+	s := NewProductService(products, nil, nil, nil)
+
+	// 1 is active, 2 deleted and 3 purged
+	for _, id := range []int64{1, 2, 3} {
+		prices, info, err := s.PriceHistory(context.Background(), model.ProductPriceHistoryRequest{ProductID: id})
+		require.NoError(t, err, id)
+		assert.Len(t, prices, 1)
+		assert.Equal(t, int64(1), info.Total)
+
+		current, err := s.PriceAt(context.Background(), model.ProductPriceAtRequest{ProductID: id})
+		require.NoError(t, err, id)
+		assert.Equal(t, model.Money{Amount: 1999, Currency: "USD"}, current.Price)
+	}
+
+	_, _, err := s.PriceHistory(context.Background(), model.ProductPriceHistoryRequest{ProductID: 4})
+	assert.Equal(t, constant.ErrProductNotFound, err, "a product that never existed has no history")
+	_, err = s.PriceAt(context.Background(), model.ProductPriceAtRequest{ProductID: 4})
+	assert.Equal(t, constant.ErrProductNotFound, err)
+
+	before := from.Add(-time.Hour)
+	_, err = s.PriceAt(context.Background(), model.ProductPriceAtRequest{ProductID: 2, At: &before})
+	assert.Equal(t, constant.ErrPriceNotFound, err)
+}
+
+type fakeConversionRepository struct {
+	repository.ProductRepository
+	product *model.Product
+}
+
+func (r *fakeConversionRepository) GetLatest(ctx context.Context, id int64) (*model.Product, error) {
+	return r.product, nil
+}
+
+type fakeExchangeRateService struct {
+	rates *exchange.Rates
+}
+
+func (s *fakeExchangeRateService) Rates(ctx context.Context) (*exchange.Rates, error) {
+	return s.rates, nil
+}
+
+func TestProductGetConvertsPrice(t *testing.T) {
+	products := &fakeConversionRepository{product: &model.Product{ID: 1, Price: model.Money{Amount: 2000, Currency: "USD"}}}
+	rates := &exchange.Rates{Base: "USD", Timestamp: time.Now(), Rates: map[string]float64{"EUR": 0.8}}
+	s := NewProductService(products, nil, &fakeExchangeRateService{rates}, nil)
+
+	res, err := s.Get(context.Background(), model.ProductGetRequest{ID: 1, Currency: "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, model.Money{Amount: 1600, Currency: "EUR"}, res.Price)
+
+	_, err = s.Get(context.Background(), model.ProductGetRequest{ID: 1, Currency: "JPY"})
+	assert.Equal(t, constant.ErrExchangeRateCurrency, err, "a currency without a rate is the caller's mistake")
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"strings"
+	"time"
+)
+
+type ProductVariantService interface {
+	SetOptions(ctx context.Context, req model.ProductOptionsSetRequest) (*model.ProductResponse, error)
+	List(ctx context.Context, req model.ProductVariantListRequest) ([]*model.ProductVariantResponse, error)
+	Get(ctx context.Context, req model.ProductVariantGetRequest) (*model.ProductVariantResponse, error)
+	Create(ctx context.Context, req model.ProductVariantCreateRequest) (*model.ProductVariantResponse, error)
+	Update(ctx context.Context, req model.ProductVariantUpdateRequest) (*model.ProductVariantResponse, error)
+	Delete(ctx context.Context, req model.ProductVariantDeleteRequest) error
+}
+
+type productVariantService struct {
+	productRepository repository.ProductRepository
+}
+
+func NewProductVariantService(productRepository repository.ProductRepository) ProductVariantService {
+	return &productVariantService{productRepository}
+}
+
+// SetOptions replaces the product's options and generates a variant for every new
+// combination of values. Variants whose combination still exists are left untouched.
+func (s *productVariantService) SetOptions(ctx context.Context, req model.ProductOptionsSetRequest) (*model.ProductResponse, error) {
+	product, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]*model.ProductOption, len(req.Options))
+	for i, option := range req.Options {
+		values := make([]string, len(option.Values))
+		for j, value := range option.Values {
+			values[j] = strings.TrimSpace(value)
+		}
+		options[i] = &model.ProductOption{Name: strings.TrimSpace(option.Name), Values: values}
+	}
+
+	err = model.ValidateProductOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	remove, combinations := model.PlanVariants(options, product.Variants)
+	create := make([]*model.ProductVariant, len(combinations))
+	generated := make(map[string]bool, len(combinations))
+	for i, combination := range combinations {
+		create[i] = &model.ProductVariant{
+			ProductID: product.ID,
+			Options:   combination,
+			Available: true,
+			CreatedAt: time.Now(),
+		}
+		err = s.generateSKU(ctx, create[i], options, generated)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.productRepository.SetOptions(ctx, product.ID, options, remove, create)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	product, err = s.productRepository.Get(ctx, product.ID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductResponse(product), nil
+}
+
+func (s *productVariantService) List(ctx context.Context, req model.ProductVariantListRequest) ([]*model.ProductVariantResponse, error) {
+	product, err := s.productRepository.Get(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductVariantListResponse(product.Variants, product), nil
+}
+
+func (s *productVariantService) Get(ctx context.Context, req model.ProductVariantGetRequest) (*model.ProductVariantResponse, error) {
+	product, err := s.productRepository.Get(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	variant, err := s.getVariant(ctx, req.ProductID, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewProductVariantResponse(variant, product), nil
+}
+
+// Create adds a single combination, typically one removed earlier, to a product that
+// already has options.
+func (s *productVariantService) Create(ctx context.Context, req model.ProductVariantCreateRequest) (*model.ProductVariantResponse, error) {
+	product, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := &model.ProductVariant{
+		ProductID: product.ID,
+		SKU:       strings.TrimSpace(req.SKU),
+		Options:   req.Options,
+		Available: req.Available == nil || *req.Available,
+		CreatedAt: time.Now(),
+	}
+
+	if !variant.MatchesOptions(product.Options) {
+		return nil, constant.ErrVariantOptionsInvalid
+	}
+	for _, existing := range product.Variants {
+		if existing.OptionsKey() == variant.OptionsKey() {
+			return nil, constant.ErrVariantExists
+		}
+	}
+
+	if variant.SKU == "" {
+		err = s.generateSKU(ctx, variant, product.Options, map[string]bool{})
+	} else {
+		err = s.checkSKU(ctx, variant)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	variant.PriceOverride, err = parsePriceOverride(req.Price, product.Price.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.productRepository.CreateVariant(ctx, variant)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductVariantResponse(variant, product), nil
+}
+
+func (s *productVariantService) Update(ctx context.Context, req model.ProductVariantUpdateRequest) (*model.ProductVariantResponse, error) {
+	product, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := s.getVariant(ctx, req.ProductID, req.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant.SKU = strings.TrimSpace(req.SKU)
+	err = s.checkSKU(ctx, variant)
+	if err != nil {
+		return nil, err
+	}
+
+	variant.PriceOverride, err = parsePriceOverride(req.Price, product.Price.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	variant.Available = req.Available
+	variant.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	err = s.productRepository.UpdateVariant(ctx, variant)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return model.NewProductVariantResponse(variant, product), nil
+}
+
+func (s *productVariantService) Delete(ctx context.Context, req model.ProductVariantDeleteRequest) error {
+	_, err := s.authorize(ctx, req.ProductID)
+	if err != nil {
+		return err
+	}
+
+	variant, err := s.getVariant(ctx, req.ProductID, req.VariantID)
+	if err != nil {
+		return err
+	}
+
+	err = s.productRepository.DeleteVariant(ctx, variant)
+	if err != nil {
+		return s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+func (s *productVariantService) authorize(ctx context.Context, productID int64) (*model.Product, error) {
+	product, err := s.productRepository.Get(ctx, productID)
+	if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	return product, nil
+}
+
+func (s *productVariantService) getVariant(ctx context.Context, productID, variantID int64) (*model.ProductVariant, error) {
+	variant, err := s.productRepository.GetVariant(ctx, variantID)
+	if err == sql.ErrNoRows || err == nil && variant.ProductID != productID {
+		return nil, constant.ErrVariantNotFound
+	} else if err != nil {
+		return nil, s.switchErrProductNotFoundOrErrServer(err)
+	}
+
+	return variant, nil
+}
+
+func (s *productVariantService) checkSKU(ctx context.Context, variant *model.ProductVariant) error {
+	existing, err := s.productRepository.GetVariantBySKU(ctx, variant.SKU)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Log().Err(err).Msg("failed to get variant by sku")
+		return constant.ErrServer
+	} else if err == nil && existing.ID != variant.ID {
+		return constant.ErrVariantSKUTaken
+	}
+	return nil
+}
+
+// generateSKU gives the variant a generated SKU no other variant uses, numbering it when
+// values slugify alike or a SKU is taken already. generated holds the SKUs handed out to
+// variants not stored yet.
+func (s *productVariantService) generateSKU(ctx context.Context, variant *model.ProductVariant, options []*model.ProductOption, generated map[string]bool) error {
+	sku := model.GenerateSKU(variant.ProductID, options, variant.Options)
+	for n := 1; ; n++ {
+		variant.SKU = model.NumberSKU(sku, n)
+		if generated[variant.SKU] {
+			continue
+		}
+
+		err := s.checkSKU(ctx, variant)
+		if err == constant.ErrVariantSKUTaken {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		generated[variant.SKU] = true
+		return nil
+	}
+}
+
+// parsePriceOverride reads an optional override priced in the product's currency.
+func parsePriceOverride(price *json.Number, currency string) (sql.NullInt64, error) {
+	if price == nil {
+		return sql.NullInt64{}, nil
+	}
+
+	money, err := model.ParseMoney(price.String(), currency)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	return sql.NullInt64{Int64: money.Amount, Valid: true}, nil
+}
+
+func (s *productVariantService) switchErrProductNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrProductNotFound
+	case constant.ErrVariantExists, constant.ErrVariantSKUTaken:
+		return err
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation product repository")
+		return constant.ErrServer
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVariantRepository struct {
+	repository.ProductRepository
+	product  *model.Product
+	variants map[string]*model.ProductVariant
+	created  []*model.ProductVariant
+}
+
+func (r *fakeVariantRepository) Get(ctx context.Context, id int64) (*model.Product, error) {
+	if id != r.product.ID {
+		return nil, sql.ErrNoRows
+	}
+	return r.product, nil
+}
+
+func (r *fakeVariantRepository) GetVariantBySKU(ctx context.Context, sku string) (*model.ProductVariant, error) {
+	variant, found := r.variants[sku]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	return variant, nil
+}
+
+func (r *fakeVariantRepository) SetOptions(ctx context.Context, productID int64, options []*model.ProductOption, remove, create []*model.ProductVariant) error {
+	r.created = append(r.created, create...)
+	return nil
+}
+
+func (r *fakeVariantRepository) CreateVariant(ctx context.Context, variant *model.ProductVariant) error {
+	r.created = append(r.created, variant)
+	return nil
+}
+
+func TestSetOptionsNumbersCollidingSKUs(t *testing.T) {
+	variants := &fakeVariantRepository{
+		product: &model.Product{ID: 12, MerchantID: 7},
+		variants: map[string]*model.ProductVariant{
+			"P12-BLUE": {ID: 1, ProductID: 3, SKU: "P12-BLUE"},
+		},
+	}
+	s := NewProductVariantService(variants)
+
+	_, err := s.SetOptions(merchantContext(7), model.ProductOptionsSetRequest{
+		ProductID: 12,
+		Options:   []model.ProductOptionRequest{{Name: "Colour", Values: []string{"Red", "red!", "Blue"}}},
+	})
+	require.NoError(t, err)
+
+	skus := []string{}
+	for _, variant := range variants.created {
+		skus = append(skus, variant.SKU)
+	}
+	assert.Equal(t, []string{"P12-RED", "P12-RED-2", "P12-BLUE-2"}, skus)
+}
+
+func TestCreateVariantSKU(t *testing.T) {
+	options := []*model.ProductOption{{Name: "Colour", Values: []string{"Red", "Blue"}}}
+	variants := &fakeVariantRepository{
+		product: &model.Product{ID: 12, MerchantID: 7, Options: options},
+		variants: map[string]*model.ProductVariant{
+			"P12-RED": {ID: 1, ProductID: 3, SKU: "P12-RED"},
+		},
+	}
+	s := NewProductVariantService(variants)
+
+	created, err := s.Create(merchantContext(7), model.ProductVariantCreateRequest{ProductID: 12, Options: map[string]string{"Colour": "Red"}})
+	require.NoError(t, err)
+	assert.Equal(t, "P12-RED-2", created.SKU, "a generated SKU is numbered")
+
+	_, err = s.Create(merchantContext(7), model.ProductVariantCreateRequest{ProductID: 12, Options: map[string]string{"Colour": "Blue"}, SKU: "P12-RED"})
+	assert.Equal(t, constant.ErrVariantSKUTaken, err, "a chosen SKU is not")
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"strings"
+	"time"
+)
+
+type PromotionService interface {
+	Create(ctx context.Context, req model.PromotionCreateRequest) (*model.PromotionResponse, error)
+	List(ctx context.Context, req model.PromotionListRequest) ([]*model.PromotionResponse, *model.PageInfo, error)
+	Get(ctx context.Context, req model.PromotionGetRequest) (*model.PromotionResponse, error)
+	Delete(ctx context.Context, req model.PromotionDeleteRequest) error
+	Evaluate(ctx context.Context, req model.PromotionEvaluateRequest) (*model.PromotionEvaluationResponse, error)
+}
+
+type promotionService struct {
+	promotionRepository repository.PromotionRepository
+	productRepository   repository.ProductRepository
+}
+
+func NewPromotionService(promotionRepository repository.PromotionRepository, productRepository repository.ProductRepository) PromotionService {
+	return &promotionService{promotionRepository, productRepository}
+}
+
+func (s *promotionService) Create(ctx context.Context, req model.PromotionCreateRequest) (*model.PromotionResponse, error) {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	promotion := &model.Promotion{
+		MerchantID:  claimsID,
+		Code:        model.NormalizePromotionCode(req.Code),
+		Type:        req.Type,
+		BuyQuantity: req.BuyQuantity,
+		GetQuantity: req.GetQuantity,
+		Currency:    strings.ToUpper(req.Currency),
+		StartsAt:    time.Now(),
+		CreatedAt:   time.Now(),
+	}
+	if promotion.Currency != "" && !model.IsCurrency(promotion.Currency) {
+		return nil, constant.ErrCurrencyUnsupported
+	}
+	switch {
+	case req.Type == model.PromotionPercentage:
+		promotion.Value = req.Percentage
+	case req.Type == model.PromotionFixed && req.Amount != nil:
+		amount, err := model.ParseMoney(req.Amount.String(), promotion.Currency)
+		if err != nil {
+			return nil, err
+		}
+		promotion.Value = amount.Amount
+	}
+	if req.MinSpend != nil {
+		minSpend, err := model.ParseMoney(req.MinSpend.String(), promotion.Currency)
+		if err != nil {
+			return nil, err
+		}
+		promotion.MinSpend = sql.NullInt64{Int64: minSpend.Amount, Valid: true}
+	}
+	if req.UsageLimit != nil {
+		promotion.UsageLimit = sql.NullInt64{Int64: *req.UsageLimit, Valid: true}
+	}
+	if req.PerCustomerLimit != nil {
+		promotion.PerCustomerLimit = sql.NullInt64{Int64: *req.PerCustomerLimit, Valid: true}
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promotion.EndsAt = sql.NullTime{Time: *req.EndsAt, Valid: true}
+	}
+
+	err := promotion.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	promotion.ProductIDs, err = s.merchantProducts(ctx, claimsID, req.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.promotionRepository.ListByCodes(ctx, []string{promotion.Code}, 0)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to get promotion by code")
+		return nil, constant.ErrServer
+	} else if len(existing) > 0 {
+		return nil, constant.ErrPromotionCodeTaken
+	}
+
+	err = s.promotionRepository.Create(ctx, promotion)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to create promotion")
+		return nil, constant.ErrServer
+	}
+
+	return model.NewPromotionResponse(promotion), nil
+}
+
+// merchantProducts deduplicates productIDs, all of which must be live products of merchantID.
+func (s *promotionService) merchantProducts(ctx context.Context, merchantID int64, productIDs []int64) ([]int64, error) {
+	ids := []int64{}
+	seen := make(map[int64]bool, len(productIDs))
+	for _, id := range productIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	products, err := s.productRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list promotion products")
+		return nil, constant.ErrServer
+	}
+
+	if len(products) != len(ids) {
+		return nil, constant.ErrPromotionProducts
+	}
+	for _, product := range products {
+		if product.MerchantID != merchantID {
+			return nil, constant.ErrPromotionProducts
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *promotionService) List(ctx context.Context, req model.PromotionListRequest) ([]*model.PromotionResponse, *model.PageInfo, error) {
+	if !middleware.IsMerchant(ctx, req.MerchantID) {
+		return nil, nil, constant.ErrUnauthorized
+	}
+
+	promotions, info, err := s.promotionRepository.List(ctx, req.Page, req.MerchantID)
+	if err == constant.ErrInvalidCursor {
+		return nil, nil, err
+	} else if err != nil {
+		logger.Log().Err(err).Msg("failed to list promotions")
+		return nil, nil, constant.ErrServer
+	}
+
+	total, err := s.promotionRepository.Count(ctx, req.MerchantID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to count promotions")
+		return nil, nil, constant.ErrServer
+	}
+	info.SetTotal(total)
+
+	return model.NewPromotionListResponse(promotions), info, nil
+}
+
+func (s *promotionService) Get(ctx context.Context, req model.PromotionGetRequest) (*model.PromotionResponse, error) {
+	promotion, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewPromotionResponse(promotion), nil
+}
+
+// Delete removes the promotion and its redemptions, orders keep the discount they got.
+func (s *promotionService) Delete(ctx context.Context, req model.PromotionDeleteRequest) error {
+	_, err := s.authorize(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.promotionRepository.Delete(ctx, req.ID)
+	if err != nil {
+		return s.switchErrPromotionNotFoundOrErrServer(err)
+	}
+
+	return nil
+}
+
+// Evaluate prices the items with the codes applied without redeeming anything. Anonymous
+// callers are not checked against per-customer limits.
+func (s *promotionService) Evaluate(ctx context.Context, req model.PromotionEvaluateRequest) (*model.PromotionEvaluationResponse, error) {
+	var customerID int64
+	if middleware.HasRole(ctx, constant.ROLE_CUSTOMER) {
+		customerID, _ = middleware.GetClaimsID(ctx)
+	}
+
+	order := new(model.Order)
+	err := addOrderItems(ctx, s.productRepository, order, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation, err := evaluatePromotions(ctx, s.promotionRepository, order.Items, req.Codes, customerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return model.NewPromotionEvaluationResponse(evaluation), nil
+}
+
+func (s *promotionService) authorize(ctx context.Context, id int64) (*model.Promotion, error) {
+	promotion, err := s.promotionRepository.Get(ctx, id)
+	if err != nil {
+		return nil, s.switchErrPromotionNotFoundOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, promotion.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	return promotion, nil
+}
+
+func (s *promotionService) switchErrPromotionNotFoundOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrPromotionNotFound
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation promotion repository")
+		return constant.ErrServer
+	}
+}
+
+// evaluatePromotions loads the promotions behind codes, with the redemptions of customerID,
+// and applies them to items.
+func evaluatePromotions(ctx context.Context, promotionRepository repository.PromotionRepository, items []*model.OrderItem, codes []string, customerID int64, now time.Time) (*model.PromotionEvaluation, error) {
+	normalized := make([]string, len(codes))
+	for i, code := range codes {
+		normalized[i] = model.NormalizePromotionCode(code)
+	}
+
+	promotions, err := promotionRepository.ListByCodes(ctx, normalized, customerID)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to list promotions by code")
+		return nil, constant.ErrServer
+	}
+
+	return model.EvaluatePromotions(items, normalized, promotions, now), nil
+}
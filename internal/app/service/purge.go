@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/storage"
+	"time"
+)
+
+// PurgeService hard-deletes merchants and products that stayed soft-deleted for longer
+// than SoftDeleteRetention.
+type PurgeService interface {
+	Purge(ctx context.Context) error
+}
+
+type purgeService struct {
+	merchantRepository repository.MerchantRepository
+	productRepository  repository.ProductRepository
+	storage            storage.Storage
+}
+
+func NewPurgeService(merchantRepository repository.MerchantRepository, productRepository repository.ProductRepository, storage storage.Storage) PurgeService {
+	return &purgeService{merchantRepository, productRepository, storage}
+}
+
+func (s *purgeService) Purge(ctx context.Context) error {
+	before := time.Now().Add(-config.Cfg().SoftDeleteRetention)
+
+	// products go first, a purged merchant takes all of its products with it
+	products, keys, err := s.productRepository.Purge(ctx, before)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to purge products")
+		return err
+	}
+
+	for _, key := range keys {
+		err := s.storage.Delete(ctx, key)
+		if err != nil {
+			logger.Log().Err(err).Str("key", key).Msg("failed to delete stored image")
+		}
+	}
+
+	merchants, err := s.merchantRepository.Purge(ctx, before)
+	if err != nil {
+		logger.Log().Err(err).Msg("failed to purge merchants")
+		return err
+	}
+
+	if products > 0 || merchants > 0 {
+		logger.Log().Info().Msgf("purged %d merchants and %d products deleted before %s", merchants, products, before.Format(time.RFC3339))
+	}
+	return nil
+}
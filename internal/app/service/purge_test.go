@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// purgeLog records the order the purge steps run in.
+type purgeLog struct {
+	steps  []string
+	before []time.Time
+}
+
+type fakePurgeProductRepository struct {
+	repository.ProductRepository
+	log  *purgeLog
+	keys []string
+}
+
+func (r *fakePurgeProductRepository) Purge(ctx context.Context, before time.Time) (int64, []string, error) {
+	r.log.steps = append(r.log.steps, "products")
+	r.log.before = append(r.log.before, before)
+	return int64(len(r.keys)), r.keys, nil
+}
+
+type fakePurgeMerchantRepository struct {
+	repository.MerchantRepository
+	log *purgeLog
+}
+
+func (r *fakePurgeMerchantRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.log.steps = append(r.log.steps, "merchants")
+	r.log.before = append(r.log.before, before)
+	return 1, nil
+}
+
+type fakePurgeStorage struct {
+	storage.Storage
+	log *purgeLog
+}
+
+func (s *fakePurgeStorage) Delete(ctx context.Context, key string) error {
+	s.log.steps = append(s.log.steps, "delete "+key)
+	return nil
+}
+
+func TestPurge(t *testing.T) {
+	log := &purgeLog{}
+	s := NewPurgeService(
+		&fakePurgeMerchantRepository{log: log},
+		&fakePurgeProductRepository{log: log, keys: []string{"products/1/a.png"}},
+		&fakePurgeStorage{log: log},
+	)
+
+	start := time.Now()
+	assert.NoError(t, s.Purge(context.Background()))
+	assert.Equal(t, []string{"products", "delete products/1/a.png", "merchants"}, log.steps,
+		"products go before their merchants")
+
+	cutoff := start.Add(-config.Cfg().SoftDeleteRetention)
+	assert.Len(t, log.before, 2)
+	for _, before := range log.before {
+		assert.WithinDuration(t, cutoff, before, time.Second, "rows deleted within the retention window stay")
+	}
+	assert.Equal(t, log.before[0], log.before[1], "both purges use the same cutoff")
+}
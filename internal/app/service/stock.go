@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"time"
+)
+
+type StockService interface {
+	Get(ctx context.Context, req model.StockGetRequest) (*model.StockResponse, error)
+	Adjust(ctx context.Context, req model.StockAdjustRequest) (*model.StockResponse, error)
+	Reserve(ctx context.Context, req model.StockReservationCreateRequest) (*model.StockReservationResponse, error)
+	Confirm(ctx context.Context, req model.StockReservationConfirmRequest) (*model.StockReservationResponse, error)
+	Release(ctx context.Context, req model.StockReservationReleaseRequest) (*model.StockReservationResponse, error)
+}
+
+type stockService struct {
+	productRepository repository.ProductRepository
+}
+
+func NewStockService(productRepository repository.ProductRepository) StockService {
+	return &stockService{productRepository}
+}
+
+func (s *stockService) Get(ctx context.Context, req model.StockGetRequest) (*model.StockResponse, error) {
+	stock, err := s.productRepository.GetStock(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrStockOrErrServer(err)
+	}
+
+	return model.NewStockResponse(stock), nil
+}
+
+func (s *stockService) Adjust(ctx context.Context, req model.StockAdjustRequest) (*model.StockResponse, error) {
+	product, err := s.productRepository.Get(ctx, req.ProductID)
+	if err != nil {
+		return nil, s.switchErrStockOrErrServer(err)
+	}
+
+	if !middleware.IsMerchant(ctx, product.MerchantID) {
+		return nil, constant.ErrUnauthorized
+	}
+
+	stock, err := s.productRepository.AdjustStock(ctx, req.ProductID, req.Delta)
+	if err != nil {
+		return nil, s.switchErrStockOrErrServer(err)
+	}
+
+	return model.NewStockResponse(stock), nil
+}
+
+func (s *stockService) Reserve(ctx context.Context, req model.StockReservationCreateRequest) (*model.StockReservationResponse, error) {
+	claimsID, valid := middleware.GetClaimsID(ctx)
+	if !valid {
+		return nil, constant.ErrUnauthorized
+	}
+
+	ttl := config.Cfg().StockReservationTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > config.Cfg().StockReservationMaxTTL {
+		ttl = config.Cfg().StockReservationMaxTTL
+	}
+
+	now := time.Now()
+	reservation := &model.StockReservation{
+		ProductID: req.ProductID,
+		OwnerID:   claimsID,
+		Quantity:  req.Quantity,
+		Status:    model.ReservationPending,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	err := s.productRepository.CreateReservation(ctx, reservation)
+	if err != nil {
+		return nil, s.switchErrStockOrErrServer(err)
+	}
+
+	return model.NewStockReservationResponse(reservation), nil
+}
+
+func (s *stockService) Confirm(ctx context.Context, req model.StockReservationConfirmRequest) (*model.StockReservationResponse, error) {
+	err := s.authorizeReservation(ctx, req.ProductID, req.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.productRepository.ConfirmReservation(ctx, req.ReservationID)
+	if err != nil {
+		return nil, s.switchErrReservationOrErrServer(err)
+	}
+
+	return model.NewStockReservationResponse(reservation), nil
+}
+
+func (s *stockService) Release(ctx context.Context, req model.StockReservationReleaseRequest) (*model.StockReservationResponse, error) {
+	err := s.authorizeReservation(ctx, req.ProductID, req.ReservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	reservation, err := s.productRepository.ReleaseReservation(ctx, req.ReservationID)
+	if err != nil {
+		return nil, s.switchErrReservationOrErrServer(err)
+	}
+
+	return model.NewStockReservationResponse(reservation), nil
+}
+
+func (s *stockService) authorizeReservation(ctx context.Context, productID, reservationID int64) error {
+	reservation, err := s.productRepository.GetReservation(ctx, reservationID)
+	if err != nil {
+		return s.switchErrReservationOrErrServer(err)
+	} else if reservation.ProductID != productID {
+		return constant.ErrReservationNotFound
+	}
+
+	product, err := s.productRepository.Get(ctx, productID)
+	if err != nil {
+		return s.switchErrStockOrErrServer(err)
+	}
+
+	if !middleware.IsCustomer(ctx, reservation.OwnerID) && !middleware.IsMerchant(ctx, product.MerchantID) {
+		return constant.ErrUnauthorized
+	}
+
+	return nil
+}
+
+func (s *stockService) switchErrStockOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrProductNotFound
+	case constant.ErrInsufficientStock:
+		return err
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation stock repository")
+		return constant.ErrServer
+	}
+}
+
+func (s *stockService) switchErrReservationOrErrServer(err error) error {
+	switch err {
+	case sql.ErrNoRows:
+		return constant.ErrReservationNotFound
+	case constant.ErrReservationNotPending, constant.ErrReservationExpired:
+		return err
+	default:
+		logger.Log().Err(err).Msg("failed to execute operation stock repository")
+		return constant.ErrServer
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 )
@@ -13,11 +14,61 @@ type Config struct {
 
 	HttpRateLimitRequest int
 	HttpRateLimitTime    time.Duration
+	TrustedProxies       string
 
-	JwtSecretKey string
-	JwtTTL       time.Duration
+	JwtSecretKey     string
+	JwtSigningMethod string
+	JwtSigningKeyID  string
+	JwtKeyFiles      string
+	JwtTTL           time.Duration
+	JwtRefreshTTL    time.Duration
 
-	PaginationLimit int
+	PaginationLimit        int
+	PaginationCursorSecret string
+	ListCountTTL           time.Duration
+
+	StockReservationTTL    time.Duration
+	StockReservationMaxTTL time.Duration
+
+	SoftDeleteRetention time.Duration
+	PurgeInterval       time.Duration
+
+	PriceRuleInterval time.Duration
+
+	CartTTL time.Duration
+
+	IdempotencyTTL     time.Duration
+	IdempotencyLockTTL time.Duration
+
+	StorageDriver      string
+	StorageLocalDir    string
+	StoragePublicURL   string
+	StorageS3Endpoint  string
+	StorageS3Region    string
+	StorageS3Bucket    string
+	StorageS3AccessKey string
+	StorageS3SecretKey string
+
+	ImageMaxBytes     int64
+	ImageMinDimension int
+	ImageMaxDimension int
+
+	SearchDriver string
+
+	ExchangeRateDriver  string
+	ExchangeRateFile    string
+	ExchangeRateURL     string
+	ExchangeRateBase    string
+	ExchangeRateAPIKey  string
+	ExchangeRateRefresh time.Duration
+	ExchangeRateMaxAge  time.Duration
+
+	PaymentDriver        string
+	PaymentURL           string
+	PaymentAPIKey        string
+	PaymentWebhookSecret string
+	PaymentWebhookMaxAge time.Duration
+	PaymentRetries       int
 
 	MysqlUser            string
 	MysqlPassword        string
@@ -27,6 +78,7 @@ type Config struct {
 	MysqlMaxIdleConns    int
 	MysqlMaxOpenConns    int
 	MysqlConnMaxLifetime time.Duration
+	MysqlMigrateOnStart  bool
 
 	RedisPassword string
 	RedisHost     string
@@ -37,16 +89,58 @@ type Config struct {
 }
 
 func load() Config {
-	if err := godotenv.Load(); err != nil {
+	if err := godotenv.Load(envFile()); err != nil {
 		log.Fatal("Error loading .env file")
 	}
 
 	AppPort, err := strconv.Atoi(os.Getenv("AppPort"))
 	HttpRateLimitRequest, err := strconv.Atoi(os.Getenv("HttpRateLimitRequest"))
 	HttpRateLimitTime,err := time.ParseDuration(os.Getenv("HttpRateLimitTime"))
+	TrustedProxies := os.Getenv("TrustedProxies")
 	JwtSecretKey := os.Getenv("JwtSecretKey")
+	JwtSigningMethod := os.Getenv("JwtSigningMethod")
+	JwtSigningKeyID := os.Getenv("JwtSigningKeyID")
+	JwtKeyFiles := os.Getenv("JwtKeyFiles")
 	JwtTTL, err := time.ParseDuration(os.Getenv("JwtTTL"))
+	JwtRefreshTTL, err := time.ParseDuration(os.Getenv("JwtRefreshTTL"))
 	PaginationLimit, err := strconv.Atoi(os.Getenv("PaginationLimit"))
+	PaginationCursorSecret := os.Getenv("PaginationCursorSecret")
+	ListCountTTL, err := time.ParseDuration(os.Getenv("ListCountTTL"))
+	StockReservationTTL, err := time.ParseDuration(os.Getenv("StockReservationTTL"))
+	StockReservationMaxTTL, err := time.ParseDuration(os.Getenv("StockReservationMaxTTL"))
+	SoftDeleteRetention, err := time.ParseDuration(os.Getenv("SoftDeleteRetention"))
+	PurgeInterval, err := time.ParseDuration(os.Getenv("PurgeInterval"))
+	PriceRuleInterval, err := time.ParseDuration(os.Getenv("PriceRuleInterval"))
+	CartTTL, err := time.ParseDuration(os.Getenv("CartTTL"))
+	IdempotencyTTL, err := time.ParseDuration(os.Getenv("IdempotencyTTL"))
+	IdempotencyLockTTL, err := time.ParseDuration(os.Getenv("IdempotencyLockTTL"))
+	StorageDriver := os.Getenv("StorageDriver")
+	StorageLocalDir := os.Getenv("StorageLocalDir")
+	StoragePublicURL := os.Getenv("StoragePublicURL")
+	StorageS3Endpoint := os.Getenv("StorageS3Endpoint")
+	StorageS3Region := os.Getenv("StorageS3Region")
+	StorageS3Bucket := os.Getenv("StorageS3Bucket")
+	StorageS3AccessKey := os.Getenv("StorageS3AccessKey")
+	StorageS3SecretKey := os.Getenv("StorageS3SecretKey")
+	ImageMaxBytes, err := strconv.ParseInt(os.Getenv("ImageMaxBytes"), 10, 64)
+	ImageMinDimension, err := strconv.Atoi(os.Getenv("ImageMinDimension"))
+	ImageMaxDimension, err := strconv.Atoi(os.Getenv("ImageMaxDimension"))
+	SearchDriver := os.Getenv("SearchDriver")
+
+	ExchangeRateDriver := os.Getenv("ExchangeRateDriver")
+	ExchangeRateFile := os.Getenv("ExchangeRateFile")
+	ExchangeRateURL := os.Getenv("ExchangeRateURL")
+	ExchangeRateBase := os.Getenv("ExchangeRateBase")
+	ExchangeRateAPIKey := os.Getenv("ExchangeRateAPIKey")
+	ExchangeRateRefresh, err := time.ParseDuration(os.Getenv("ExchangeRateRefresh"))
+	ExchangeRateMaxAge, err := time.ParseDuration(os.Getenv("ExchangeRateMaxAge"))
+
+	PaymentDriver := os.Getenv("PaymentDriver")
+	PaymentURL := os.Getenv("PaymentURL")
+	PaymentAPIKey := os.Getenv("PaymentAPIKey")
+	PaymentWebhookSecret := os.Getenv("PaymentWebhookSecret")
+	PaymentWebhookMaxAge, err := time.ParseDuration(os.Getenv("PaymentWebhookMaxAge"))
+	PaymentRetries, err := strconv.Atoi(os.Getenv("PaymentRetries"))
 	MysqlUser := os.Getenv("MysqlUser")
 	MysqlPassword := os.Getenv("MysqlPassword")
 	MysqlHost := os.Getenv("MysqlHost")
@@ -55,6 +149,7 @@ func load() Config {
 	MysqlMaxIdleConns,err := strconv.Atoi(os.Getenv("MysqlMaxIdleConns"))
 	MysqlMaxOpenConns,err := strconv.Atoi(os.Getenv("MysqlMaxOpenConns"))
 	MysqlConnMaxLifetime,err := time.ParseDuration(os.Getenv("MysqlConnMaxLifetime"))
+	MysqlMigrateOnStart, err := strconv.ParseBool(os.Getenv("MysqlMigrateOnStart"))
 	RedisPassword := os.Getenv("RedisPassword")
 	RedisHost := os.Getenv("RedisHost")
 	RedisPort,err := strconv.Atoi(os.Getenv("RedisPort"))
@@ -66,30 +161,96 @@ func load() Config {
 		log.Fatal("error in get value from .env")
 	}
 
+	// anyone could forge cursors signed with an empty secret
+	if PaginationCursorSecret == "" {
+		log.Fatal("PaginationCursorSecret must be set in .env")
+	}
+
 	return Config{
-		AppPort:              AppPort,
-		HttpRateLimitRequest: HttpRateLimitRequest,
-		HttpRateLimitTime:    HttpRateLimitTime,
-		JwtSecretKey:         JwtSecretKey,
-		JwtTTL:               JwtTTL,
-		PaginationLimit:      PaginationLimit,
-		MysqlUser:            MysqlUser,
-		MysqlPassword:        MysqlPassword,
-		MysqlHost:            MysqlHost,
-		MysqlPort:            MysqlPort,
-		MysqlDatabase:        MysqlDatabase,
-		MysqlMaxIdleConns:    MysqlMaxIdleConns,
-		MysqlMaxOpenConns:    MysqlMaxOpenConns,
-		MysqlConnMaxLifetime: MysqlConnMaxLifetime,
-		RedisPassword:        RedisPassword,
-		RedisHost:            RedisHost,
-		RedisPort:            RedisPort,
-		RedisDatabase:        RedisDatabase,
-		RedisPoolSize:        RedisPoolSize,
-		RedisTTL:             RedisTTL,
+		AppPort:                AppPort,
+		HttpRateLimitRequest:   HttpRateLimitRequest,
+		HttpRateLimitTime:      HttpRateLimitTime,
+		TrustedProxies:         TrustedProxies,
+		JwtSecretKey:           JwtSecretKey,
+		JwtSigningMethod:       JwtSigningMethod,
+		JwtSigningKeyID:        JwtSigningKeyID,
+		JwtKeyFiles:            JwtKeyFiles,
+		JwtTTL:                 JwtTTL,
+		JwtRefreshTTL:          JwtRefreshTTL,
+		PaginationLimit:        PaginationLimit,
+		PaginationCursorSecret: PaginationCursorSecret,
+		ListCountTTL:           ListCountTTL,
+		StockReservationTTL:    StockReservationTTL,
+		StockReservationMaxTTL: StockReservationMaxTTL,
+		SoftDeleteRetention:    SoftDeleteRetention,
+		PurgeInterval:          PurgeInterval,
+		PriceRuleInterval:      PriceRuleInterval,
+		CartTTL:                CartTTL,
+		IdempotencyTTL:         IdempotencyTTL,
+		IdempotencyLockTTL:     IdempotencyLockTTL,
+		StorageDriver:          StorageDriver,
+		StorageLocalDir:        StorageLocalDir,
+		StoragePublicURL:       StoragePublicURL,
+		StorageS3Endpoint:      StorageS3Endpoint,
+		StorageS3Region:        StorageS3Region,
+		StorageS3Bucket:        StorageS3Bucket,
+		StorageS3AccessKey:     StorageS3AccessKey,
+		StorageS3SecretKey:     StorageS3SecretKey,
+		ImageMaxBytes:          ImageMaxBytes,
+		ImageMinDimension:      ImageMinDimension,
+		ImageMaxDimension:      ImageMaxDimension,
+		SearchDriver:           SearchDriver,
+		ExchangeRateDriver:     ExchangeRateDriver,
+		ExchangeRateFile:       ExchangeRateFile,
+		ExchangeRateURL:        ExchangeRateURL,
+		ExchangeRateBase:       ExchangeRateBase,
+		ExchangeRateAPIKey:     ExchangeRateAPIKey,
+		ExchangeRateRefresh:    ExchangeRateRefresh,
+		ExchangeRateMaxAge:     ExchangeRateMaxAge,
+		PaymentDriver:          PaymentDriver,
+		PaymentURL:             PaymentURL,
+		PaymentAPIKey:          PaymentAPIKey,
+		PaymentWebhookSecret:   PaymentWebhookSecret,
+		PaymentWebhookMaxAge:   PaymentWebhookMaxAge,
+		PaymentRetries:         PaymentRetries,
+		MysqlUser:              MysqlUser,
+		MysqlPassword:          MysqlPassword,
+		MysqlHost:              MysqlHost,
+		MysqlPort:              MysqlPort,
+		MysqlDatabase:          MysqlDatabase,
+		MysqlMaxIdleConns:      MysqlMaxIdleConns,
+		MysqlMaxOpenConns:      MysqlMaxOpenConns,
+		MysqlConnMaxLifetime:   MysqlConnMaxLifetime,
+		MysqlMigrateOnStart:    MysqlMigrateOnStart,
+		RedisPassword:          RedisPassword,
+		RedisHost:              RedisHost,
+		RedisPort:              RedisPort,
+		RedisDatabase:          RedisDatabase,
+		RedisPoolSize:          RedisPoolSize,
+		RedisTTL:               RedisTTL,
+	}
+}
+
+// envFile finds the .env of the working directory or the closest of its parents, so
+// tests running in a package directory read the one at the root of the module.
+func envFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ".env"
+	}
+	for {
+		path := filepath.Join(dir, ".env")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ".env"
+		}
+		dir = parent
 	}
 }
 
 var config = load()
 
-func Cfg() *Config { return &config }
\ No newline at end of file
+func Cfg() *Config { return &config }
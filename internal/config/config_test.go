@@ -11,8 +11,32 @@ func TestConfig(t *testing.T) {
 	assert.NotZero(t, Cfg().HttpRateLimitRequest, "HTTP_RATE_LIMIT_REQUEST")
 	assert.NotEmpty(t, Cfg().HttpRateLimitTime, "HTTP_RATE_LIMIT_TIME")
 	assert.NotEmpty(t, Cfg().JwtSecretKey, "JWT_SECRET_KEY")
+	assert.NotEmpty(t, Cfg().JwtSigningMethod, "JWT_SIGNING_METHOD")
+	assert.NotEmpty(t, Cfg().JwtSigningKeyID, "JWT_SIGNING_KEY_ID")
 	assert.NotEmpty(t, Cfg().JwtTTL, "JWT_TTL")
+	assert.NotEmpty(t, Cfg().JwtRefreshTTL, "JWT_REFRESH_TTL")
 	assert.NotZero(t, Cfg().PaginationLimit, "PAGINATION_LIMIT")
+	assert.NotEmpty(t, Cfg().PaginationCursorSecret, "PAGINATION_CURSOR_SECRET")
+	assert.NotZero(t, Cfg().ListCountTTL, "LIST_COUNT_TTL")
+	assert.NotZero(t, Cfg().StockReservationTTL, "STOCK_RESERVATION_TTL")
+	assert.NotZero(t, Cfg().StockReservationMaxTTL, "STOCK_RESERVATION_MAX_TTL")
+	assert.NotZero(t, Cfg().SoftDeleteRetention, "SOFT_DELETE_RETENTION")
+	assert.NotZero(t, Cfg().PurgeInterval, "PURGE_INTERVAL")
+	assert.NotZero(t, Cfg().PriceRuleInterval, "PRICE_RULE_INTERVAL")
+	assert.NotZero(t, Cfg().CartTTL, "CART_TTL")
+	assert.NotZero(t, Cfg().IdempotencyTTL, "IDEMPOTENCY_TTL")
+	assert.NotZero(t, Cfg().IdempotencyLockTTL, "IDEMPOTENCY_LOCK_TTL")
+	assert.NotEmpty(t, Cfg().StorageDriver, "STORAGE_DRIVER")
+	assert.NotZero(t, Cfg().ImageMaxBytes, "IMAGE_MAX_BYTES")
+	assert.NotZero(t, Cfg().ImageMinDimension, "IMAGE_MIN_DIMENSION")
+	assert.NotZero(t, Cfg().ImageMaxDimension, "IMAGE_MAX_DIMENSION")
+	assert.NotEmpty(t, Cfg().SearchDriver, "SEARCH_DRIVER")
+	assert.NotEmpty(t, Cfg().ExchangeRateDriver, "EXCHANGE_RATE_DRIVER")
+	assert.NotZero(t, Cfg().ExchangeRateRefresh, "EXCHANGE_RATE_REFRESH")
+	assert.NotZero(t, Cfg().ExchangeRateMaxAge, "EXCHANGE_RATE_MAX_AGE")
+	assert.NotEmpty(t, Cfg().PaymentDriver, "PAYMENT_DRIVER")
+	assert.NotEmpty(t, Cfg().PaymentWebhookSecret, "PAYMENT_WEBHOOK_SECRET")
+	assert.NotZero(t, Cfg().PaymentWebhookMaxAge, "PAYMENT_WEBHOOK_MAX_AGE")
 	assert.NotEmpty(t, Cfg().MysqlUser, "MYSQL_USER")
 	assert.NotEmpty(t, Cfg().MysqlPassword, "MYSQL_PASSWORD")
 	assert.NotEmpty(t, Cfg().MysqlHost, "MYSQL_HOST")
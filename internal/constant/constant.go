@@ -1,5 +1,21 @@
 package constant
 
 const (
-	API_KEY_HEADER = "X-API-Key"
-)
\ No newline at end of file
+	API_KEY_HEADER    = "X-API-Key"
+	CART_TOKEN_HEADER = "X-Cart-Token"
+
+	PAYMENT_SIGNATURE_HEADER = "Payment-Signature"
+
+	IDEMPOTENCY_KEY_HEADER     = "Idempotency-Key"
+	IDEMPOTENT_REPLAYED_HEADER = "Idempotent-Replayed"
+
+	ETAG_HEADER          = "ETag"
+	IF_MATCH_HEADER      = "If-Match"
+	IF_NONE_MATCH_HEADER = "If-None-Match"
+
+	ACCEPT_PATCH_HEADER    = "Accept-Patch"
+	MERGE_PATCH_MEDIA_TYPE = "application/merge-patch+json"
+
+	ROLE_MERCHANT = "merchant"
+	ROLE_CUSTOMER = "customer"
+)
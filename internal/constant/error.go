@@ -13,15 +13,98 @@ var (
 	ErrUrlPathParameter  = errors.New("Invalid url path parameter")
 	ErrUrlQueryParameter = errors.New("Invalid url query parameter")
 	ErrRequestBody       = errors.New("Invalid request body")
+	ErrRequestTooLarge   = errors.New("Request body is too large")
 	ErrUnauthorized      = errors.New("You are not authorized to perform this action")
+	ErrForbiddenRole     = errors.New("Your account type cannot perform this action")
 	ErrFieldValidation   = errors.New("Field is not valid")
+	ErrInvalidCursor     = errors.New("Invalid pagination cursor")
+
+	ErrPreconditionFailed = errors.New("Resource was modified since it was read, fetch it again and retry")
+	ErrPatchMediaType     = errors.New("Patch must be sent as application/merge-patch+json")
 
 	ErrMerchantNotFound   = errors.New("Merchant not found")
 	ErrEmailRegistered    = errors.New("Email already in use")
 	ErrEmailNotRegistered = errors.New("Email not registered")
 	ErrWrongPassword      = errors.New("Password incorrect")
 
-	ErrProductNotFound = errors.New("Product not found")
+	ErrInvalidRefreshToken = errors.New("Refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("Refresh token was already used, please login again")
+
+	ErrCustomerNotFound = errors.New("Customer not found")
+
+	ErrProductNotFound        = errors.New("Product not found")
+	ErrProductMerchantDeleted = errors.New("Restore the merchant before restoring its products")
+
+	ErrPriceInvalid        = errors.New("Price must be a plain decimal number")
+	ErrPriceNegative       = errors.New("Price cannot be negative")
+	ErrPricePrecision      = errors.New("Price has more decimal places than its currency allows")
+	ErrCurrencyUnsupported = errors.New("Currency is not a supported ISO 4217 code")
+
+	ErrExchangeRateUnavailable = errors.New("Exchange rates are currently unavailable")
+	ErrExchangeRateCurrency    = errors.New("No exchange rate is quoted for the currency")
+
+	ErrPriceNotFound = errors.New("Product had no price at that time")
+
+	ErrPriceRuleNotFound = errors.New("Price rule not found")
+	ErrPriceRuleInvalid  = errors.New("Price rule needs either a sale price or a percentage, and an end in the future")
+	ErrPriceRuleOverlap  = errors.New("Price rule overlaps another rule of the product")
+	ErrPriceRuleCurrency = errors.New("Remove scheduled sale prices before changing the product currency")
+
+	ErrProductOptionsInvalid = errors.New("Option names and values must be non-empty and unique")
+	ErrTooManyVariants       = errors.New("Options would generate too many variants")
+	ErrProductOptionsTooLong = errors.New("Option names and values are too long to combine into variants")
+	ErrVariantNotFound       = errors.New("Variant not found")
+	ErrVariantOptionsInvalid = errors.New("Variant must pick one value of every product option")
+	ErrVariantExists         = errors.New("A variant with these options already exists")
+	ErrVariantSKUTaken       = errors.New("Variant SKU already in use")
+	ErrVariantPriceCurrency  = errors.New("Clear variant price overrides before changing the product currency")
+
+	ErrImageNotFound     = errors.New("Image not found")
+	ErrImageTooLarge     = errors.New("Image exceeds the maximum upload size")
+	ErrImageUnsupported  = errors.New("Image must be a JPEG, PNG or GIF")
+	ErrImageDimensions   = errors.New("Image dimensions are out of the allowed range")
+	ErrImageOrderInvalid = errors.New("Image ids must list every image of the product exactly once")
+
+	ErrCategoryNotFound       = errors.New("Category not found")
+	ErrCategoryParentNotFound = errors.New("Parent category not found")
+	ErrCategorySlugTaken      = errors.New("Category slug already in use")
+	ErrCategoryInvalidSlug    = errors.New("Category slug may only contain lowercase letters, digits and single dashes")
+	ErrCategoryCycle          = errors.New("Category cannot be placed under itself or its descendants")
+	ErrCategoryHasChildren    = errors.New("Category still has child categories")
+	ErrCategoryInUse          = errors.New("Category is used by products of other merchants")
+
+	ErrInsufficientStock     = errors.New("Insufficient stock")
+	ErrReservationNotFound   = errors.New("Reservation not found")
+	ErrReservationNotPending = errors.New("Reservation is no longer pending")
+	ErrReservationExpired    = errors.New("Reservation has expired")
+
+	ErrOrderNotFound          = errors.New("Order not found")
+	ErrOrderProductNotFound   = errors.New("Ordered product not found")
+	ErrInvalidOrderTransition = errors.New("Order cannot move to the requested status")
+	ErrOrderCurrencyMismatch  = errors.New("Ordered products must share a currency")
+	ErrOrderNotPayable        = errors.New("Order is not awaiting payment")
+	ErrOrderPaymentPending    = errors.New("Order cannot be cancelled while a payment is in progress")
+
+	ErrPaymentProvider  = errors.New("Payment provider could not process the payment")
+	ErrPaymentSignature = errors.New("Payment webhook signature is invalid")
+
+	ErrIdempotencyKeyInvalid  = errors.New("Idempotency-Key must be at most 255 characters")
+	ErrIdempotencyKeyReused   = errors.New("Idempotency-Key was already used for a different request")
+	ErrIdempotencyKeyInFlight = errors.New("A request with this Idempotency-Key is still being processed")
+
+	ErrCartItemNotFound = errors.New("Product is not in the cart")
+	ErrCartFull         = errors.New("Cart cannot hold more products")
+
+	ErrPromotionNotFound      = errors.New("Promotion not found")
+	ErrPromotionCodeTaken     = errors.New("Promotion code already in use")
+	ErrPromotionCodeInvalid   = errors.New("Promotion code may only contain letters, digits, dashes and underscores")
+	ErrPromotionInvalid       = errors.New("Promotion is missing the fields its type needs or has an invalid period")
+	ErrPromotionProducts      = errors.New("Promotions can only target products of their merchant")
+	ErrPromotionInactive      = errors.New("Promotion is not active at this time")
+	ErrPromotionUsedUp        = errors.New("Promotion has reached its usage limit")
+	ErrPromotionCustomerLimit = errors.New("Promotion was already used the maximum number of times")
+	ErrPromotionMinSpend      = errors.New("Items do not reach the promotion's minimum spend")
+	ErrPromotionNotApplicable = errors.New("Promotion does not apply to these items")
 )
 
 func NewErrFieldValidation(err validator.FieldError) error {
@@ -0,0 +1,464 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const (
+	migrationLockName    = "schema_migrations"
+	migrationLockTimeout = 60
+)
+
+var (
+	ErrMigrationLocked   = errors.New("another process is running migrations")
+	ErrMigrationChecksum = errors.New("applied migration does not match its source file")
+	ErrMigrationDirty    = errors.New("a previous migration failed halfway, fix the schema by hand and run migrate force")
+	ErrMigrationUnknown  = errors.New("applied migration has no source file")
+	ErrMigrationNotDirty = errors.New("migration is not dirty")
+
+	migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt sql.NullTime
+}
+
+type Migrator interface {
+	Up(ctx context.Context) error
+	Down(ctx context.Context, steps int) error
+	Status(ctx context.Context) ([]*MigrationStatus, error)
+	Force(ctx context.Context, version int64, applied bool) error
+}
+
+func NewMigrator(client Client) (Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &migrator{client, migrations}, nil
+}
+
+type migrator struct {
+	client     Client
+	migrations []*Migration
+}
+
+type appliedMigration struct {
+	Version   int64
+	Checksum  string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+func (m *migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			logger.Log().Info().Msgf("applying migration %d_%s", migration.Version, migration.Name)
+
+			_, err = conn.ExecContext(ctx, `
+			INSERT INTO
+				schema_migrations (version, name, checksum, dirty, applied_at)
+			VALUES
+				(?, ?, ?, 1, ?)
+			`, migration.Version, migration.Name, migration.Checksum, time.Now())
+			if err != nil {
+				return err
+			}
+
+			err = execStatements(ctx, conn, migration.Up)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = conn.ExecContext(ctx, `
+			UPDATE
+				schema_migrations
+			SET
+				dirty = 0
+			WHERE
+				version = ?
+			`, migration.Version)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			logger.Log().Info().Msgf("reverting migration %d_%s", migration.Version, migration.Name)
+
+			_, err = conn.ExecContext(ctx, `
+			UPDATE
+				schema_migrations
+			SET
+				dirty = 1
+			WHERE
+				version = ?
+			`, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			err = execStatements(ctx, conn, migration.Down)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = conn.ExecContext(ctx, `
+			DELETE FROM
+				schema_migrations
+			WHERE
+				version = ?
+			`, migration.Version)
+			if err != nil {
+				return err
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Status reads the applied migrations without taking the migration lock, so it works
+// while migrations run, and without creating the bookkeeping table.
+func (m *migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	conn, err := m.client.Conn().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var tables int
+	err = conn.QueryRowContext(ctx, `
+	SELECT
+		COUNT(*)
+	FROM
+		information_schema.tables
+	WHERE
+		table_schema = DATABASE() AND table_name = 'schema_migrations'
+	`).Scan(&tables)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]*appliedMigration)
+	if tables > 0 {
+		applied, err = m.applied(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []*MigrationStatus
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if a, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.Dirty = a.Dirty
+			status.AppliedAt = sql.NullTime{Time: a.AppliedAt, Valid: true}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Force clears the dirty flag left by a migration that failed halfway, once its schema
+// changes were completed or undone by hand: applied records it as applied, otherwise it
+// is recorded as never run.
+func (m *migrator) Force(ctx context.Context, version int64, applied bool) error {
+	var migration *Migration
+	for _, known := range m.migrations {
+		if known.Version == version {
+			migration = known
+		}
+	}
+	if migration == nil {
+		return fmt.Errorf("version %d: %w", version, ErrMigrationUnknown)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		query := `
+		UPDATE
+			schema_migrations
+		SET
+			dirty = 0, checksum = ?, applied_at = ?
+		WHERE
+			version = ? AND dirty = 1
+		`
+		args := []interface{}{migration.Checksum, time.Now(), version}
+		if !applied {
+			query = `
+			DELETE FROM
+				schema_migrations
+			WHERE
+				version = ? AND dirty = 1
+			`
+			args = []interface{}{version}
+		}
+
+		res, err := conn.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		} else if affected == 0 {
+			return fmt.Errorf("version %d: %w", version, ErrMigrationNotDirty)
+		}
+
+		logger.Log().Info().Msgf("forced migration %d_%s to applied=%t", migration.Version, migration.Name, applied)
+		return nil
+	})
+}
+
+func (m *migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.client.Conn().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, migrationLockName, migrationLockTimeout).Scan(&locked)
+	if err != nil {
+		return err
+	} else if !locked.Valid || locked.Int64 != 1 {
+		return ErrMigrationLocked
+	}
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, migrationLockName)
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations
+	(
+		version    bigint(20)   NOT NULL,
+		name       varchar(255) NOT NULL,
+		checksum   char(64)     NOT NULL,
+		dirty      tinyint(1)   NOT NULL DEFAULT 0,
+		applied_at datetime     NOT NULL,
+		PRIMARY KEY (version)
+	) ENGINE = InnoDB
+	`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]*appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `
+	SELECT
+		version, checksum, dirty, applied_at
+	FROM
+		schema_migrations
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]*appliedMigration)
+	for rows.Next() {
+		a := new(appliedMigration)
+		err := rows.Scan(&a.Version, &a.Checksum, &a.Dirty, &a.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[a.Version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *migrator) verify(ctx context.Context, conn *sql.Conn) (map[int64]*appliedMigration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, a := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("version %d: %w", version, ErrMigrationUnknown)
+		} else if a.Dirty {
+			return nil, fmt.Errorf("version %d: %w", version, ErrMigrationDirty)
+		} else if a.Checksum != migration.Checksum {
+			return nil, fmt.Errorf("version %d: %w", version, ErrMigrationChecksum)
+		}
+	}
+
+	return applied, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names", version)
+		}
+
+		switch match[3] {
+		case "up":
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		case "down":
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func execStatements(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range splitStatements(script) {
+		_, err := conn.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements breaks a script on semicolons that are not inside quotes or comments,
+// since the driver is not opened with multiStatements.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
+	)
+
+	flush := func() {
+		statement := strings.TrimSpace(current.String())
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "-- "), c == '#':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
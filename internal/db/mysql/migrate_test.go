@@ -0,0 +1,110 @@
+package mysql
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- a comment; with a semicolon
+CREATE TABLE a (id int); # another; comment
+INSERT INTO a VALUES ('x;y'), ("it\'s; fine"), ('it''s');
+ALTER TABLE ` + "`a;b`" + ` ADD COLUMN c int;;
+`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (id int)",
+		`INSERT INTO a VALUES ('x;y'), ("it\'s; fine"), ('it''s')`,
+		"ALTER TABLE `a;b` ADD COLUMN c int",
+	}, splitStatements(script))
+
+	assert.Empty(t, splitStatements("-- only a comment\n  \n"))
+	assert.Equal(t, []string{"SELECT 1"}, splitStatements("SELECT 1"), "the last statement needs no semicolon")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/000002_add_b.up.sql":      {Data: []byte("ALTER TABLE a ADD b int;")},
+		"migrations/000002_add_b.down.sql":    {Data: []byte("ALTER TABLE a DROP b;")},
+		"migrations/000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+		"migrations/000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"migrations/000010_add_c.up.sql":      {Data: []byte("ALTER TABLE a ADD c int;")},
+		"migrations/000010_add_c.down.sql":    {Data: []byte("ALTER TABLE a DROP c;")},
+	}
+
+	migrations, err := loadMigrations(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []int64{1, 2, 10}, []int64{migrations[0].Version, migrations[1].Version, migrations[2].Version},
+		"ordered by version, gaps allowed")
+	assert.Equal(t, "create_a", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE a;", migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+}
+
+func TestLoadMigrationsRejects(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"migrations/000001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id int);")},
+		},
+		"conflicting names": {
+			"migrations/000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id int);")},
+			"migrations/000001_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+		},
+		"bad file name": {
+			"migrations/create_a.sql": {Data: []byte("CREATE TABLE a (id int);")},
+		},
+	}
+	for name, fsys := range cases {
+		_, err := loadMigrations(fsys)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	require.NoError(t, err)
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "embedded migrations have no gaps")
+	}
+}
+
+// appliedChecksums pins the up files databases may have applied, Up refuses to start when
+// one no longer matches. Schema changes go into a new migration, add its checksum here.
+var appliedChecksums = map[int64]string{
+	1:  "4722032f3a8696e9df44574dfbbdff7010e38824bf427f3d0e1dd8e3bacc3b3f",
+	2:  "db7f3a55ae12d6dd55a3a5ee895a9d3b95465698e6ec62bfad5c7e9e91241af7",
+	3:  "02beafa10f10f1583dac0cc873b8c25949043f880d99ea7e065bcbd6826f3d34",
+	4:  "3bb79171c5fb8d15fd6e8f128e2734a3ba9b63d4b8e2398ad6e9f4ad032d85ea",
+	5:  "61e05f0afaa71c880804242a621c763661df3b4fe8c1347c5e3903c15dd78b27",
+	6:  "ee77ebc9dff77fd653c62fca4525aa81acc8ac824db40279c549ae59d3efedd3",
+	7:  "d33138e8c4d90e025d76ecbcd0c830c5f23f8b75d09ac17726c634eebae991f3",
+	8:  "a917037de22eb77fc88b45b2d7a87c359fee2f9c6fa7eef5a1ace37daf13caec",
+	9:  "4348a6deb9dfc6a538e5058cb20a5cb18eb532f927bfa112266c45164ca043fd",
+	10: "08451c5420a7fd3b3da6028a3513a4c11fd66024f37458ead0db911b482ee785",
+	11: "e4184f0fa81afe7c99a9db7e24040597049ea9a9cb8e3d01cbaa054580580f13",
+	12: "92ac0c3410ecdce12f42c627efc95068eabde0df9e89c13c2e1112eec413acf7",
+	13: "21eba7789d75a2f3ef186745fa359f53e1cb0a23014b562299d41550968e0709",
+	14: "5fc5c3a6f04ef8e21d8ad10ec06e82e4af30a02330bd98482484a75691b7cd66",
+	15: "061b296c765980e62f5d3b274692c9b74e5dee3421160ca85042aed83f2ab63f",
+	16: "3c2510db4e41c67b1741ba0ce4db1b663b47ff21fcf0fbdd71cadf8673f1a9a6",
+	17: "742f0639bd61f3e3fce665a5457cd676fd6f7647c77993a758a350d1b5a1bf16",
+	18: "f71864a4de2958254f9a1822e8dd75cf26952664ccbc7abba04757d9e82cbf2a",
+	19: "12e525ab4dc4c42391e1fe6e10533f8cbad6d8069c6b7e9a211ba9e2cb3b1a42",
+	20: "84cd64bfd44660092c54471bd154449544f0d82e342cc176c6368b2e1f01c191",
+}
+
+func TestEmbeddedMigrationsUnchanged(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	require.NoError(t, err)
+	for _, migration := range migrations {
+		checksum, found := appliedChecksums[migration.Version]
+		if assert.True(t, found, "migration %d is missing from appliedChecksums", migration.Version) {
+			assert.Equal(t, checksum, migration.Checksum, "migration %d_%s was edited after it was applied", migration.Version, migration.Name)
+		}
+	}
+}
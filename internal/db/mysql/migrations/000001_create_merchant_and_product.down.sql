@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `product`;
+
+DROP TABLE IF EXISTS `merchant`;
@@ -1,4 +1,4 @@
-CREATE TABLE `merchant`
+CREATE TABLE IF NOT EXISTS `merchant`
 (
     `id`         bigint(20)   NOT NULL AUTO_INCREMENT,
     `name`       varchar(255) NOT NULL,
@@ -11,7 +11,7 @@ CREATE TABLE `merchant`
 ) ENGINE = InnoDB;
 
 
-CREATE TABLE `product`
+CREATE TABLE IF NOT EXISTS `product`
 (
     `id`          bigint(20)   NOT NULL AUTO_INCREMENT,
     `name`        varchar(255) NOT NULL,
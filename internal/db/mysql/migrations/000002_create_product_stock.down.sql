@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `stock_reservation`;
+
+DROP TABLE IF EXISTS `product_stock`;
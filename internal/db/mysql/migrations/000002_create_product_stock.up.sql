@@ -0,0 +1,25 @@
+CREATE TABLE `product_stock`
+(
+    `product_id` bigint(20) NOT NULL,
+    `on_hand`    int(11)    NOT NULL DEFAULT 0,
+    `reserved`   int(11)    NOT NULL DEFAULT 0,
+    `updated_at` datetime            DEFAULT NULL,
+    PRIMARY KEY (`product_id`),
+    CONSTRAINT `product_stock_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+
+CREATE TABLE `stock_reservation`
+(
+    `id`         bigint(20)  NOT NULL AUTO_INCREMENT,
+    `product_id` bigint(20)  NOT NULL,
+    `owner_id`   bigint(20)  NOT NULL,
+    `quantity`   int(11)     NOT NULL,
+    `status`     varchar(16) NOT NULL,
+    `expires_at` datetime    NOT NULL,
+    `created_at` datetime    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime             DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `product_status_expires_at` (`product_id`, `status`, `expires_at`),
+    CONSTRAINT `stock_reservation_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
@@ -0,0 +1,27 @@
+CREATE TABLE `sales_order`
+(
+    `id`         bigint(20)  NOT NULL AUTO_INCREMENT,
+    `buyer_id`   bigint(20)  NOT NULL,
+    `status`     varchar(16) NOT NULL,
+    `total`      float       NOT NULL,
+    `created_at` datetime    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime             DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `buyer_id` (`buyer_id`)
+) ENGINE = InnoDB;
+
+
+CREATE TABLE `order_item`
+(
+    `id`          bigint(20)   NOT NULL AUTO_INCREMENT,
+    `order_id`    bigint(20)   NOT NULL,
+    `product_id`  bigint(20)   NOT NULL,
+    `merchant_id` bigint(20)   NOT NULL,
+    `name`        varchar(255) NOT NULL,
+    `unit_price`  float        NOT NULL,
+    `quantity`    int(11)      NOT NULL,
+    `subtotal`    float        NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `merchant_id` (`merchant_id`),
+    CONSTRAINT `order_item_order_id` FOREIGN KEY (`order_id`) REFERENCES `sales_order` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
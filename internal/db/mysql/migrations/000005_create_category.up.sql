@@ -0,0 +1,25 @@
+CREATE TABLE `category`
+(
+    `id`         bigint(20)   NOT NULL AUTO_INCREMENT,
+    `parent_id`  bigint(20)            DEFAULT NULL,
+    `name`       varchar(255) NOT NULL,
+    `slug`       varchar(255) NOT NULL,
+    `position`   int(11)      NOT NULL DEFAULT 0,
+    `created_at` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime              DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `slug` (`slug`),
+    KEY `parent_id_position` (`parent_id`, `position`),
+    CONSTRAINT `category_parent_id` FOREIGN KEY (`parent_id`) REFERENCES `category` (`id`)
+) ENGINE = InnoDB;
+
+
+CREATE TABLE `product_category`
+(
+    `product_id`  bigint(20) NOT NULL,
+    `category_id` bigint(20) NOT NULL,
+    PRIMARY KEY (`product_id`, `category_id`),
+    KEY `category_id` (`category_id`),
+    CONSTRAINT `product_category_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `product_category_category_id` FOREIGN KEY (`category_id`) REFERENCES `category` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
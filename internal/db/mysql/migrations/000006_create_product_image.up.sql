@@ -0,0 +1,16 @@
+CREATE TABLE `product_image`
+(
+    `id`           bigint(20)   NOT NULL AUTO_INCREMENT,
+    `product_id`   bigint(20)   NOT NULL,
+    `storage_key`  varchar(512) NOT NULL,
+    `url`          varchar(1024) NOT NULL,
+    `content_type` varchar(64)  NOT NULL,
+    `size`         bigint(20)   NOT NULL,
+    `width`        int(11)      NOT NULL,
+    `height`       int(11)      NOT NULL,
+    `position`     int(11)      NOT NULL,
+    `created_at`   datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `product_id_position` (`product_id`, `position`),
+    CONSTRAINT `product_image_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
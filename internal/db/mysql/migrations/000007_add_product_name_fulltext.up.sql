@@ -0,0 +1,2 @@
+ALTER TABLE `product`
+    ADD FULLTEXT INDEX `ft_product_name` (`name`) WITH PARSER ngram;
@@ -0,0 +1,5 @@
+ALTER TABLE `product`
+    DROP INDEX `created_at_id`;
+
+ALTER TABLE `merchant`
+    DROP INDEX `created_at_id`;
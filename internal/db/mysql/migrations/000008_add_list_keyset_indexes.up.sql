@@ -0,0 +1,5 @@
+ALTER TABLE `merchant`
+    ADD INDEX `created_at_id` (`created_at`, `id`);
+
+ALTER TABLE `product`
+    ADD INDEX `created_at_id` (`created_at`, `id`);
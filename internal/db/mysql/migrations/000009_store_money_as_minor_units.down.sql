@@ -0,0 +1,23 @@
+ALTER TABLE `order_item`
+    MODIFY `unit_price` float NOT NULL,
+    MODIFY `subtotal`   float NOT NULL;
+
+UPDATE `order_item`
+SET `unit_price` = `unit_price` / 100,
+    `subtotal`   = `subtotal` / 100;
+
+
+ALTER TABLE `sales_order`
+    DROP COLUMN `currency`,
+    MODIFY `total` float NOT NULL;
+
+UPDATE `sales_order`
+SET `total` = `total` / 100;
+
+
+ALTER TABLE `product`
+    DROP COLUMN `currency`,
+    MODIFY `price` float NOT NULL;
+
+UPDATE `product`
+SET `price` = `price` / 100;
@@ -0,0 +1,47 @@
+ALTER TABLE `product`
+    ADD COLUMN `price_minor` bigint(20) NOT NULL DEFAULT 0 AFTER `price`,
+    ADD COLUMN `currency`    char(3)    NOT NULL DEFAULT 'IDR' AFTER `price_minor`;
+
+UPDATE `product`
+SET `price_minor` = ROUND(`price` * 100);
+
+ALTER TABLE `product`
+    DROP COLUMN `price`;
+
+ALTER TABLE `product`
+    RENAME COLUMN `price_minor` TO `price`,
+    ALTER COLUMN `price` DROP DEFAULT;
+
+
+ALTER TABLE `sales_order`
+    ADD COLUMN `total_minor` bigint(20) NOT NULL DEFAULT 0 AFTER `total`,
+    ADD COLUMN `currency`    char(3)    NOT NULL DEFAULT 'IDR' AFTER `total_minor`;
+
+UPDATE `sales_order`
+SET `total_minor` = ROUND(`total` * 100);
+
+ALTER TABLE `sales_order`
+    DROP COLUMN `total`;
+
+ALTER TABLE `sales_order`
+    RENAME COLUMN `total_minor` TO `total`,
+    ALTER COLUMN `total` DROP DEFAULT;
+
+
+ALTER TABLE `order_item`
+    ADD COLUMN `unit_price_minor` bigint(20) NOT NULL DEFAULT 0 AFTER `unit_price`,
+    ADD COLUMN `subtotal_minor`   bigint(20) NOT NULL DEFAULT 0 AFTER `subtotal`;
+
+UPDATE `order_item`
+SET `unit_price_minor` = ROUND(`unit_price` * 100),
+    `subtotal_minor`   = ROUND(`unit_price` * 100) * `quantity`;
+
+ALTER TABLE `order_item`
+    DROP COLUMN `unit_price`,
+    DROP COLUMN `subtotal`;
+
+ALTER TABLE `order_item`
+    RENAME COLUMN `unit_price_minor` TO `unit_price`,
+    RENAME COLUMN `subtotal_minor` TO `subtotal`,
+    ALTER COLUMN `unit_price` DROP DEFAULT,
+    ALTER COLUMN `subtotal` DROP DEFAULT;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `product_variant`;
+
+DROP TABLE IF EXISTS `product_option`;
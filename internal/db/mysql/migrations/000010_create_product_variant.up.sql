@@ -0,0 +1,28 @@
+CREATE TABLE `product_option`
+(
+    `id`            bigint(20)  NOT NULL AUTO_INCREMENT,
+    `product_id`    bigint(20)  NOT NULL,
+    `name`          varchar(64) NOT NULL,
+    `option_values` json        NOT NULL,
+    `position`      int(11)     NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `product_id_name` (`product_id`, `name`),
+    CONSTRAINT `product_option_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+
+CREATE TABLE `product_variant`
+(
+    `id`         bigint(20)   NOT NULL AUTO_INCREMENT,
+    `product_id` bigint(20)   NOT NULL,
+    `sku`        varchar(64)  NOT NULL,
+    `options`    varchar(512) NOT NULL,
+    `price`      bigint(20)            DEFAULT NULL,
+    `available`  tinyint(1)   NOT NULL DEFAULT 1,
+    `created_at` datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` datetime              DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `sku` (`sku`),
+    UNIQUE KEY `product_id_options` (`product_id`, `options`),
+    CONSTRAINT `product_variant_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
@@ -0,0 +1,8 @@
+ALTER TABLE `product`
+    DROP INDEX `merchant_id_deleted_at`,
+    DROP INDEX `deleted_at`,
+    DROP COLUMN `deleted_at`;
+
+ALTER TABLE `merchant`
+    DROP INDEX `deleted_at`,
+    DROP COLUMN `deleted_at`;
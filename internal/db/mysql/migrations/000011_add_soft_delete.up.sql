@@ -0,0 +1,8 @@
+ALTER TABLE `merchant`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL,
+    ADD INDEX `deleted_at` (`deleted_at`);
+
+ALTER TABLE `product`
+    ADD COLUMN `deleted_at` datetime DEFAULT NULL,
+    ADD INDEX `deleted_at` (`deleted_at`),
+    ADD INDEX `merchant_id_deleted_at` (`merchant_id`, `deleted_at`);
@@ -0,0 +1,17 @@
+CREATE TABLE `audit_log`
+(
+    `id`          bigint(20)  NOT NULL AUTO_INCREMENT,
+    `actor_id`    bigint(20)           DEFAULT NULL,
+    `actor_role`  varchar(16) NOT NULL DEFAULT '',
+    `action`      varchar(16) NOT NULL,
+    `entity_type` varchar(32) NOT NULL,
+    `entity_id`   bigint(20)  NOT NULL,
+    `merchant_id` bigint(20)  NOT NULL,
+    `changes`     json        NOT NULL,
+    `request_id`  varchar(64) NOT NULL DEFAULT '',
+    `ip`          varchar(45) NOT NULL DEFAULT '',
+    `created_at`  datetime    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `merchant_id_created_at_id` (`merchant_id`, `created_at`, `id`),
+    KEY `entity_type_entity_id` (`entity_type`, `entity_id`)
+) ENGINE = InnoDB;
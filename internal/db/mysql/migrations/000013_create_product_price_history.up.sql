@@ -0,0 +1,16 @@
+CREATE TABLE `product_price_history`
+(
+    `id`             bigint(20) NOT NULL AUTO_INCREMENT,
+    `product_id`     bigint(20) NOT NULL,
+    `price`          bigint(20) NOT NULL,
+    `currency`       char(3)    NOT NULL,
+    `effective_from` datetime   NOT NULL,
+    `effective_to`   datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `product_id_effective_from` (`product_id`, `effective_from`),
+    CONSTRAINT `product_price_history_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+INSERT INTO `product_price_history` (`product_id`, `price`, `currency`, `effective_from`)
+SELECT `id`, `price`, `currency`, `created_at`
+FROM `product`;
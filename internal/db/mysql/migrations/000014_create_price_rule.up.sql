@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS `price_rule`;
+CREATE TABLE `price_rule`
+(
+    `id`          bigint(20) NOT NULL AUTO_INCREMENT,
+    `product_id`  bigint(20) NOT NULL,
+    `starts_at`   datetime   NOT NULL,
+    `ends_at`     datetime   NOT NULL,
+    `sale_price`  bigint(20) DEFAULT NULL,
+    `percentage`  tinyint(3) DEFAULT NULL,
+    `active`      tinyint(1) NOT NULL DEFAULT 0,
+    `created_at`  datetime   NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `product_id_starts_at` (`product_id`, `starts_at`),
+    KEY `active_starts_at_ends_at` (`active`, `starts_at`, `ends_at`),
+    CONSTRAINT `price_rule_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
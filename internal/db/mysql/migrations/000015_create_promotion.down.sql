@@ -0,0 +1,6 @@
+ALTER TABLE `sales_order`
+    DROP COLUMN `discount`;
+
+DROP TABLE IF EXISTS `promotion_redemption`;
+DROP TABLE IF EXISTS `promotion_product`;
+DROP TABLE IF EXISTS `promotion`;
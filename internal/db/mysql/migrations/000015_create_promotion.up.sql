@@ -0,0 +1,52 @@
+DROP TABLE IF EXISTS `promotion`;
+CREATE TABLE `promotion`
+(
+    `id`                 bigint(20)  NOT NULL AUTO_INCREMENT,
+    `merchant_id`        bigint(20)  NOT NULL,
+    `code`               varchar(64) NOT NULL,
+    `type`               varchar(16) NOT NULL,
+    `value`              bigint(20)  NOT NULL DEFAULT 0,
+    `buy_quantity`       int(11)     NOT NULL DEFAULT 0,
+    `get_quantity`       int(11)     NOT NULL DEFAULT 0,
+    `currency`           char(3)     NOT NULL DEFAULT '',
+    `min_spend`          bigint(20)           DEFAULT NULL,
+    `usage_limit`        bigint(20)           DEFAULT NULL,
+    `per_customer_limit` bigint(20)           DEFAULT NULL,
+    `starts_at`          datetime    NOT NULL,
+    `ends_at`            datetime             DEFAULT NULL,
+    `created_at`         datetime    NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `code` (`code`),
+    KEY `merchant_id_created_at_id` (`merchant_id`, `created_at`, `id`),
+    CONSTRAINT `promotion_merchant_id` FOREIGN KEY (`merchant_id`) REFERENCES `merchant` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+DROP TABLE IF EXISTS `promotion_product`;
+CREATE TABLE `promotion_product`
+(
+    `promotion_id` bigint(20) NOT NULL,
+    `product_id`   bigint(20) NOT NULL,
+    PRIMARY KEY (`promotion_id`, `product_id`),
+    KEY `product_id` (`product_id`),
+    CONSTRAINT `promotion_product_promotion_id` FOREIGN KEY (`promotion_id`) REFERENCES `promotion` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `promotion_product_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+DROP TABLE IF EXISTS `promotion_redemption`;
+CREATE TABLE `promotion_redemption`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT,
+    `promotion_id` bigint(20) NOT NULL,
+    `customer_id`  bigint(20) NOT NULL,
+    `order_id`     bigint(20) NOT NULL,
+    `discount`     bigint(20) NOT NULL,
+    `created_at`   datetime   NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `promotion_id_customer_id` (`promotion_id`, `customer_id`),
+    KEY `order_id` (`order_id`),
+    CONSTRAINT `promotion_redemption_promotion_id` FOREIGN KEY (`promotion_id`) REFERENCES `promotion` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `promotion_redemption_order_id` FOREIGN KEY (`order_id`) REFERENCES `sales_order` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
+
+ALTER TABLE `sales_order`
+    ADD COLUMN `discount` bigint(20) NOT NULL DEFAULT 0 AFTER `total`;
@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS `payment`;
+CREATE TABLE `payment`
+(
+    `id`              bigint(20)   NOT NULL AUTO_INCREMENT,
+    `order_id`        bigint(20)   NOT NULL,
+    `provider`        varchar(16)  NOT NULL,
+    `intent_id`       varchar(255) NOT NULL,
+    `idempotency_key` varchar(64)  NOT NULL,
+    `amount`          bigint(20)   NOT NULL,
+    `currency`        char(3)      NOT NULL,
+    `status`          varchar(16)  NOT NULL,
+    `client_secret`   varchar(255) NOT NULL DEFAULT '',
+    `created_at`      datetime     NOT NULL,
+    `updated_at`      datetime              DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `provider_idempotency_key` (`provider`, `idempotency_key`),
+    UNIQUE KEY `provider_intent_id` (`provider`, `intent_id`),
+    KEY `order_id` (`order_id`),
+    CONSTRAINT `payment_order_id` FOREIGN KEY (`order_id`) REFERENCES `sales_order` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB;
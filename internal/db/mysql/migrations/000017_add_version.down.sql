@@ -0,0 +1,5 @@
+ALTER TABLE `product`
+    DROP COLUMN `version`;
+
+ALTER TABLE `merchant`
+    DROP COLUMN `version`;
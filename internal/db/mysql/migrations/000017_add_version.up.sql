@@ -0,0 +1,5 @@
+ALTER TABLE `merchant`
+    ADD COLUMN `version` bigint(20) NOT NULL DEFAULT 1;
+
+ALTER TABLE `product`
+    ADD COLUMN `version` bigint(20) NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+ALTER TABLE `order_item`
+    DROP COLUMN `status`;
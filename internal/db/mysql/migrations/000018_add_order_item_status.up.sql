@@ -0,0 +1,6 @@
+ALTER TABLE `order_item`
+    ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'pending' AFTER `name`;
+
+UPDATE `order_item`
+    INNER JOIN `sales_order` ON `order_item`.`order_id` = `sales_order`.`id`
+SET `order_item`.`status` = `sales_order`.`status`;
@@ -0,0 +1,4 @@
+ALTER TABLE `category`
+    DROP FOREIGN KEY `category_merchant_id`,
+    DROP KEY `merchant_id`,
+    DROP COLUMN `merchant_id`;
@@ -0,0 +1,4 @@
+ALTER TABLE `category`
+    ADD COLUMN `merchant_id` bigint(20) DEFAULT NULL AFTER `parent_id`,
+    ADD KEY `merchant_id` (`merchant_id`),
+    ADD CONSTRAINT `category_merchant_id` FOREIGN KEY (`merchant_id`) REFERENCES `merchant` (`id`) ON DELETE SET NULL;
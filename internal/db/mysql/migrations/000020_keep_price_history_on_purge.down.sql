@@ -0,0 +1,5 @@
+DELETE FROM `product_price_history`
+WHERE `product_id` NOT IN (SELECT `id` FROM `product`);
+
+ALTER TABLE `product_price_history`
+    ADD CONSTRAINT `product_price_history_product_id` FOREIGN KEY (`product_id`) REFERENCES `product` (`id`) ON DELETE CASCADE;
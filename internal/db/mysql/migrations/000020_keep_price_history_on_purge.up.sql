@@ -0,0 +1,2 @@
+ALTER TABLE `product_price_history`
+    DROP FOREIGN KEY `product_price_history_product_id`;
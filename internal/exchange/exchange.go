@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrUnknownCurrency = errors.New("exchange: no rate for currency")
+
+// Rates quotes currencies against Base: one unit of Base buys Rates[code] units of code.
+// Timestamp is when the provider says the rates were valid, not when they were fetched.
+type Rates struct {
+	Base      string
+	Timestamp time.Time
+	Rates     map[string]float64
+}
+
+// Rate returns how many units of to one unit of from buys, crossing through the base
+// currency when neither side is the base.
+func (r *Rates) Rate(from, to string) (float64, error) {
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+func (r *Rates) rate(code string) (float64, error) {
+	if code == r.Base {
+		return 1, nil
+	}
+	rate, ok := r.Rates[code]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%w %s", ErrUnknownCurrency, code)
+	}
+	return rate, nil
+}
+
+type Provider interface {
+	Rates(ctx context.Context) (*Rates, error)
+}
+
+// ratesDocument is the JSON both providers read, the format popularised by Open Exchange
+// Rates: {"base": "USD", "timestamp": 1622505600, "rates": {"IDR": 14285.5, ...}}. A hand
+// written file may say when its rates were valid in RFC 3339 as_of instead of timestamp.
+type ratesDocument struct {
+	Base      string             `json:"base"`
+	Timestamp int64              `json:"timestamp"`
+	AsOf      string             `json:"as_of"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+// decodeRates rejects documents that do not say when their rates were valid, they could
+// never go stale.
+func decodeRates(r io.Reader) (*Rates, error) {
+	var doc ratesDocument
+	err := json.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("exchange: decoding rates: %w", err)
+	}
+	if doc.Base == "" || len(doc.Rates) == 0 {
+		return nil, fmt.Errorf("exchange: rates document needs a base and rates")
+	}
+
+	rates := &Rates{Base: doc.Base, Rates: doc.Rates}
+	switch {
+	case doc.Timestamp != 0:
+		rates.Timestamp = time.Unix(doc.Timestamp, 0).UTC()
+	case doc.AsOf != "":
+		asOf, err := time.Parse(time.RFC3339, doc.AsOf)
+		if err != nil {
+			return nil, fmt.Errorf("exchange: decoding rates: as_of: %w", err)
+		}
+		rates.Timestamp = asOf.UTC()
+	default:
+		return nil, fmt.Errorf("exchange: rates document needs a timestamp or as_of")
+	}
+	return rates, nil
+}
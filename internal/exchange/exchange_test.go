@@ -0,0 +1,103 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `{"base": "USD", "timestamp": 1622505600, "rates": {"IDR": 14250, "EUR": 0.8, "JPY": 110}}`
+
+func TestRatesRate(t *testing.T) {
+	rates := &Rates{Base: "USD", Rates: map[string]float64{"IDR": 14250, "EUR": 0.8}}
+
+	rate, err := rates.Rate("USD", "IDR")
+	assert.NoError(t, err)
+	assert.Equal(t, 14250.0, rate)
+
+	rate, err = rates.Rate("EUR", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.25, rate)
+
+	rate, err = rates.Rate("EUR", "IDR")
+	assert.NoError(t, err)
+	assert.InDelta(t, 17812.5, rate, 1e-9)
+
+	_, err = rates.Rate("USD", "GBP")
+	assert.True(t, errors.Is(err, ErrUnknownCurrency))
+}
+
+func TestFileProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exchange")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rates.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(document), 0644))
+
+	rates, err := NewFileProvider(path).Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "USD", rates.Base)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), rates.Timestamp)
+	assert.Equal(t, 110.0, rates.Rates["JPY"])
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"base": "USD", "as_of": "2021-06-01T07:00:00+07:00", "rates": {"IDR": 14250}}`), 0644))
+	rates, err = NewFileProvider(path).Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), rates.Timestamp)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"base": "USD", "rates": {"IDR": 14250}}`), 0644))
+	_, err = NewFileProvider(path).Rates(context.Background())
+	assert.Error(t, err, "rates without a date could never go stale")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"base": "USD", "as_of": "June 2021", "rates": {"IDR": 14250}}`), 0644))
+	_, err = NewFileProvider(path).Rates(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewFileProvider(filepath.Join(dir, "missing.json")).Rates(context.Background())
+	assert.Error(t, err)
+}
+
+func TestFileProviderFixture(t *testing.T) {
+	rates, err := NewFileProvider("../../exchange_rates.json").Rates(context.Background())
+	require.NoError(t, err)
+	assert.False(t, rates.Timestamp.IsZero())
+}
+
+func TestHTTPProvider(t *testing.T) {
+	var query, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, authorization = r.URL.RawQuery, r.Header.Get("Authorization")
+		if r.URL.Path != "/latest.json" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(document))
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(HTTPOptions{URL: server.URL + "/latest.json", Base: "USD", APIKey: "secret"})
+	require.NoError(t, err)
+
+	rates, err := provider.Rates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "base=USD", query)
+	assert.Equal(t, "Token secret", authorization)
+	assert.Equal(t, 14250.0, rates.Rates["IDR"])
+
+	provider, err = NewHTTPProvider(HTTPOptions{URL: server.URL + "/missing.json"})
+	require.NoError(t, err)
+	_, err = provider.Rates(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewHTTPProvider(HTTPOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type HTTPOptions struct {
+	// URL returns the rates document, Base is sent as the base query parameter.
+	URL    string
+	Base   string
+	APIKey string
+	// HTTPClient defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+type httpProvider struct {
+	options HTTPOptions
+	client  *http.Client
+}
+
+func NewHTTPProvider(options HTTPOptions) (Provider, error) {
+	if options.URL == "" {
+		return nil, fmt.Errorf("http exchange rate provider needs a url")
+	}
+
+	client := options.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &httpProvider{options, client}, nil
+}
+
+func (p *httpProvider) Rates(ctx context.Context) (*Rates, error) {
+	u, err := url.Parse(p.options.URL)
+	if err != nil {
+		return nil, err
+	}
+	if p.options.Base != "" {
+		query := u.Query()
+		query.Set("base", p.options.Base)
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.options.APIKey != "" {
+		req.Header.Set("Authorization", "Token "+p.options.APIKey)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("exchange: GET %s: %s: %s", p.options.URL, res.Status, body)
+	}
+
+	return decodeRates(res.Body)
+}
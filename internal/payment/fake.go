@@ -0,0 +1,83 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type fakeProvider struct {
+	webhookSecret    string
+	webhookTolerance time.Duration
+
+	mu       sync.Mutex
+	intents  map[string]*Intent
+	requests map[string]IntentRequest
+}
+
+// NewFakeProvider keeps intents in memory and never talks to anyone, payments complete
+// when a webhook signed with webhookSecret says so.
+func NewFakeProvider(webhookSecret string, webhookTolerance time.Duration) (Provider, error) {
+	// anyone could sign webhooks with an empty secret and mark orders paid
+	if webhookSecret == "" {
+		return nil, fmt.Errorf("fake payment provider needs a webhook secret")
+	}
+
+	return &fakeProvider{
+		webhookSecret:    webhookSecret,
+		webhookTolerance: webhookTolerance,
+		intents:          make(map[string]*Intent),
+		requests:         make(map[string]IntentRequest),
+	}, nil
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if intent, found := p.intents[req.IdempotencyKey]; found {
+			first := p.requests[req.IdempotencyKey]
+			if first.Amount != req.Amount || !strings.EqualFold(first.Currency, req.Currency) {
+				return nil, ErrIdempotencyKeyReused
+			}
+			copied := *intent
+			return &copied, nil
+		}
+	}
+
+	id := fmt.Sprintf("pi_fake_%d", len(p.requests)+1)
+	intent := &Intent{
+		ID:           id,
+		Amount:       req.Amount,
+		Currency:     strings.ToUpper(req.Currency),
+		Status:       IntentRequiresPayment,
+		ClientSecret: id + "_secret",
+		Metadata:     req.Metadata,
+	}
+
+	key := req.IdempotencyKey
+	if key == "" {
+		key = id
+	}
+	p.intents[key] = intent
+	p.requests[key] = req
+
+	copied := *intent
+	return &copied, nil
+}
+
+func (p *fakeProvider) ParseEvent(payload []byte, signature string) (*Event, error) {
+	err := VerifySignature(p.webhookSecret, payload, signature, p.webhookTolerance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEvent(payload)
+}
@@ -0,0 +1,145 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HTTPOptions struct {
+	// URL is the API root, intents are created with POST {URL}/v1/payment_intents.
+	URL              string
+	APIKey           string
+	WebhookSecret    string
+	WebhookTolerance time.Duration
+	// Retries is how many times a request with an idempotency key is repeated after a
+	// network error or a 5xx, RetryBackoff defaults to 500ms and grows with each attempt.
+	Retries      int
+	RetryBackoff time.Duration
+	// HTTPClient defaults to a client with a 10 second timeout.
+	HTTPClient *http.Client
+}
+
+type httpProvider struct {
+	options HTTPOptions
+	client  *http.Client
+}
+
+// NewHTTPProvider talks to a Stripe-like API: form encoded requests authenticated with a
+// bearer key and an Idempotency-Key header, JSON responses.
+func NewHTTPProvider(options HTTPOptions) (Provider, error) {
+	if options.URL == "" {
+		return nil, fmt.Errorf("http payment provider needs a url")
+	}
+	if options.WebhookSecret == "" {
+		return nil, fmt.Errorf("http payment provider needs a webhook secret")
+	}
+
+	if options.RetryBackoff == 0 {
+		options.RetryBackoff = 500 * time.Millisecond
+	}
+	client := options.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &httpProvider{options, client}, nil
+}
+
+func (p *httpProvider) Name() string {
+	return "http"
+}
+
+// CreateIntent only retries requests with an idempotency key, the provider then answers a
+// retry of a request it already handled with the same intent.
+func (p *httpProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	for key, value := range req.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	attempts := 1
+	if req.IdempotencyKey != "" {
+		attempts += p.options.Retries
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * p.options.RetryBackoff):
+			}
+		}
+
+		var intent *Intent
+		var retry bool
+		intent, retry, err = p.createIntent(ctx, req.IdempotencyKey, form.Encode())
+		if !retry {
+			return intent, err
+		}
+	}
+	return nil, err
+}
+
+func (p *httpProvider) createIntent(ctx context.Context, idempotencyKey, body string) (*Intent, bool, error) {
+	endpoint := strings.TrimSuffix(p.options.URL, "/") + "/v1/payment_intents"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.options.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.options.APIKey)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+		var doc struct {
+			Error struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(message, &doc) == nil && doc.Error.Type == "idempotency_error" {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("payment: POST %s: %s: %s", endpoint, res.Status, message)
+	}
+
+	var doc intentDocument
+	err = json.NewDecoder(res.Body).Decode(&doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("payment: decoding intent: %w", err)
+	}
+	return doc.intent(), false, nil
+}
+
+func (p *httpProvider) ParseEvent(payload []byte, signature string) (*Event, error) {
+	err := VerifySignature(p.options.WebhookSecret, payload, signature, p.options.WebhookTolerance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeEvent(payload)
+}
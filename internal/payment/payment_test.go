@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySignature(t *testing.T) {
+	at := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":"evt_1"}`)
+	header := Sign("secret", payload, at)
+
+	assert.NoError(t, VerifySignature("secret", payload, header, 5*time.Minute, at.Add(time.Minute)))
+	assert.NoError(t, VerifySignature("secret", payload, "v1=0000,"+header, 5*time.Minute, at), "any v1 may match")
+
+	assert.Equal(t, ErrInvalidSignature, VerifySignature("other", payload, header, 5*time.Minute, at))
+	assert.Equal(t, ErrInvalidSignature, VerifySignature("secret", []byte(`{"id":"evt_2"}`), header, 5*time.Minute, at))
+	assert.Equal(t, ErrInvalidSignature, VerifySignature("secret", payload, header, 5*time.Minute, at.Add(6*time.Minute)), "replayed")
+	assert.Equal(t, ErrInvalidSignature, VerifySignature("secret", payload, "t=abc,v1=00", 5*time.Minute, at))
+	assert.Equal(t, ErrInvalidSignature, VerifySignature("secret", payload, "", 5*time.Minute, at))
+}
+
+func TestFakeProvider(t *testing.T) {
+	provider, err := NewFakeProvider("secret", 5*time.Minute)
+	require.NoError(t, err)
+
+	req := IntentRequest{Amount: 1999, Currency: "usd", IdempotencyKey: "order-1-payment-0"}
+	intent, err := provider.CreateIntent(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", intent.Currency)
+	assert.Equal(t, IntentRequiresPayment, intent.Status)
+
+	again, err := provider.CreateIntent(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, intent.ID, again.ID, "same key, same intent")
+
+	req.Amount = 2999
+	_, err = provider.CreateIntent(context.Background(), req)
+	assert.Equal(t, ErrIdempotencyKeyReused, err)
+
+	other, err := provider.CreateIntent(context.Background(), IntentRequest{Amount: 1999, Currency: "USD"})
+	require.NoError(t, err)
+	assert.NotEqual(t, intent.ID, other.ID)
+
+	payload, err := EncodeEvent(&Event{ID: "evt_1", Type: EventIntentSucceeded, Intent: *intent})
+	require.NoError(t, err)
+
+	event, err := provider.ParseEvent(payload, Sign("secret", payload, time.Now()))
+	require.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventIntentSucceeded, event.Type)
+	assert.Equal(t, intent.ID, event.Intent.ID)
+	assert.Equal(t, "USD", event.Intent.Currency)
+
+	_, err = provider.ParseEvent(payload, Sign("other", payload, time.Now()))
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	_, err = NewFakeProvider("", 5*time.Minute)
+	assert.Error(t, err, "anyone could sign webhooks with an empty secret")
+}
+
+// stripeStub answers like a Stripe-like API: the first request of an idempotency key is
+// failed failures times, then every request with that key gets the same intent.
+type stripeStub struct {
+	mu       sync.Mutex
+	failures int
+	requests int
+	keys     []string
+	bodies   map[string]string
+}
+
+func (s *stripeStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/v1/payment_intents" || r.Header.Get("Authorization") != "Bearer sk_test" {
+		http.Error(w, `{"error":{"type":"invalid_request_error"}}`, http.StatusUnauthorized)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	key := r.Header.Get("Idempotency-Key")
+	s.requests++
+	s.keys = append(s.keys, key)
+	if s.requests <= s.failures {
+		http.Error(w, `{"error":{"type":"api_error"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	if first, found := s.bodies[key]; found && first != string(body) {
+		http.Error(w, `{"error":{"type":"idempotency_error"}}`, http.StatusBadRequest)
+		return
+	}
+	s.bodies[key] = string(body)
+
+	form, _ := url.ParseQuery(string(body))
+	w.Write([]byte(`{"id":"pi_1","amount":` + form.Get("amount") + `,"currency":"` + form.Get("currency") +
+		`","status":"requires_payment_method","client_secret":"pi_1_secret","metadata":{"order_id":"` + form.Get("metadata[order_id]") + `"}}`))
+}
+
+func TestHTTPProvider(t *testing.T) {
+	stub := &stripeStub{failures: 2, bodies: make(map[string]string)}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	provider, err := NewHTTPProvider(HTTPOptions{
+		URL:              server.URL,
+		APIKey:           "sk_test",
+		WebhookSecret:    "secret",
+		WebhookTolerance: 5 * time.Minute,
+		Retries:          2,
+		RetryBackoff:     time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	req := IntentRequest{Amount: 1999, Currency: "USD", IdempotencyKey: "order-1-payment-0", Metadata: map[string]string{"order_id": "1"}}
+	intent, err := provider.CreateIntent(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, &Intent{
+		ID:           "pi_1",
+		Amount:       1999,
+		Currency:     "USD",
+		Status:       IntentRequiresPayment,
+		ClientSecret: "pi_1_secret",
+		Metadata:     map[string]string{"order_id": "1"},
+	}, intent)
+	assert.Equal(t, []string{"order-1-payment-0", "order-1-payment-0", "order-1-payment-0"}, stub.keys, "retried with the same key")
+
+	req.Amount = 2999
+	_, err = provider.CreateIntent(context.Background(), req)
+	assert.Equal(t, ErrIdempotencyKeyReused, err)
+
+	stub.failures, stub.requests = 1, 0
+	_, err = provider.CreateIntent(context.Background(), IntentRequest{Amount: 1999, Currency: "USD"})
+	assert.Error(t, err, "never retried without a key")
+	assert.Equal(t, 1, stub.requests)
+
+	_, err = NewHTTPProvider(HTTPOptions{URL: server.URL})
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// Index is an in-memory inverted index from tokens to the documents containing them.
+// It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[int64][]string
+	postings map[string]map[int64]int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int64][]string),
+		postings: make(map[string]map[int64]int),
+	}
+}
+
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Add indexes text under id, replacing whatever was indexed for id before.
+func (ix *Index) Add(id int64, text string) {
+	tokens := Tokenize(text)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(id)
+	ix.docs[id] = tokens
+	for _, token := range tokens {
+		posting, ok := ix.postings[token]
+		if !ok {
+			posting = make(map[int64]int)
+			ix.postings[token] = posting
+		}
+		posting[id]++
+	}
+}
+
+func (ix *Index) Remove(id int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+func (ix *Index) remove(id int64) {
+	for _, token := range ix.docs[id] {
+		posting := ix.postings[token]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(ix.postings, token)
+		}
+	}
+	delete(ix.docs, id)
+}
+
+// Search returns the documents matching every term of query, best first. A term matches
+// a token exactly, as its prefix or with a few typos, each scoring less than the former.
+// Matches are weighted by how rare the token is and how often it appears in the document.
+func (ix *Index) Search(query string) []Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var scores map[int64]float64
+	for _, term := range terms {
+		termScores := make(map[int64]float64)
+		for token, posting := range ix.postings {
+			weight := matchWeight(token, term)
+			if weight == 0 {
+				continue
+			}
+
+			idf := math.Log(1 + float64(len(ix.docs))/float64(len(posting)))
+			for id, tf := range posting {
+				score := weight * idf * (1 + math.Log(float64(tf)))
+				if score > termScores[id] {
+					termScores[id] = score
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] += termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	return hits
+}
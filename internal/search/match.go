@@ -0,0 +1,140 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	exactWeight  = 1.0
+	prefixWeight = 0.75
+	typoWeight   = 0.5
+)
+
+// Tokenize lowercases text and splits it on everything that is not a letter or digit.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), isSeparator)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// maxTypos is how many edits a query term of n runes may be away from a token: none
+// for short terms, where a single edit usually means a different word.
+func maxTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchWeight scores how well token matches the query term: exactly, as a prefix, or
+// within maxTypos edits. 0 means no match.
+func matchWeight(token, term string) float64 {
+	switch {
+	case token == term:
+		return exactWeight
+	case strings.HasPrefix(token, term):
+		return prefixWeight
+	}
+
+	typos := maxTypos(len([]rune(term)))
+	if typos == 0 {
+		return 0
+	}
+
+	distance := editDistance([]rune(token), []rune(term), typos)
+	if distance > typos {
+		return 0
+	}
+	return typoWeight / float64(distance)
+}
+
+// editDistance is the optimal string alignment distance between a and b (insertions,
+// deletions, substitutions and adjacent transpositions). It gives up and returns limit+1
+// as soon as the distance is known to exceed limit.
+func editDistance(a, b []rune, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = minInt(curr[j], prev2[j-2]+1)
+			}
+			rowMin = minInt(rowMin, curr[j])
+		}
+
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Highlight HTML escapes text and wraps every word matching a term of query in <em> tags.
+func Highlight(text, query string) string {
+	terms := Tokenize(query)
+
+	var b strings.Builder
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && isSeparator(runes[end]) == isSeparator(runes[start]) {
+			end++
+		}
+
+		chunk := string(runes[start:end])
+		if !isSeparator(runes[start]) && matchesAny(strings.ToLower(chunk), terms) {
+			b.WriteString("<em>" + html.EscapeString(chunk) + "</em>")
+		} else {
+			b.WriteString(html.EscapeString(chunk))
+		}
+		start = end
+	}
+
+	return b.String()
+}
+
+func matchesAny(token string, terms []string) bool {
+	for _, term := range terms {
+		if matchWeight(token, term) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(hits []Hit) []int64 {
+	res := make([]int64, len(hits))
+	for i, hit := range hits {
+		res[i] = hit.ID
+	}
+	return res
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance([]rune("phone"), []rune("phone"), 2))
+	assert.Equal(t, 1, editDistance([]rune("phone"), []rune("phnoe"), 2))
+	assert.Equal(t, 1, editDistance([]rune("phone"), []rune("phon"), 2))
+	assert.Equal(t, 1, editDistance([]rune("keyboard"), []rune("kebyoard"), 2))
+	assert.Equal(t, 3, editDistance([]rune("phone"), []rune("table"), 2))
+}
+
+func TestIndexSearch(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(1, "Apple iPhone 12 Pro")
+	ix.Add(2, "Samsung Galaxy Phone Case")
+	ix.Add(3, "Phone charger, phone stand")
+	ix.Add(4, "Mechanical keyboard")
+	assert.Equal(t, 4, ix.Len())
+
+	assert.Equal(t, []int64{3, 2, 1}, ids(ix.Search("phone")), "term frequency ranks 3 first, iphone is one edit away")
+	assert.Equal(t, []int64{1}, ids(ix.Search("iphone pro")), "every term must match")
+	assert.Equal(t, []int64{4}, ids(ix.Search("mech")), "prefix")
+	assert.Equal(t, []int64{4}, ids(ix.Search("keybaord")), "transposition")
+	assert.Equal(t, []int64{3, 2}, ids(ix.Search("phnoe")), "typo")
+	assert.Empty(t, ix.Search("pro max"))
+	assert.Empty(t, ix.Search("  "))
+
+	exact := ix.Search("phone")[0].Score
+	typo := ix.Search("phnoe")[0].Score
+	assert.Greater(t, exact, typo)
+
+	ix.Add(3, "Wireless charger")
+	assert.Equal(t, []int64{2, 1}, ids(ix.Search("phone")))
+
+	ix.Remove(2)
+	assert.Equal(t, []int64{1}, ids(ix.Search("phone")))
+	assert.Equal(t, 3, ix.Len())
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "Apple <em>iPhone</em> 12 <em>Pro</em>", Highlight("Apple iPhone 12 Pro", "iphnoe pro"))
+	assert.Equal(t, "<em>Mechanical</em> keyboard &lt;RGB&gt;", Highlight("Mechanical keyboard <RGB>", "mech"))
+	assert.Equal(t, "Cable", Highlight("Cable", ""))
+}
@@ -3,42 +3,110 @@ package middleware
 import (
 	"context"
 	"fmt"
-	"github.com/SemmiDev/go-product/internal/config"
 	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/token"
 	"github.com/SemmiDev/go-product/internal/web"
 	"net/http"
 	"strconv"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-func JWTVerifier(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenHeader := r.Header.Get(constant.API_KEY_HEADER)
-		if tokenHeader == "" {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
-		}
+type AccessTokenRevocation interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+func NewJWTVerifier(revocation AccessTokenRevocation) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenHeader := r.Header.Get(constant.API_KEY_HEADER)
+			if tokenHeader == "" {
+				web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
+				return
+			}
 
-		tokenParse, err := jwt.Parse(tokenHeader, func(jwtToken *jwt.Token) (interface{}, error) {
-			if jwtToken.Method != jwt.SigningMethodHS256 {
-				return nil, constant.ErrUnauthorized
+			ctx, status, err := verifyToken(r.Context(), revocation, tokenHeader)
+			if err != nil {
+				web.MarshalError(w, status, err)
+				return
 			}
-			return []byte(config.Cfg().JwtSecretKey), nil
+
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
+	}
+}
 
-		if err != nil || !tokenParse.Valid {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
-		}
+// NewOptionalJWTVerifier lets requests without a token through anonymously, a token that is
+// present must still be valid.
+func NewOptionalJWTVerifier(revocation AccessTokenRevocation) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenHeader := r.Header.Get(constant.API_KEY_HEADER)
+			if tokenHeader == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		claims := tokenParse.Claims.(jwt.MapClaims)
-		claimsID, err := strconv.ParseInt(fmt.Sprint(claims["id"]), 10, 64)
+			ctx, status, err := verifyToken(r.Context(), revocation, tokenHeader)
+			if err != nil {
+				web.MarshalError(w, status, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// verifyToken parses tokenHeader and stores its claims in ctx, failing with the status to
+// answer.
+func verifyToken(ctx context.Context, revocation AccessTokenRevocation, tokenHeader string) (context.Context, int, error) {
+	claims, claimsID, claimsRole, err := parseClaims(tokenHeader)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+
+	parent := ctx
+	ctx = WithClaims(ctx, claimsID, claimsRole)
+
+	if exp, valid := claims["exp"].(float64); valid {
+		ctx = context.WithValue(ctx, claimsExpiresAtKey, time.Unix(int64(exp), 0))
+	}
+
+	if jti, valid := claims["jti"].(string); valid {
+		revoked, err := revocation.IsAccessTokenRevoked(parent, jti)
 		if err != nil {
-			web.MarshalError(w, http.StatusUnauthorized, constant.ErrUnauthorized)
-			return
+			logger.Log().Err(err).Msg("failed to check access token revocation")
+			return nil, http.StatusInternalServerError, constant.ErrServer
+		} else if revoked {
+			return nil, http.StatusUnauthorized, constant.ErrUnauthorized
 		}
+		ctx = context.WithValue(ctx, claimsJTIKey, jti)
+	}
+
+	return ctx, 0, nil
+}
+
+// parseClaims checks the signature and expiry of tokenHeader, not whether it was revoked.
+func parseClaims(tokenHeader string) (jwt.MapClaims, int64, string, error) {
+	tokenParse, err := token.ParseToken(tokenHeader)
+	if err != nil || !tokenParse.Valid {
+		return nil, 0, "", constant.ErrUnauthorized
+	}
+
+	claims := tokenParse.Claims.(jwt.MapClaims)
+	claimsID, err := strconv.ParseInt(fmt.Sprint(claims["id"]), 10, 64)
+	if err != nil {
+		return nil, 0, "", constant.ErrUnauthorized
+	}
+
+	// a token without a role grants nothing, merchants holding one from before roles log in again
+	claimsRole, _ := claims["role"].(string)
+	if claimsRole != constant.ROLE_MERCHANT && claimsRole != constant.ROLE_CUSTOMER {
+		return nil, 0, "", constant.ErrUnauthorized
+	}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsIDKey, claimsID)))
-	})
+	return claims, claimsID, claimsRole, nil
 }
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/security/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claimsGenerator signs whatever claims it is given.
+type claimsGenerator jwt.MapClaims
+
+func (g claimsGenerator) GenerateClaims() jwt.MapClaims {
+	claims := jwt.MapClaims{}
+	for name, value := range g {
+		claims[name] = value
+	}
+	return claims
+}
+
+func signToken(t *testing.T, claims claimsGenerator) string {
+	signed, _, err := token.GenerateToken(claims)
+	require.NoError(t, err)
+	return signed
+}
+
+type fakeRevocation struct {
+	revoked map[string]bool
+}
+
+func (r *fakeRevocation) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return r.revoked[jti], nil
+}
+
+// serveVerified sends a request with tokenHeader through verifier and returns the status and
+// the claims the handler saw.
+func serveVerified(verifier func(http.Handler) http.Handler, tokenHeader string) (int, context.Context) {
+	var ctx context.Context
+	r := httptest.NewRequest("GET", "/", nil)
+	if tokenHeader != "" {
+		r.Header.Set(constant.API_KEY_HEADER, tokenHeader)
+	}
+	w := httptest.NewRecorder()
+	verifier(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(w, r)
+	return w.Code, ctx
+}
+
+func TestJWTVerifierRoles(t *testing.T) {
+	verifier := NewJWTVerifier(&fakeRevocation{})
+
+	for _, role := range []string{constant.ROLE_MERCHANT, constant.ROLE_CUSTOMER} {
+		code, ctx := serveVerified(verifier, signToken(t, claimsGenerator{"id": 7, "role": role}))
+		require.Equal(t, http.StatusOK, code, role)
+
+		id, _ := GetClaimsID(ctx)
+		claimsRole, _ := GetClaimsRole(ctx)
+		assert.Equal(t, int64(7), id)
+		assert.Equal(t, role, claimsRole, "the role survives signing and verification")
+		_, hasJTI := GetClaimsJTI(ctx)
+		assert.True(t, hasJTI)
+	}
+}
+
+func TestJWTVerifierRejects(t *testing.T) {
+	verifier := NewJWTVerifier(&fakeRevocation{})
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"malformed", "nonsense", http.StatusUnauthorized},
+		{"no role", signToken(t, claimsGenerator{"id": 7}), http.StatusUnauthorized},
+		{"role not a string", signToken(t, claimsGenerator{"id": 7, "role": 1}), http.StatusUnauthorized},
+		{"unknown role", signToken(t, claimsGenerator{"id": 7, "role": "admin"}), http.StatusUnauthorized},
+		{"no id", signToken(t, claimsGenerator{"role": constant.ROLE_MERCHANT}), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		code, _ := serveVerified(verifier, tt.token)
+		assert.Equal(t, tt.status, code, tt.name)
+	}
+
+	optional := NewOptionalJWTVerifier(&fakeRevocation{})
+	code, ctx := serveVerified(optional, "")
+	assert.Equal(t, http.StatusOK, code, "anonymous requests pass the optional verifier")
+	_, hasRole := GetClaimsRole(ctx)
+	assert.False(t, hasRole)
+	code, _ = serveVerified(optional, signToken(t, claimsGenerator{"id": 7}))
+	assert.Equal(t, http.StatusUnauthorized, code, "a token that is present must be valid")
+}
+
+func TestJWTVerifierRevoked(t *testing.T) {
+	signed := signToken(t, claimsGenerator{"id": 7, "role": constant.ROLE_MERCHANT})
+	parsed, err := token.ParseToken(signed)
+	require.NoError(t, err)
+	jti := parsed.Claims.(jwt.MapClaims)["jti"].(string)
+
+	code, _ := serveVerified(NewJWTVerifier(&fakeRevocation{revoked: map[string]bool{jti: true}}), signed)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
@@ -2,18 +2,73 @@ package middleware
 
 import (
 	"context"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/web"
+	"net/http"
+	"time"
 )
 
 type key string
 
-const claimsIDKey = key("id")
+const (
+	claimsIDKey        = key("id")
+	claimsRoleKey      = key("role")
+	claimsJTIKey       = key("jti")
+	claimsExpiresAtKey = key("exp")
+)
+
+// WithClaims stores the id and role of a verified token in ctx.
+func WithClaims(ctx context.Context, id int64, role string) context.Context {
+	ctx = context.WithValue(ctx, claimsIDKey, id)
+	return context.WithValue(ctx, claimsRoleKey, role)
+}
 
 func GetClaimsID(ctx context.Context) (int64, bool) {
 	claimsID, valid := ctx.Value(claimsIDKey).(int64)
 	return claimsID, valid
 }
 
+func GetClaimsRole(ctx context.Context) (string, bool) {
+	claimsRole, valid := ctx.Value(claimsRoleKey).(string)
+	return claimsRole, valid
+}
+
+func GetClaimsJTI(ctx context.Context) (string, bool) {
+	claimsJTI, valid := ctx.Value(claimsJTIKey).(string)
+	return claimsJTI, valid
+}
+
+func GetClaimsExpiresAt(ctx context.Context) (time.Time, bool) {
+	claimsExpiresAt, valid := ctx.Value(claimsExpiresAtKey).(time.Time)
+	return claimsExpiresAt, valid
+}
+
 func IsMe(ctx context.Context, id int64) bool {
 	claimsID, valid := GetClaimsID(ctx)
 	return valid && claimsID == id
 }
+
+func HasRole(ctx context.Context, role string) bool {
+	claimsRole, valid := GetClaimsRole(ctx)
+	return valid && claimsRole == role
+}
+
+func IsMerchant(ctx context.Context, id int64) bool {
+	return HasRole(ctx, constant.ROLE_MERCHANT) && IsMe(ctx, id)
+}
+
+func IsCustomer(ctx context.Context, id int64) bool {
+	return HasRole(ctx, constant.ROLE_CUSTOMER) && IsMe(ctx, id)
+}
+
+func RequireRole(role string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasRole(r.Context(), role) {
+				web.MarshalError(w, http.StatusForbidden, constant.ErrForbiddenRole)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
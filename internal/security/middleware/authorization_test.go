@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	verifier := NewJWTVerifier(&fakeRevocation{})
+	merchantOnly := func(next http.Handler) http.Handler { return verifier(RequireRole(constant.ROLE_MERCHANT)(next)) }
+	customerOnly := func(next http.Handler) http.Handler { return verifier(RequireRole(constant.ROLE_CUSTOMER)(next)) }
+
+	merchant := signToken(t, claimsGenerator{"id": 7, "role": constant.ROLE_MERCHANT})
+	customer := signToken(t, claimsGenerator{"id": 7, "role": constant.ROLE_CUSTOMER})
+
+	code, _ := serveVerified(merchantOnly, customer)
+	assert.Equal(t, http.StatusForbidden, code, "a customer on a merchant route")
+	code, _ = serveVerified(customerOnly, merchant)
+	assert.Equal(t, http.StatusForbidden, code, "a merchant on a customer route")
+
+	code, _ = serveVerified(merchantOnly, merchant)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = serveVerified(customerOnly, customer)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = serveVerified(RequireRole(constant.ROLE_CUSTOMER), "")
+	assert.Equal(t, http.StatusForbidden, code, "anonymous requests have no role")
+}
+
+func TestIsMerchantIsCustomer(t *testing.T) {
+	merchant := WithClaims(context.Background(), 7, constant.ROLE_MERCHANT)
+	customer := WithClaims(context.Background(), 7, constant.ROLE_CUSTOMER)
+
+	assert.True(t, IsMerchant(merchant, 7))
+	assert.False(t, IsMerchant(merchant, 8), "another merchant")
+	assert.False(t, IsMerchant(customer, 7), "a customer sharing the id")
+
+	assert.True(t, IsCustomer(customer, 7))
+	assert.False(t, IsCustomer(customer, 8))
+	assert.False(t, IsCustomer(merchant, 7))
+
+	assert.False(t, IsMerchant(context.Background(), 0))
+	assert.False(t, IsCustomer(context.Background(), 0))
+}
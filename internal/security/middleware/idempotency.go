@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/web"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	chimiddleware "github.com/go-chi/chi/middleware"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyStore interface {
+	GetIdempotentResponse(ctx context.Context, key string) (*model.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, key string, res *model.IdempotentResponse) error
+	// LockIdempotencyKey returns an empty token when the key is locked already.
+	LockIdempotencyKey(ctx context.Context, key string) (string, error)
+	// UnlockIdempotencyKey releases the lock only if it is still held with token.
+	UnlockIdempotencyKey(ctx context.Context, key, token string) error
+}
+
+// NewIdempotency replays the first response to a POST, PUT or PATCH carrying an
+// Idempotency-Key to repeats of it by the same caller on the same path, so clients can
+// retry after a timeout without creating duplicates. Reusing a key for a different request
+// fails with 422 and a repeat arriving while the first is still handled with 409. Server
+// errors are not kept, the request may be retried.
+func NewIdempotency(store IdempotencyStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idempotencyKey := r.Header.Get(constant.IDEMPOTENCY_KEY_HEADER)
+			if idempotencyKey == "" || !isIdempotentMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(idempotencyKey) > maxIdempotencyKeyLength {
+				web.MarshalError(w, http.StatusBadRequest, constant.ErrIdempotencyKeyInvalid)
+				return
+			}
+
+			// no endpoint takes more than an image upload
+			maxBody := config.Cfg().ImageMaxBytes + 1<<20
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBody+1))
+			if err != nil {
+				web.MarshalError(w, http.StatusBadRequest, constant.ErrRequestBody)
+				return
+			} else if int64(len(body)) > maxBody {
+				web.MarshalError(w, http.StatusRequestEntityTooLarge, constant.ErrRequestTooLarge)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			key := idempotencyStoreKey(r, idempotencyKey)
+			requestHash := hashIdempotentRequest(r, body)
+
+			stored, err := store.GetIdempotentResponse(r.Context(), key)
+			if err != nil {
+				logger.Log().Err(err).Msg("failed to get idempotent response")
+				web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+				return
+			}
+
+			if stored == nil {
+				token, err := store.LockIdempotencyKey(r.Context(), key)
+				if err != nil {
+					logger.Log().Err(err).Msg("failed to lock idempotency key")
+					web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+					return
+				} else if token == "" {
+					web.MarshalError(w, http.StatusConflict, constant.ErrIdempotencyKeyInFlight)
+					return
+				}
+				defer func() {
+					err := store.UnlockIdempotencyKey(context.Background(), key, token)
+					if err != nil {
+						logger.Log().Err(err).Msg("failed to unlock idempotency key")
+					}
+				}()
+
+				// the first request may have finished between the lookup and the lock
+				stored, err = store.GetIdempotentResponse(r.Context(), key)
+				if err != nil {
+					logger.Log().Err(err).Msg("failed to get idempotent response")
+					web.MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+					return
+				}
+			}
+
+			if stored != nil {
+				replayIdempotentResponse(w, stored, requestHash)
+				return
+			}
+
+			buf := new(bytes.Buffer)
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(buf)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			err = store.SaveIdempotentResponse(context.Background(), key, &model.IdempotentResponse{
+				RequestHash: requestHash,
+				Status:      status,
+				Header:      w.Header().Clone(),
+				Body:        buf.Bytes(),
+			})
+			if err != nil {
+				logger.Log().Err(err).Msg("failed to save idempotent response")
+			}
+		})
+	}
+}
+
+func isIdempotentMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, stored *model.IdempotentResponse, requestHash string) {
+	if stored.RequestHash != requestHash {
+		web.MarshalError(w, http.StatusUnprocessableEntity, constant.ErrIdempotencyKeyReused)
+		return
+	}
+
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(constant.IDEMPOTENT_REPLAYED_HEADER, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// idempotencyStoreKey scopes a client's key to the request path and the caller: the
+// token's subject, which outlives the token itself, or the address of anonymous callers.
+func idempotencyStoreKey(r *http.Request, idempotencyKey string) string {
+	caller := "ip_" + GetClientIP(r.Context())
+	if tokenHeader := r.Header.Get(constant.API_KEY_HEADER); tokenHeader != "" {
+		if _, claimsID, claimsRole, err := parseClaims(tokenHeader); err == nil {
+			caller = fmt.Sprintf("%s_%d", claimsRole, claimsID)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + caller + "\n" + idempotencyKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashIdempotentRequest fingerprints what besides the path decides the outcome of a
+// request: its query, its preconditions and its body.
+func hashIdempotentRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.RawQuery + "\n"))
+	for _, name := range []string{constant.IF_MATCH_HEADER, constant.IF_NONE_MATCH_HEADER} {
+		hash.Write([]byte(name + ": " + strings.Join(r.Header.Values(name), ", ") + "\n"))
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*model.IdempotentResponse
+	locks     map[string]string
+	tokens    int
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{
+		responses: make(map[string]*model.IdempotentResponse),
+		locks:     make(map[string]string),
+	}
+}
+
+func (s *fakeIdempotencyStore) GetIdempotentResponse(ctx context.Context, key string) (*model.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.responses[key], nil
+}
+
+func (s *fakeIdempotencyStore) SaveIdempotentResponse(ctx context.Context, key string, res *model.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = res
+	return nil
+}
+
+func (s *fakeIdempotencyStore) LockIdempotencyKey(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, locked := s.locks[key]; locked {
+		return "", nil
+	}
+	s.tokens++
+	s.locks[key] = fmt.Sprintf("token-%d", s.tokens)
+	return s.locks[key], nil
+}
+
+func (s *fakeIdempotencyStore) UnlockIdempotencyKey(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key] == token {
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// idempotencyServer mounts the middleware behind ClientIP in front of handler and returns
+// a function sending requests to it.
+func idempotencyServer(t *testing.T, store IdempotencyStore, handler http.HandlerFunc) func(method, target, remoteAddr, key, body string) *httptest.ResponseRecorder {
+	clientIP, err := NewClientIP("")
+	require.NoError(t, err)
+	server := clientIP(NewIdempotency(store)(handler))
+
+	return func(method, target, remoteAddr, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.RemoteAddr = remoteAddr
+		if key != "" {
+			r.Header.Set(constant.IDEMPOTENCY_KEY_HEADER, key)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w
+	}
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	calls := 0
+	send := idempotencyServer(t, store, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Location", "/v1/orders/1")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d,"body":%q}`, calls, body)
+	})
+
+	first := send("POST", "/v1/orders", "203.0.113.7:1", "key-1", `{"items":[]}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(constant.IDEMPOTENT_REPLAYED_HEADER))
+
+	again := send("POST", "/v1/orders", "203.0.113.7:2", "key-1", `{"items":[]}`)
+	assert.Equal(t, 1, calls, "the handler runs once")
+	assert.Equal(t, http.StatusCreated, again.Code)
+	assert.Equal(t, first.Body.String(), again.Body.String())
+	assert.Equal(t, "/v1/orders/1", again.Header().Get("Location"))
+	assert.Equal(t, "true", again.Header().Get(constant.IDEMPOTENT_REPLAYED_HEADER))
+	assert.Empty(t, store.locks, "the lock is released")
+
+	send("POST", "/v1/orders", "203.0.113.7:1", "", `{"items":[]}`)
+	send("GET", "/v1/orders", "203.0.113.7:1", "key-1", "")
+	assert.Equal(t, 3, calls, "requests without a key and GETs pass through")
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	calls := 0
+	send := idempotencyServer(t, newFakeIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusCreated, send("POST", "/v1/orders?coupon=a", "203.0.113.7:1", "key-1", `{"items":[]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, send("POST", "/v1/orders?coupon=a", "203.0.113.7:1", "key-1", `{"items":[1]}`).Code,
+		"another body")
+	assert.Equal(t, http.StatusUnprocessableEntity, send("POST", "/v1/orders?coupon=b", "203.0.113.7:1", "key-1", `{"items":[]}`).Code,
+		"another query")
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyKeyReusedPrecondition(t *testing.T) {
+	calls := 0
+	clientIP, err := NewClientIP("")
+	require.NoError(t, err)
+	server := clientIP(NewIdempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	})))
+	send := func(ifMatch string) int {
+		r := httptest.NewRequest("PUT", "/v1/products/3", strings.NewReader(`{"name":"Kopi"}`))
+		r.RemoteAddr = "203.0.113.7:1"
+		r.Header.Set(constant.IDEMPOTENCY_KEY_HEADER, "key-1")
+		if ifMatch != "" {
+			r.Header.Set(constant.IF_MATCH_HEADER, ifMatch)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(`"3-abc"`))
+	assert.Equal(t, http.StatusOK, send(`"3-abc"`), "the repeat is replayed")
+	assert.Equal(t, http.StatusUnprocessableEntity, send(`"4-abc"`), "another precondition is another request")
+	assert.Equal(t, http.StatusUnprocessableEntity, send(""))
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	var send func(method, target, remoteAddr, key, body string) *httptest.ResponseRecorder
+	var repeat *httptest.ResponseRecorder
+	calls := 0
+	send = idempotencyServer(t, newFakeIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// the repeat arrives while the first request is still handled
+			repeat = send("POST", "/v1/orders", "203.0.113.7:1", "key-1", "{}")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusCreated, send("POST", "/v1/orders", "203.0.113.7:1", "key-1", "{}").Code)
+	require.NotNil(t, repeat)
+	assert.Equal(t, http.StatusConflict, repeat.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencySkipsServerErrors(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	calls := 0
+	send := idempotencyServer(t, store, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, send("POST", "/v1/orders", "203.0.113.7:1", "key-1", "{}").Code)
+	assert.Empty(t, store.responses, "server errors are not kept")
+	assert.Empty(t, store.locks)
+
+	assert.Equal(t, http.StatusCreated, send("POST", "/v1/orders", "203.0.113.7:1", "key-1", "{}").Code, "the retry runs")
+	assert.Equal(t, 2, calls)
+	assert.Len(t, store.responses, 1)
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	calls := 0
+	send := idempotencyServer(t, newFakeIdempotencyStore(), func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	send("POST", "/v1/orders", "203.0.113.7:1", "key-1", "{}")
+	send("POST", "/v1/orders", "198.51.100.1:1", "key-1", "{}")
+	assert.Equal(t, 2, calls, "callers do not share keys")
+
+	send("POST", "/v1/cart/items", "203.0.113.7:1", "key-1", "{}")
+	send("PUT", "/v1/orders", "203.0.113.7:1", "key-1", "{}")
+	assert.Equal(t, 4, calls, "neither do paths or methods")
+
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/v1/orders", "203.0.113.7:1", strings.Repeat("k", 256), "{}").Code)
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveClientIP(t *testing.T, trustedProxies, remoteAddr string, header http.Header) string {
+	clientIP, err := NewClientIP(trustedProxies)
+	require.NoError(t, err)
+
+	var ip string
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = remoteAddr
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	clientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = GetClientIP(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), r)
+	return ip
+}
+
+func TestClientIP(t *testing.T) {
+	const trusted = "10.0.0.0/8, 192.0.2.1, 2001:db8::/32"
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		ip         string
+	}{
+		{"direct", "203.0.113.7:4321", nil, "203.0.113.7"},
+		{"forged by an untrusted peer", "203.0.113.7:4321",
+			http.Header{"X-Forwarded-For": {"198.51.100.1"}, "X-Real-Ip": {"198.51.100.2"}}, "203.0.113.7"},
+		{"forwarded by a trusted proxy", "10.1.2.3:4321",
+			http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"read from the right", "10.1.2.3:4321",
+			http.Header{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1, 192.0.2.1"}}, "198.51.100.1"},
+		{"several headers", "10.1.2.3:4321",
+			http.Header{"X-Forwarded-For": {"1.1.1.1", "198.51.100.1"}}, "198.51.100.1"},
+		{"malformed hop", "10.1.2.3:4321",
+			http.Header{"X-Forwarded-For": {"1.1.1.1, nonsense, 10.0.0.5"}}, "10.0.0.5"},
+		{"only proxies", "10.1.2.3:4321",
+			http.Header{"X-Forwarded-For": {"10.0.0.9, 10.0.0.5"}}, "10.0.0.9"},
+		{"real ip", "[2001:db8::1]:4321",
+			http.Header{"X-Real-Ip": {"198.51.100.1"}}, "198.51.100.1"},
+		{"malformed real ip", "10.1.2.3:4321",
+			http.Header{"X-Real-Ip": {"nonsense"}}, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ip, serveClientIP(t, trusted, tt.remoteAddr, tt.header), tt.name)
+	}
+
+	assert.Equal(t, "10.1.2.3", serveClientIP(t, "", "10.1.2.3:4321", http.Header{"X-Forwarded-For": {"198.51.100.1"}}),
+		"no proxy is trusted by default")
+}
+
+func TestNewClientIPRejects(t *testing.T) {
+	_, err := NewClientIP("10.0.0.0/33")
+	assert.Error(t, err)
+
+	_, err = NewClientIP("10.0.0.1, proxy.local")
+	assert.Error(t, err)
+}
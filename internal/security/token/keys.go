@@ -0,0 +1,183 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrUnknownKeyID   = errors.New("token key id is not in the key set")
+	ErrUnexpectedAlg  = errors.New("token algorithm does not match its key")
+	ErrNoSigningKey   = errors.New("signing key is not in the key set")
+	ErrUnsupportedKey = errors.New("unsupported key type")
+)
+
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  crypto.PublicKey
+}
+
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet builds the keys used to sign and verify access tokens. keyFiles is a comma
+// separated list of kid=path pairs; each PEM file holds a private key (usable for signing)
+// or a public key (verification only, e.g. a key being rotated out). With no key files the
+// set falls back to a single HS256 key made from secret.
+func NewKeySet(method, signingKeyID, keyFiles, secret string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+
+	if strings.TrimSpace(keyFiles) == "" {
+		if method != "" && method != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("%s needs key files: %w", method, ErrNoSigningKey)
+		}
+		ks.signing = &Key{ID: signingKeyID, Method: jwt.SigningMethodHS256, PrivateKey: []byte(secret)}
+		ks.keys[signingKeyID] = ks.signing
+		return ks, nil
+	}
+
+	for _, entry := range strings.Split(keyFiles, ",") {
+		pair := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			return nil, fmt.Errorf("invalid key file entry %q, expected kid=path", entry)
+		}
+
+		content, err := os.ReadFile(pair[1])
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := ParseKey(pair[0], content)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", pair[0], err)
+		}
+		ks.keys[key.ID] = key
+	}
+
+	signing, ok := ks.keys[signingKeyID]
+	if !ok || signing.PrivateKey == nil {
+		return nil, fmt.Errorf("%s: %w", signingKeyID, ErrNoSigningKey)
+	} else if method != "" && signing.Method.Alg() != method {
+		return nil, fmt.Errorf("signing key %s is %s, not %s", signingKeyID, signing.Method.Alg(), method)
+	}
+	ks.signing = signing
+
+	return ks, nil
+}
+
+func ParseKey(id string, content []byte) (*Key, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("PEM block %q: %w", block.Type, ErrUnsupportedKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: id}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case *ecdsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodES256, k, &k.PublicKey
+	case *ecdsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodES256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = SigningMethodEdDSA, k
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if ec, valid := key.PublicKey.(*ecdsa.PublicKey); valid && ec.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("ES256 needs a P-256 key: %w", ErrUnsupportedKey)
+	}
+
+	return key, nil
+}
+
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.ID
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.PrivateKey)
+}
+
+func (ks *KeySet) Parse(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(jwtToken *jwt.Token) (interface{}, error) {
+		key := ks.signing
+		if kid, valid := jwtToken.Header["kid"].(string); valid {
+			key, valid = ks.keys[kid]
+			if !valid {
+				return nil, ErrUnknownKeyID
+			}
+		}
+
+		if jwtToken.Method.Alg() != key.Method.Alg() {
+			return nil, ErrUnexpectedAlg
+		}
+
+		if key.Method == jwt.SigningMethodHS256 {
+			return key.PrivateKey, nil
+		}
+		return key.PublicKey, nil
+	})
+}
+
+func (ks *KeySet) JWKS() *JWKS {
+	ids := make([]string, 0, len(ks.keys))
+	for id := range ks.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	jwks := &JWKS{Keys: []*JWK{}}
+	for _, id := range ids {
+		jwk := NewJWK(ks.keys[id])
+		if jwk != nil {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
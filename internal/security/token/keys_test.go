@@ -0,0 +1,227 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePKCS8(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func encodePKIX(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// parseError unwraps the error jwt-go wraps key lookup failures in, its ValidationError
+// predates errors.Is.
+func parseError(err error) error {
+	if validation, valid := err.(*jwt.ValidationError); valid {
+		return validation.Inner
+	}
+	return err
+}
+
+func writeKey(t *testing.T, dir, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	return path
+}
+
+func TestParseKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content []byte
+		alg     string
+		private bool
+	}{
+		{"rsa pkcs8", encodePKCS8(t, rsaKey), "RS256", true},
+		{"rsa pkcs1", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), "RS256", true},
+		{"rsa public", encodePKIX(t, &rsaKey.PublicKey), "RS256", false},
+		{"ec pkcs8", encodePKCS8(t, ecKey), "ES256", true},
+		{"ec public", encodePKIX(t, &ecKey.PublicKey), "ES256", false},
+		{"ed25519", encodePKCS8(t, edPrivate), "EdDSA", true},
+		{"ed25519 public", encodePKIX(t, edPublic), "EdDSA", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey("kid", tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, "kid", key.ID)
+			assert.Equal(t, tt.alg, key.Method.Alg())
+			assert.Equal(t, tt.private, key.PrivateKey != nil)
+			assert.NotNil(t, key.PublicKey)
+		})
+	}
+}
+
+func TestParseKeyRejects(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = ParseKey("kid", []byte("not a key"))
+	assert.Error(t, err)
+
+	_, err = ParseKey("kid", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+
+	_, err = ParseKey("kid", encodePKCS8(t, p384))
+	assert.ErrorIs(t, err, ErrUnsupportedKey, "ES256 needs P-256")
+}
+
+func TestNewKeySet(t *testing.T) {
+	dir := t.TempDir()
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	previous, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	currentPath := writeKey(t, dir, "current.pem", encodePKCS8(t, current))
+	previousPath := writeKey(t, dir, "previous.pem", encodePKIX(t, &previous.PublicKey))
+	keyFiles := "current=" + currentPath + ", previous=" + previousPath
+
+	ks, err := NewKeySet("EdDSA", "current", keyFiles, "")
+	require.NoError(t, err)
+	assert.Equal(t, "current", ks.SigningKeyID())
+
+	_, err = NewKeySet("EdDSA", "previous", keyFiles, "")
+	assert.ErrorIs(t, err, ErrNoSigningKey, "a public key cannot sign")
+
+	_, err = NewKeySet("EdDSA", "missing", keyFiles, "")
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+
+	_, err = NewKeySet("RS256", "current", keyFiles, "")
+	assert.Error(t, err, "the method must match the signing key")
+
+	_, err = NewKeySet("EdDSA", "current", "current", "")
+	assert.Error(t, err, "entries are kid=path")
+
+	_, err = NewKeySet("EdDSA", "current", "current="+filepath.Join(dir, "missing.pem"), "")
+	assert.Error(t, err)
+
+	ks, err = NewKeySet("", "default", "", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "HS256", ks.signing.Method.Alg())
+
+	_, err = NewKeySet("RS256", "default", "", "secret")
+	assert.ErrorIs(t, err, ErrNoSigningKey, "only HS256 works without key files")
+}
+
+func TestKeySetSignAndParse(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ks, err := NewKeySet("EdDSA", "current", "current="+writeKey(t, t.TempDir(), "current.pem", encodePKCS8(t, private)), "")
+	require.NoError(t, err)
+
+	signed, err := ks.Sign(jwt.MapClaims{"sub": "7"})
+	require.NoError(t, err)
+
+	parsed, err := ks.Parse(signed)
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+	assert.Equal(t, "current", parsed.Header["kid"])
+	assert.Equal(t, "7", parsed.Claims.(jwt.MapClaims)["sub"])
+
+	parts := strings.Split(signed, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"8"}`))
+	_, err = ks.Parse(strings.Join(parts, "."))
+	assert.Error(t, err, "a changed payload fails the signature")
+}
+
+func TestKeySetParseRejects(t *testing.T) {
+	ks, err := NewKeySet("HS256", "default", "", "secret")
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "7"})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	_, err = ks.Parse(sign(jwt.SigningMethodHS256, "default", []byte("secret")))
+	assert.NoError(t, err)
+
+	_, err = ks.Parse(sign(jwt.SigningMethodHS256, "unknown", []byte("secret")))
+	assert.Equal(t, ErrUnknownKeyID, parseError(err))
+
+	_, err = ks.Parse(sign(jwt.SigningMethodHS512, "default", []byte("secret")))
+	assert.Equal(t, ErrUnexpectedAlg, parseError(err))
+
+	_, err = ks.Parse(sign(jwt.SigningMethodNone, "default", jwt.UnsafeAllowNoneSignatureType))
+	assert.Equal(t, ErrUnexpectedAlg, parseError(err))
+
+	_, err = ks.Parse(sign(jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType))
+	assert.Equal(t, ErrUnexpectedAlg, parseError(err), "a token without kid is checked against the signing key")
+
+	_, err = ks.Parse(sign(jwt.SigningMethodHS256, "default", []byte("other")))
+	assert.Error(t, err)
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyFiles := strings.Join([]string{
+		"c=" + writeKey(t, dir, "c.pem", encodePKCS8(t, edPrivate)),
+		"a=" + writeKey(t, dir, "a.pem", encodePKCS8(t, rsaKey)),
+		"b=" + writeKey(t, dir, "b.pem", encodePKIX(t, &ecKey.PublicKey)),
+	}, ",")
+	ks, err := NewKeySet("EdDSA", "c", keyFiles, "")
+	require.NoError(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 3)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{jwks.Keys[0].Kid, jwks.Keys[1].Kid, jwks.Keys[2].Kid})
+
+	rsaJWK := jwks.Keys[0]
+	assert.Equal(t, JWK{Kty: "RSA", Kid: "a", Use: "sig", Alg: "RS256", N: rsaJWK.N, E: "AQAB"}, *rsaJWK)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()), rsaJWK.N)
+
+	ecJWK := jwks.Keys[1]
+	assert.Equal(t, "EC", ecJWK.Kty)
+	assert.Equal(t, "P-256", ecJWK.Crv)
+	assert.Len(t, ecJWK.X, 43, "coordinates are padded to 32 bytes")
+	assert.Len(t, ecJWK.Y, 43)
+
+	edJWK := jwks.Keys[2]
+	assert.Equal(t, JWK{Kty: "OKP", Kid: "c", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPublic)}, *edJWK)
+
+	hmac, err := NewKeySet("HS256", "default", "", "secret")
+	require.NoError(t, err)
+	assert.Empty(t, hmac.JWKS().Keys, "shared secrets are never published")
+}
@@ -1,7 +1,12 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"github.com/SemmiDev/go-product/internal/config"
+	"sync"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
@@ -11,9 +16,73 @@ type Generator interface {
 	GenerateClaims() jwt.MapClaims
 }
 
-func GenerateToken(g Generator) (string, error) {
+var (
+	keySet     *KeySet
+	keySetErr  error
+	keySetOnce sync.Once
+)
+
+func Keys() (*KeySet, error) {
+	keySetOnce.Do(func() {
+		keySet, keySetErr = NewKeySet(
+			config.Cfg().JwtSigningMethod,
+			config.Cfg().JwtSigningKeyID,
+			config.Cfg().JwtKeyFiles,
+			config.Cfg().JwtSecretKey,
+		)
+	})
+	return keySet, keySetErr
+}
+
+func GenerateToken(g Generator) (string, time.Time, error) {
+	keys, err := Keys()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	jti, err := randomString(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.Cfg().JwtTTL)
+
 	claims := g.GenerateClaims()
-	claims["exp"] = time.Now().Add(config.Cfg().JwtTTL).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.Cfg().JwtSecretKey))
+	claims["jti"] = jti
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	signed, err := keys.Sign(claims)
+	return signed, expiresAt, err
+}
+
+func ParseToken(tokenString string) (*jwt.Token, error) {
+	keys, err := Keys()
+	if err != nil {
+		return nil, err
+	}
+	return keys.Parse(tokenString)
+}
+
+func GenerateRefreshToken() (string, error) {
+	return randomString(32)
+}
+
+// GenerateCartToken names an anonymous cart, it is as hard to guess as a refresh token.
+func GenerateCartToken() (string, error) {
+	return randomString(32)
+}
+
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	b := make([]byte, size)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
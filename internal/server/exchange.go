@@ -0,0 +1,22 @@
+package server
+
+import (
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/exchange"
+)
+
+func newExchangeRateProvider() (exchange.Provider, error) {
+	switch config.Cfg().ExchangeRateDriver {
+	case "file":
+		return exchange.NewFileProvider(config.Cfg().ExchangeRateFile), nil
+	case "http":
+		return exchange.NewHTTPProvider(exchange.HTTPOptions{
+			URL:    config.Cfg().ExchangeRateURL,
+			Base:   config.Cfg().ExchangeRateBase,
+			APIKey: config.Cfg().ExchangeRateAPIKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown exchange rate driver %q", config.Cfg().ExchangeRateDriver)
+	}
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/app/service"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+	"github.com/SemmiDev/go-product/internal/db/redis"
+	"github.com/SemmiDev/go-product/internal/storage"
+	"time"
+)
+
+// startPurgeJob purges expired soft-deleted rows every PurgeInterval.
+func startPurgeJob(mysqlClient mysql.Client, redisClient redis.Client, fileStorage storage.Storage) func() {
+	purgeService := service.NewPurgeService(
+		repository.NewMerchantRepository(mysqlClient, redisClient),
+		repository.NewProductRepository(mysqlClient, redisClient),
+		fileStorage,
+	)
+	return startJob(config.Cfg().PurgeInterval, purgeService.Purge)
+}
+
+// startPriceRuleJob starts and ends scheduled sales every PriceRuleInterval, which bounds
+// how late a sale may begin or finish.
+func startPriceRuleJob(mysqlClient mysql.Client, redisClient redis.Client) func() {
+	priceRuleService := service.NewPriceRuleService(repository.NewProductRepository(mysqlClient, redisClient))
+	return startJob(config.Cfg().PriceRuleInterval, priceRuleService.Sync)
+}
+
+// startJob calls run every interval until the returned function is called. A zero interval
+// disables the job.
+func startJob(interval time.Duration, run func(ctx context.Context) error) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// failures are logged by the service, the next tick retries
+				_ = run(ctx)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/payment"
+)
+
+func newPaymentProvider() (payment.Provider, error) {
+	switch config.Cfg().PaymentDriver {
+	case "fake":
+		return payment.NewFakeProvider(config.Cfg().PaymentWebhookSecret, config.Cfg().PaymentWebhookMaxAge)
+	case "http":
+		return payment.NewHTTPProvider(payment.HTTPOptions{
+			URL:              config.Cfg().PaymentURL,
+			APIKey:           config.Cfg().PaymentAPIKey,
+			WebhookSecret:    config.Cfg().PaymentWebhookSecret,
+			WebhookTolerance: config.Cfg().PaymentWebhookMaxAge,
+			Retries:          config.Cfg().PaymentRetries,
+		})
+	default:
+		return nil, fmt.Errorf("unknown payment driver %q", config.Cfg().PaymentDriver)
+	}
+}
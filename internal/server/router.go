@@ -5,9 +5,11 @@ import (
 	"github.com/SemmiDev/go-product/internal/app/repository"
 	"github.com/SemmiDev/go-product/internal/app/service"
 	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
 	"github.com/SemmiDev/go-product/internal/db/mysql"
 	"github.com/SemmiDev/go-product/internal/db/redis"
 	"github.com/SemmiDev/go-product/internal/security/middleware"
+	"github.com/SemmiDev/go-product/internal/storage"
 	"net/http"
 
 	"github.com/go-chi/chi"
@@ -16,50 +18,224 @@ import (
 	"github.com/go-chi/httprate"
 )
 
-func NewRouter(mysqlClient mysql.Client, redisClient redis.Client) *chi.Mux {
+func NewRouter(mysqlClient mysql.Client, redisClient redis.Client, fileStorage storage.Storage) (*chi.Mux, error) {
 	router := chi.NewRouter()
 
-	router.Use(httprate.LimitByIP(
+	clientIP, err := middleware.NewClientIP(config.Cfg().TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	// the limit goes by the address ClientIP works out, httprate.LimitByIP believes any
+	// forwarding header
+	router.Use(clientIP)
+	router.Use(httprate.Limit(
 		config.Cfg().HttpRateLimitRequest,
 		config.Cfg().HttpRateLimitTime,
+		func(r *http.Request) (string, error) {
+			return middleware.GetClientIP(r.Context()), nil
+		},
 	))
 
 	router.Use(cors.AllowAll().Handler)
+	router.Use(chimiddleware.RequestID)
 	router.Use(chimiddleware.Logger)
 	router.Use(chimiddleware.Recoverer)
 
 	merchantRepository := repository.NewMerchantRepository(mysqlClient, redisClient)
 	productRepository := repository.NewProductRepository(mysqlClient, redisClient)
+	orderRepository := repository.NewOrderRepository(mysqlClient)
+	customerRepository := repository.NewCustomerRepository(mysqlClient, redisClient)
+	tokenRepository := repository.NewTokenRepository(redisClient)
+	categoryRepository := repository.NewCategoryRepository(mysqlClient)
+	exchangeRateRepository := repository.NewExchangeRateRepository(redisClient)
+	auditRepository := repository.NewAuditRepository(mysqlClient)
+	promotionRepository := repository.NewPromotionRepository(mysqlClient)
+	cartRepository := repository.NewCartRepository(redisClient)
+	paymentRepository := repository.NewPaymentRepository(mysqlClient)
+	idempotencyRepository := repository.NewIdempotencyRepository(redisClient)
+
+	productSearcher, err := newProductSearcher(mysqlClient, productRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	exchangeRateProvider, err := newExchangeRateProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	paymentProvider, err := newPaymentProvider()
+	if err != nil {
+		return nil, err
+	}
 
-	authService := service.NewAuthService(merchantRepository)
-	merchantService := service.NewMerchantService(merchantRepository)
-	productService := service.NewProductService(productRepository)
+	authService := service.NewAuthService(merchantRepository, customerRepository, tokenRepository, cartRepository)
+	auditService := service.NewAuditService(auditRepository)
+	merchantService := service.NewMerchantService(merchantRepository, productRepository, productSearcher, auditService)
+	customerService := service.NewCustomerService(customerRepository)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepository, exchangeRateProvider)
+	productService := service.NewProductService(productRepository, productSearcher, exchangeRateService, auditService)
+	stockService := service.NewStockService(productRepository)
+	orderService := service.NewOrderService(orderRepository, productRepository, promotionRepository)
+	categoryService := service.NewCategoryService(categoryRepository, productRepository)
+	productImageService := service.NewProductImageService(productRepository, fileStorage)
+	productVariantService := service.NewProductVariantService(productRepository)
+	priceRuleService := service.NewPriceRuleService(productRepository)
+	promotionService := service.NewPromotionService(promotionRepository, productRepository)
+	cartService := service.NewCartService(cartRepository, productRepository)
+	paymentService := service.NewPaymentService(paymentRepository, orderRepository, paymentProvider)
 
 	authHandler := handler.NewAuthHandler(authService)
 	merchantHandler := handler.NewMerchantHandler(merchantService)
+	customerHandler := handler.NewCustomerHandler(customerService)
 	productHandler := handler.NewProductHandler(productService)
+	stockHandler := handler.NewStockHandler(stockService)
+	orderHandler := handler.NewOrderHandler(orderService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	productImageHandler := handler.NewProductImageHandler(productImageService)
+	productVariantHandler := handler.NewProductVariantHandler(productVariantService)
+	priceRuleHandler := handler.NewPriceRuleHandler(priceRuleService)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+	cartHandler := handler.NewCartHandler(cartService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	jwksHandler := handler.NewJWKSHandler()
 
 	router.Options("/*", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/.well-known/jwks.json", jwksHandler.Get())
+
+	err = mountLocalStorage(router)
+	if err != nil {
+		return nil, err
+	}
+
 	api := router.Route("/v1", func(router chi.Router) {})
 
+	jwtVerifier := middleware.NewJWTVerifier(tokenRepository)
+	optionalJWTVerifier := middleware.NewOptionalJWTVerifier(tokenRepository)
+	merchantOnly := middleware.RequireRole(constant.ROLE_MERCHANT)
+	customerOnly := middleware.RequireRole(constant.ROLE_CUSTOMER)
+	// only resource routes, replaying a login or refresh would keep its tokens in redis and
+	// the webhook settles a payment once whatever it is sent
+	idempotent := middleware.NewIdempotency(idempotencyRepository)
+
 	api.Route("/merchants", func(r chi.Router) {
 		r.Post("/auth", authHandler.Login())
+		r.Post("/auth/refresh", authHandler.Refresh())
+		r.With(jwtVerifier).Post("/auth/logout", authHandler.Logout())
+
+		r.Group(func(r chi.Router) {
+			r.Use(idempotent)
 
-		r.Post("/", merchantHandler.Create())
-		r.Get("/", merchantHandler.List())
-		r.Get("/{merchant_id}", merchantHandler.Get())
-		r.With(middleware.JWTVerifier).Put("/{merchant_id}", merchantHandler.Update())
-		r.With(middleware.JWTVerifier).Put("/{merchant_id}/password", merchantHandler.UpdatePassword())
-		r.With(middleware.JWTVerifier).Delete("/{merchant_id}", merchantHandler.Delete())
+			r.Post("/", merchantHandler.Create())
+			r.Get("/", merchantHandler.List())
+			r.Get("/{merchant_id}", merchantHandler.Get())
+			r.With(jwtVerifier, merchantOnly).Put("/{merchant_id}", merchantHandler.Update())
+			r.With(jwtVerifier, merchantOnly).Patch("/{merchant_id}", merchantHandler.Patch())
+			r.With(jwtVerifier, merchantOnly).Put("/{merchant_id}/password", merchantHandler.UpdatePassword())
+			r.With(jwtVerifier, merchantOnly).Delete("/{merchant_id}", merchantHandler.Delete())
+			r.With(jwtVerifier, merchantOnly).Post("/{merchant_id}/restore", merchantHandler.Restore())
+			r.With(jwtVerifier, merchantOnly).Get("/{merchant_id}/trash", merchantHandler.Trash())
+			r.With(jwtVerifier, merchantOnly).Get("/{merchant_id}/audit", auditHandler.List())
+			r.With(jwtVerifier, merchantOnly).Get("/{merchant_id}/promotions", promotionHandler.List())
+		})
+	})
+
+	api.Route("/customers", func(r chi.Router) {
+		r.Post("/auth", authHandler.LoginCustomer())
+		r.Post("/auth/refresh", authHandler.Refresh())
+		r.With(jwtVerifier).Post("/auth/logout", authHandler.Logout())
+
+		r.Group(func(r chi.Router) {
+			r.Use(idempotent)
+
+			r.Post("/", customerHandler.Create())
+			r.With(jwtVerifier, customerOnly).Get("/{customer_id}", customerHandler.Get())
+			r.With(jwtVerifier, customerOnly).Put("/{customer_id}", customerHandler.Update())
+		})
 	})
 
 	api.Route("/products", func(r chi.Router) {
-		r.With(middleware.JWTVerifier).Post("/", productHandler.Create())
+		r.Use(idempotent)
+
+		r.With(jwtVerifier, merchantOnly).Post("/", productHandler.Create())
 		r.Get("/", productHandler.List())
+		r.Get("/search", productHandler.Search())
 		r.Get("/{product_id}", productHandler.Get())
-		r.With(middleware.JWTVerifier).Put("/{product_id}", productHandler.Update())
-		r.With(middleware.JWTVerifier).Delete("/{product_id}", productHandler.Delete())
+		r.With(jwtVerifier, merchantOnly).Put("/{product_id}", productHandler.Update())
+		r.With(jwtVerifier, merchantOnly).Patch("/{product_id}", productHandler.Patch())
+		r.With(jwtVerifier, merchantOnly).Delete("/{product_id}", productHandler.Delete())
+		r.With(jwtVerifier, merchantOnly).Post("/{product_id}/restore", productHandler.Restore())
+		r.Get("/{product_id}/price", productHandler.PriceAt())
+		r.Get("/{product_id}/price-history", productHandler.PriceHistory())
+		r.With(jwtVerifier, merchantOnly).Get("/{product_id}/price-rules", priceRuleHandler.List())
+		r.With(jwtVerifier, merchantOnly).Post("/{product_id}/price-rules", priceRuleHandler.Create())
+		r.With(jwtVerifier, merchantOnly).Delete("/{product_id}/price-rules/{rule_id}", priceRuleHandler.Delete())
+
+		r.Get("/{product_id}/stock", stockHandler.Get())
+		r.With(jwtVerifier, merchantOnly).Post("/{product_id}/stock/adjust", stockHandler.Adjust())
+		r.With(jwtVerifier, customerOnly).Post("/{product_id}/stock/reservations", stockHandler.Reserve())
+		r.With(jwtVerifier).Post("/{product_id}/stock/reservations/{reservation_id}/confirm", stockHandler.Confirm())
+		r.With(jwtVerifier).Post("/{product_id}/stock/reservations/{reservation_id}/release", stockHandler.Release())
+
+		r.With(jwtVerifier, merchantOnly).Post("/{product_id}/images", productImageHandler.Upload())
+		r.With(jwtVerifier, merchantOnly).Put("/{product_id}/images", productImageHandler.Reorder())
+		r.With(jwtVerifier, merchantOnly).Delete("/{product_id}/images/{image_id}", productImageHandler.Delete())
+
+		r.With(jwtVerifier, merchantOnly).Put("/{product_id}/options", productVariantHandler.SetOptions())
+		r.Get("/{product_id}/variants", productVariantHandler.List())
+		r.Get("/{product_id}/variants/{variant_id}", productVariantHandler.Get())
+		r.With(jwtVerifier, merchantOnly).Post("/{product_id}/variants", productVariantHandler.Create())
+		r.With(jwtVerifier, merchantOnly).Put("/{product_id}/variants/{variant_id}", productVariantHandler.Update())
+		r.With(jwtVerifier, merchantOnly).Delete("/{product_id}/variants/{variant_id}", productVariantHandler.Delete())
+
+		r.Get("/{product_id}/categories", categoryHandler.ListProductCategories())
+		r.With(jwtVerifier, merchantOnly).Put("/{product_id}/categories", categoryHandler.SetProductCategories())
+	})
+
+	api.Route("/categories", func(r chi.Router) {
+		r.Use(idempotent)
+
+		r.Get("/", categoryHandler.List())
+		r.Get("/{category_id}", categoryHandler.Get())
+		r.With(jwtVerifier, merchantOnly).Post("/", categoryHandler.Create())
+		r.With(jwtVerifier, merchantOnly).Put("/{category_id}", categoryHandler.Update())
+		r.With(jwtVerifier, merchantOnly).Delete("/{category_id}", categoryHandler.Delete())
+	})
+
+	api.Route("/promotions", func(r chi.Router) {
+		r.Use(idempotent)
+
+		r.With(jwtVerifier, merchantOnly).Post("/", promotionHandler.Create())
+		r.With(optionalJWTVerifier).Post("/evaluate", promotionHandler.Evaluate())
+		r.With(jwtVerifier, merchantOnly).Get("/{promotion_id}", promotionHandler.Get())
+		r.With(jwtVerifier, merchantOnly).Delete("/{promotion_id}", promotionHandler.Delete())
+	})
+
+	api.Route("/cart", func(r chi.Router) {
+		r.Use(optionalJWTVerifier, idempotent)
+
+		r.Get("/", cartHandler.Get())
+		r.Delete("/", cartHandler.Clear())
+		r.Post("/items", cartHandler.AddItem())
+		r.Put("/items/{product_id}", cartHandler.UpdateItem())
+		r.Delete("/items/{product_id}", cartHandler.RemoveItem())
+	})
+
+	api.Route("/orders", func(r chi.Router) {
+		r.Use(jwtVerifier, idempotent)
+
+		r.With(customerOnly).Post("/", orderHandler.Create())
+		r.Get("/", orderHandler.List())
+		r.Get("/{order_id}", orderHandler.Get())
+		r.Put("/{order_id}/status", orderHandler.UpdateStatus())
+		r.With(customerOnly).Post("/{order_id}/checkout", paymentHandler.Checkout())
+	})
+
+	api.Route("/payments", func(r chi.Router) {
+		r.Post("/webhook", paymentHandler.Webhook())
 	})
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/app/repository"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
+)
+
+func newProductSearcher(mysqlClient mysql.Client, productRepository repository.ProductRepository) (repository.ProductSearcher, error) {
+	switch config.Cfg().SearchDriver {
+	case "mysql":
+		return repository.NewMySQLProductSearcher(mysqlClient, productRepository), nil
+	case "memory":
+		return repository.NewMemoryProductSearcher(context.Background(), mysqlClient, productRepository)
+	default:
+		return nil, fmt.Errorf("unknown search driver %q", config.Cfg().SearchDriver)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/SemmiDev/go-product/internal/db/mysql"
 	"github.com/SemmiDev/go-product/internal/db/redis"
 	"github.com/SemmiDev/go-product/internal/logger"
+	"github.com/SemmiDev/go-product/internal/security/token"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,21 +16,54 @@ import (
 )
 
 func Start() error {
+	_, err := token.Keys()
+	if err != nil {
+		return err
+	}
+
 	mysqlClient, err := mysql.NewClient()
 	if err != nil {
 		return err
 	}
 	defer mysqlClient.Close()
 
+	if config.Cfg().MysqlMigrateOnStart {
+		migrator, err := mysql.NewMigrator(mysqlClient)
+		if err != nil {
+			return err
+		}
+
+		err = migrator.Up(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
 	redisClient, err := redis.NewClient()
 	if err != nil {
 		return err
 	}
 	defer redisClient.Close()
 
+	fileStorage, err := newStorage()
+	if err != nil {
+		return err
+	}
+
+	router, err := NewRouter(mysqlClient, redisClient, fileStorage)
+	if err != nil {
+		return err
+	}
+
+	stopPurgeJob := startPurgeJob(mysqlClient, redisClient, fileStorage)
+	defer stopPurgeJob()
+
+	stopPriceRuleJob := startPriceRuleJob(mysqlClient, redisClient)
+	defer stopPriceRuleJob()
+
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Cfg().AppPort),
-		Handler: NewRouter(mysqlClient, redisClient),
+		Handler: router,
 	}
 
 	idleConnsClosed := make(chan struct{})
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/storage"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+func newStorage() (storage.Storage, error) {
+	switch config.Cfg().StorageDriver {
+	case "local":
+		return storage.NewLocalStorage(config.Cfg().StorageLocalDir, config.Cfg().StoragePublicURL)
+	case "s3":
+		return storage.NewS3Storage(storage.S3Options{
+			Endpoint:        config.Cfg().StorageS3Endpoint,
+			Region:          config.Cfg().StorageS3Region,
+			Bucket:          config.Cfg().StorageS3Bucket,
+			AccessKeyID:     config.Cfg().StorageS3AccessKey,
+			SecretAccessKey: config.Cfg().StorageS3SecretKey,
+			PublicURL:       config.Cfg().StoragePublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Cfg().StorageDriver)
+	}
+}
+
+// mountLocalStorage serves the local storage directory under the path of StoragePublicURL.
+func mountLocalStorage(router chi.Router) error {
+	if config.Cfg().StorageDriver != "local" {
+		return nil
+	}
+
+	publicURL, err := url.Parse(config.Cfg().StoragePublicURL)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.TrimSuffix(publicURL.Path, "/")
+	if prefix == "" {
+		return fmt.Errorf("StoragePublicURL needs a path to serve local storage from")
+	}
+
+	router.Handle(prefix+"/*", http.StripPrefix(prefix, http.FileServer(http.Dir(config.Cfg().StorageLocalDir))))
+	return nil
+}
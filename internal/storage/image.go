@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ImageInfo struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// InspectImage sniffs the content type from data itself, ignoring whatever the client
+// claimed, and decodes only the header to read the dimensions.
+func InspectImage(data []byte) (*ImageInfo, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	return &ImageInfo{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/config"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EncodeCursor serializes c as base64url(json) "." base64url(hmac) so clients can pass it
+// around but not forge one.
+func EncodeCursor(c *model.Cursor) string {
+	payload, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signCursor(encoded))
+}
+
+func DecodeCursor(s string) (*model.Cursor, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 2 {
+		return nil, constant.ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signCursor(parts[0])) {
+		return nil, constant.ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, constant.ErrInvalidCursor
+	}
+
+	cursor := new(model.Cursor)
+	err = json.Unmarshal(payload, cursor)
+	if err != nil {
+		return nil, constant.ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func signCursor(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(config.Cfg().PaginationCursorSecret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// GetPage reads limit/offset like GetPagination and switches to keyset pagination when
+// the request carries a cursor or asks for pagination=cursor.
+func GetPage(r *http.Request) (model.Page, error) {
+	limit, offset, err := GetPagination(r)
+	if err != nil {
+		return model.Page{}, err
+	}
+
+	page := model.Page{Limit: limit, Offset: offset}
+
+	cursorQuery := r.URL.Query().Get("cursor")
+	switch r.URL.Query().Get("pagination") {
+	case "", "offset":
+		page.UseCursor = cursorQuery != ""
+	case "cursor":
+		page.UseCursor = true
+	default:
+		return model.Page{}, constant.ErrUrlQueryParameter
+	}
+
+	if !page.UseCursor {
+		return page, nil
+	} else if r.URL.Query().Get("offset") != "" {
+		return model.Page{}, constant.ErrUrlQueryParameter
+	}
+
+	if cursorQuery != "" {
+		page.Cursor, err = DecodeCursor(cursorQuery)
+		if err != nil {
+			return model.Page{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// NewListResponse wraps data in the list envelope. Links repeat the request with the
+// offset or cursor of the neighbouring pages and are nil when there is no such page.
+func NewListResponse(r *http.Request, page model.Page, data interface{}, info *model.PageInfo) *model.ListResponse {
+	res := &model.ListResponse{
+		Data:    data,
+		Total:   info.Total,
+		Limit:   page.Limit,
+		HasMore: info.HasMore,
+	}
+
+	if !page.UseCursor {
+		res.Offset = &page.Offset
+		if info.HasMore {
+			res.Links.Next = pageLink(r, "offset", strconv.Itoa(page.Offset+page.Limit))
+		}
+		if page.Offset > 0 {
+			prev := page.Offset - page.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			res.Links.Prev = pageLink(r, "offset", strconv.Itoa(prev))
+		}
+		return res
+	}
+
+	if info.Next != nil {
+		next := EncodeCursor(info.Next)
+		res.NextCursor = &next
+		res.Links.Next = pageLink(r, "cursor", next)
+	}
+	if info.Prev != nil {
+		prev := EncodeCursor(info.Prev)
+		res.PrevCursor = &prev
+		res.Links.Prev = pageLink(r, "cursor", prev)
+	}
+	return res
+}
+
+func pageLink(r *http.Request, key, value string) *string {
+	query := r.URL.Query()
+	query.Set(key, value)
+	if key == "cursor" {
+		query.Del("offset")
+	}
+
+	link := r.URL.Path + "?" + query.Encode()
+	return &link
+}
+
+func MarshalList(w http.ResponseWriter, r *http.Request, page model.Page, data interface{}, info *model.PageInfo) {
+	MarshalPayload(w, http.StatusOK, NewListResponse(r, page, data, info))
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"encoding/base64"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := &model.Cursor{Sort: "-created_at,name", Keys: []string{"2021-06-01T00:00:00Z", "Ayam"}, ID: 42, Backward: true}
+
+	decoded, err := DecodeCursor(EncodeCursor(cursor))
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	encoded := EncodeCursor(&model.Cursor{Sort: "name", Keys: []string{"a"}, ID: 1})
+	parts := strings.Split(encoded, ".")
+
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"name","k":["a"],"i":2}`))
+	tests := map[string]string{
+		"changed payload":   forged + "." + parts[1],
+		"changed signature": parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte("signature")),
+		"no signature":      parts[0],
+		"empty signature":   parts[0] + ".",
+		"extra part":        encoded + ".x",
+		"not base64":        "!!!." + parts[1],
+		"empty":             "",
+	}
+	for name, cursor := range tests {
+		_, err := DecodeCursor(cursor)
+		assert.Equal(t, constant.ErrInvalidCursor, err, name)
+	}
+}
+
+func TestGetPage(t *testing.T) {
+	cursor := EncodeCursor(&model.Cursor{Sort: "name", Keys: []string{"a"}, ID: 1})
+
+	page, err := GetPage(httptest.NewRequest("GET", "/?limit=5&offset=10", nil))
+	require.NoError(t, err)
+	assert.Equal(t, model.Page{Limit: 5, Offset: 10}, page)
+
+	page, err = GetPage(httptest.NewRequest("GET", "/?pagination=cursor", nil))
+	require.NoError(t, err)
+	assert.True(t, page.UseCursor)
+	assert.Nil(t, page.Cursor)
+
+	page, err = GetPage(httptest.NewRequest("GET", "/?cursor="+cursor, nil))
+	require.NoError(t, err)
+	assert.True(t, page.UseCursor)
+	assert.Equal(t, int64(1), page.Cursor.ID)
+
+	_, err = GetPage(httptest.NewRequest("GET", "/?cursor="+cursor+"&offset=5", nil))
+	assert.Equal(t, constant.ErrUrlQueryParameter, err, "cursor and offset exclude each other")
+
+	_, err = GetPage(httptest.NewRequest("GET", "/?cursor=abc.def", nil))
+	assert.Equal(t, constant.ErrInvalidCursor, err)
+
+	_, err = GetPage(httptest.NewRequest("GET", "/?pagination=pages", nil))
+	assert.Equal(t, constant.ErrUrlQueryParameter, err)
+}
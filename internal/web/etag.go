@@ -0,0 +1,33 @@
+package web
+
+import (
+	"encoding/json"
+	"github.com/SemmiDev/go-product/internal/app/model"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"net/http"
+)
+
+// MarshalVersioned writes payload like MarshalPayload along with its ETag, or only the ETag
+// with 304 Not Modified when a GET already names it in If-None-Match.
+func MarshalVersioned(w http.ResponseWriter, r *http.Request, code int, version int64, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		MarshalError(w, http.StatusInternalServerError, constant.ErrServer)
+		return
+	}
+
+	etag := model.ETag(version, body)
+	w.Header().Set(constant.ETAG_HEADER, etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && model.MatchesETag(r.Header.Get(constant.IF_NONE_MATCH_HEADER), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(body, '\n'))
+}
+
+func GetPrecondition(r *http.Request) model.Precondition {
+	return model.ParsePrecondition(r.Header.Get(constant.IF_MATCH_HEADER))
+}
@@ -1,12 +1,13 @@
 package web
 
 import (
+	"github.com/SemmiDev/go-product/internal/app/model"
 	"github.com/SemmiDev/go-product/internal/config"
 	"github.com/SemmiDev/go-product/internal/constant"
 	"io"
-	"mime/multipart"
+	"io/ioutil"
+	"mime"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/go-chi/chi"
@@ -52,6 +53,15 @@ func GetUrlQueryInt64(r *http.Request, key string) (int64, error) {
 	return i, nil
 }
 
+// GetUrlQueryCurrency returns the optional ISO 4217 code under key, empty when absent.
+func GetUrlQueryCurrency(r *http.Request, key string) (string, error) {
+	currency := r.URL.Query().Get(key)
+	if currency != "" && !model.IsCurrency(currency) {
+		return "", constant.ErrUrlQueryParameter
+	}
+	return currency, nil
+}
+
 func GetPagination(r *http.Request) (limit, offset int, err error) {
 	limitQuery := r.URL.Query().Get("limit")
 	offsetQuery := r.URL.Query().Get("offset")
@@ -82,19 +92,89 @@ func GetPagination(r *http.Request) (limit, offset int, err error) {
 	return
 }
 
-func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
-	src, err := file.Open()
+// ReadUploadedFile reads the multipart file field of r, rejecting bodies and files larger
+// than maxBytes with constant.ErrImageTooLarge.
+func ReadUploadedFile(w http.ResponseWriter, r *http.Request, field string, maxBytes int64) ([]byte, error) {
+	// leave room for the multipart boundaries and the other form fields
+	maxBody := maxBytes + 1<<20
+	if r.ContentLength > maxBody {
+		return nil, constant.ErrImageTooLarge
+	}
+	body := limitBody(w, r, maxBody)
+
+	err := r.ParseMultipartForm(1 << 20)
+	if body.exceeded {
+		return nil, constant.ErrImageTooLarge
+	} else if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile(field)
 	if err != nil {
-		return err
+		return nil, constant.ErrRequestBody
 	}
-	defer src.Close()
+	defer file.Close()
 
-	out, err := os.Create(dst)
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxBytes+1))
 	if err != nil {
-		return err
+		return nil, constant.ErrRequestBody
+	} else if int64(len(data)) > maxBytes {
+		return nil, constant.ErrImageTooLarge
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, src)
-	return err
-}
\ No newline at end of file
+	return data, nil
+}
+
+// maxBytesBody is http.MaxBytesReader remembering whether it cut the body off, which the
+// error it returns only tells from Go 1.19 on. Bodies sent without a Content-Length are
+// only found to be too large while they are read.
+type maxBytesBody struct {
+	io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func limitBody(w http.ResponseWriter, r *http.Request, limit int64) *maxBytesBody {
+	body := &maxBytesBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit), limit: limit}
+	r.Body = body
+	return body
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// mergePatchMaxBytes bounds a merge patch body, far above what the few fields of a
+// merchant or product take.
+const mergePatchMaxBytes = 64 << 10
+
+// ReadMergePatch reads a JSON merge patch body, which may also be labelled plain JSON.
+// Other content types fail with constant.ErrPatchMediaType, bodies larger than
+// mergePatchMaxBytes with constant.ErrRequestTooLarge.
+func ReadMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != constant.MERGE_PATCH_MEDIA_TYPE && mediaType != "application/json") {
+		w.Header().Set(constant.ACCEPT_PATCH_HEADER, constant.MERGE_PATCH_MEDIA_TYPE)
+		return nil, constant.ErrPatchMediaType
+	}
+
+	if r.ContentLength > mergePatchMaxBytes {
+		return nil, constant.ErrRequestTooLarge
+	}
+	body := limitBody(w, r, mergePatchMaxBytes)
+
+	patch, err := ioutil.ReadAll(body)
+	if body.exceeded {
+		return nil, constant.ErrRequestTooLarge
+	} else if err != nil {
+		return nil, constant.ErrRequestBody
+	}
+	return patch, nil
+}
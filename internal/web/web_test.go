@@ -0,0 +1,90 @@
+package web
+
+import (
+	"bytes"
+	"github.com/SemmiDev/go-product/internal/constant"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func multipartBody(t *testing.T, field string, content []byte) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, "image.png")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestReadUploadedFile(t *testing.T) {
+	const maxBytes = 1024
+
+	body, contentType := multipartBody(t, "image", []byte("small"))
+	r := httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", contentType)
+	data, err := ReadUploadedFile(httptest.NewRecorder(), r, "image", maxBytes)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("small"), data)
+
+	body, contentType = multipartBody(t, "image", bytes.Repeat([]byte{1}, maxBytes+1))
+	r = httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", contentType)
+	_, err = ReadUploadedFile(httptest.NewRecorder(), r, "image", maxBytes)
+	assert.Equal(t, constant.ErrImageTooLarge, err)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("not multipart"))
+	r.Header.Set("Content-Type", contentType)
+	_, err = ReadUploadedFile(httptest.NewRecorder(), r, "image", maxBytes)
+	assert.Equal(t, constant.ErrRequestBody, err)
+}
+
+func TestReadUploadedFileOversizedBody(t *testing.T) {
+	const maxBytes = 1024
+	body, contentType := multipartBody(t, "image", bytes.Repeat([]byte{1}, 2<<20))
+
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body.Bytes()))
+	r.Header.Set("Content-Type", contentType)
+	_, err := ReadUploadedFile(httptest.NewRecorder(), r, "image", maxBytes)
+	assert.Equal(t, constant.ErrImageTooLarge, err, "declared length over the limit")
+
+	r = httptest.NewRequest("POST", "/", io.MultiReader(bytes.NewReader(body.Bytes())))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", contentType)
+	_, err = ReadUploadedFile(httptest.NewRecorder(), r, "image", maxBytes)
+	assert.Equal(t, constant.ErrImageTooLarge, err, "chunked body over the limit")
+}
+
+func TestReadMergePatch(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/", strings.NewReader(`{"name":"Shop"}`))
+	r.Header.Set("Content-Type", constant.MERGE_PATCH_MEDIA_TYPE+"; charset=utf-8")
+	patch, err := ReadMergePatch(httptest.NewRecorder(), r)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"Shop"}`, string(patch))
+
+	w := httptest.NewRecorder()
+	r = httptest.NewRequest("PATCH", "/", strings.NewReader(`[]`))
+	r.Header.Set("Content-Type", "application/json-patch+json")
+	_, err = ReadMergePatch(w, r)
+	assert.Equal(t, constant.ErrPatchMediaType, err)
+	assert.Equal(t, constant.MERGE_PATCH_MEDIA_TYPE, w.Header().Get(constant.ACCEPT_PATCH_HEADER))
+
+	large := `{"name":"` + strings.Repeat("a", mergePatchMaxBytes) + `"}`
+	r = httptest.NewRequest("PATCH", "/", strings.NewReader(large))
+	r.Header.Set("Content-Type", "application/json")
+	_, err = ReadMergePatch(httptest.NewRecorder(), r)
+	assert.Equal(t, constant.ErrRequestTooLarge, err, "declared length over the limit")
+
+	r = httptest.NewRequest("PATCH", "/", io.MultiReader(strings.NewReader(large)))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", "application/json")
+	_, err = ReadMergePatch(httptest.NewRecorder(), r)
+	assert.Equal(t, constant.ErrRequestTooLarge, err, "chunked body over the limit")
+}
@@ -1,13 +1,91 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"github.com/SemmiDev/go-product/internal/db/mysql"
 	"github.com/SemmiDev/go-product/internal/logger"
 	"github.com/SemmiDev/go-product/internal/server"
+	"os"
+	"strconv"
+	"text/tabwriter"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migrate(os.Args[2:])
+		if err != nil {
+			logger.Log().Fatal().Err(err).Msg("failed to run migrations")
+		}
+		return
+	}
+
 	err := server.Start()
 	if err != nil {
 		logger.Log().Fatal().Err(err).Msg("failed to run server")
 	}
-}
\ No newline at end of file
+}
+
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s migrate up|down [steps]|status|force <version> applied|reverted", os.Args[0])
+	}
+
+	mysqlClient, err := mysql.NewClient()
+	if err != nil {
+		return err
+	}
+	defer mysqlClient.Close()
+
+	migrator, err := mysql.NewMigrator(mysqlClient)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		return migrator.Down(ctx, steps)
+	case "force":
+		if len(args) != 3 || (args[2] != "applied" && args[2] != "reverted") {
+			return fmt.Errorf("usage: %s migrate force <version> applied|reverted", os.Args[0])
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.Force(ctx, version, args[2] == "applied")
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.Dirty {
+				state = "dirty"
+			} else if status.Applied {
+				state = "applied"
+			}
+			if status.AppliedAt.Valid {
+				appliedAt = status.AppliedAt.Time.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}